@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mausumi-ghadei-omniful/ims/db"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errBinStock = errors.New("not enough stock")
+
+// CreateHubBin
+func CreateHubBin(c *gin.Context) {
+	var hub models.Hub
+	if err := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c)).First(&hub, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hub not found"})
+		return
+	}
+
+	var bin models.HubBin
+	if err := c.ShouldBindJSON(&bin); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := bin.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	bin.ID = 0
+	bin.HubID = hub.ID
+	bin.Code = bin.BuildCode()
+
+	if err := db.DB.GetMasterDB(c.Request.Context()).Create(&bin).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bin"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Bin created", "bin": bin})
+}
+
+// GetHubBins
+func GetHubBins(c *gin.Context) {
+	dbconn := db.DB.GetMasterDB(c.Request.Context())
+
+	var hub models.Hub
+	if err := dbconn.Where("tenant_id = ?", tenantOf(c)).First(&hub, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hub not found"})
+		return
+	}
+
+	var bins []models.HubBin
+	query := dbconn.Where("hub_id = ?", hub.ID)
+	if zone := c.Query("zone"); zone != "" {
+		query = query.Where("zone = ?", zone)
+	}
+	if err := query.Order("code ASC").Find(&bins).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bins"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": bins})
+}
+
+// GetHubStock rolls bin stock up to hub level per SKU; stock not yet put away shows as unbinned
+func GetHubStock(c *gin.Context) {
+	dbconn := db.DB.GetMasterDB(c.Request.Context())
+
+	var hub models.Hub
+	if err := dbconn.Where("tenant_id = ?", tenantOf(c)).First(&hub, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hub not found"})
+		return
+	}
+
+	var inventories []models.Inventory
+	if err := dbconn.Where("tenant_id = ? AND location = ?", hub.TenantID, hub.Location).Find(&inventories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inventory"})
+		return
+	}
+
+	var binRows []struct {
+		SKU      string
+		BinID    uint
+		Code     string
+		Quantity int
+	}
+	err := dbconn.Table("bin_stocks AS s").
+		Select("s.sku, s.bin_id, b.code, s.quantity").
+		Joins("JOIN hub_bins b ON b.id = s.bin_id").
+		Where("b.hub_id = ? AND s.quantity > 0", hub.ID).
+		Order("b.code").Scan(&binRows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bin stock"})
+		return
+	}
+
+	type binQuantity struct {
+		BinID    uint   `json:"bin_id"`
+		BinCode  string `json:"bin_code"`
+		Quantity int    `json:"quantity"`
+	}
+	type skuStock struct {
+		SKU      string        `json:"sku"`
+		Total    int           `json:"total"`
+		Binned   int           `json:"binned"`
+		Unbinned int           `json:"unbinned"`
+		Bins     []binQuantity `json:"bins"`
+	}
+	stock := make(map[string]*skuStock)
+	for _, inv := range inventories {
+		stock[inv.SKU] = &skuStock{SKU: inv.SKU, Total: inv.Quantity, Bins: []binQuantity{}}
+	}
+	for _, row := range binRows {
+		s, ok := stock[row.SKU]
+		if !ok {
+			s = &skuStock{SKU: row.SKU, Bins: []binQuantity{}}
+			stock[row.SKU] = s
+		}
+		s.Binned += row.Quantity
+		s.Bins = append(s.Bins, binQuantity{BinID: row.BinID, BinCode: row.Code, Quantity: row.Quantity})
+	}
+
+	data := make([]skuStock, 0, len(stock))
+	for _, s := range stock {
+		s.Unbinned = s.Total - s.Binned
+		data = append(data, *s)
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].SKU < data[j].SKU })
+
+	c.JSON(http.StatusOK, gin.H{"hub": hub, "data": data})
+}
+
+// Putaway moves unbinned hub stock of a SKU into a bin
+func Putaway(c *gin.Context) {
+	var request struct {
+		BinID    uint   `json:"bin_id" binding:"required"`
+		SKU      string `json:"sku" binding:"required"`
+		Quantity int    `json:"quantity" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var stock *models.BinStock
+	status := http.StatusInternalServerError
+	err := db.DB.GetMasterDB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var bin models.HubBin
+		if err := tx.First(&bin, request.BinID).Error; err != nil {
+			status = http.StatusNotFound
+			return errors.New("Bin not found")
+		}
+		var hub models.Hub
+		if err := tx.Where("tenant_id = ?", tenantOf(c)).First(&hub, bin.HubID).Error; err != nil {
+			status = http.StatusNotFound
+			return errors.New("Bin not found")
+		}
+
+		var inventory models.Inventory
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("tenant_id = ? AND sku = ? AND location = ?", hub.TenantID, request.SKU, hub.Location).First(&inventory)
+		if res.Error != nil {
+			status = http.StatusNotFound
+			return fmt.Errorf("Inventory not found for SKU: %s at location: %s", request.SKU, hub.Location)
+		}
+
+		var binned int
+		err := tx.Table("bin_stocks AS s").Select("COALESCE(SUM(s.quantity), 0)").
+			Joins("JOIN hub_bins b ON b.id = s.bin_id").
+			Where("b.hub_id = ? AND s.sku = ?", hub.ID, request.SKU).Scan(&binned).Error
+		if err != nil {
+			return err
+		}
+		if inventory.Quantity-binned < request.Quantity {
+			status = http.StatusBadRequest
+			return fmt.Errorf("not enough unbinned stock: %d available", inventory.Quantity-binned)
+		}
+
+		stock, err = changeBinStock(tx, bin.ID, request.SKU, request.Quantity)
+		return err
+	})
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stock put away", "stock": stock})
+}
+
+// MoveBinStock moves stock of a SKU between two bins of the same hub
+func MoveBinStock(c *gin.Context) {
+	var request struct {
+		SKU       string `json:"sku" binding:"required"`
+		FromBinID uint   `json:"from_bin_id" binding:"required"`
+		ToBinID   uint   `json:"to_bin_id" binding:"required"`
+		Quantity  int    `json:"quantity" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.FromBinID == request.ToBinID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_bin_id and to_bin_id must differ"})
+		return
+	}
+
+	var from, to *models.BinStock
+	status := http.StatusInternalServerError
+	err := db.DB.GetMasterDB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var bins []models.HubBin
+		if err := tx.Where("id IN ?", []uint{request.FromBinID, request.ToBinID}).Find(&bins).Error; err != nil {
+			return err
+		}
+		if len(bins) != 2 {
+			status = http.StatusNotFound
+			return errors.New("Bin not found")
+		}
+		if bins[0].HubID != bins[1].HubID {
+			status = http.StatusBadRequest
+			return errors.New("bins belong to different hubs; use a stock transfer")
+		}
+		var owned int64
+		if err := tx.Model(&models.Hub{}).Where("id = ? AND tenant_id = ?", bins[0].HubID, tenantOf(c)).Count(&owned).Error; err != nil {
+			return err
+		}
+		if owned == 0 {
+			status = http.StatusNotFound
+			return errors.New("Bin not found")
+		}
+
+		var err error
+		if from, err = changeBinStock(tx, request.FromBinID, request.SKU, -request.Quantity); err != nil {
+			if errors.Is(err, errBinStock) {
+				status = http.StatusBadRequest
+			}
+			return err
+		}
+		to, err = changeBinStock(tx, request.ToBinID, request.SKU, request.Quantity)
+		return err
+	})
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stock moved", "from": from, "to": to})
+}
+
+// GeneratePickList plans bin picks for finalized orders at a hub and takes the picked
+// units out of their bins. Picks already taken for an order, by its stock reduction or an
+// earlier pick list, are listed again rather than taken twice. Lines that bins cannot
+// fully cover are reported as shortages.
+func GeneratePickList(c *gin.Context) {
+	var request struct {
+		Location string `json:"location" binding:"required"`
+		Lines    []struct {
+			OrderID  string `json:"order_id" binding:"required"`
+			SKU      string `json:"sku" binding:"required"`
+			Quantity int    `json:"quantity" binding:"required,gt=0"`
+		} `json:"lines" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	type shortage struct {
+		OrderID string `json:"order_id"`
+		SKU     string `json:"sku"`
+		Missing int    `json:"missing"`
+	}
+	var (
+		picks     []models.Pick
+		shortages []shortage
+		status    = http.StatusInternalServerError
+	)
+	err := db.DB.GetMasterDB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var hub models.Hub
+		if err := tx.Where("tenant_id = ? AND location = ?", tenantOf(c), request.Location).First(&hub).Error; err != nil {
+			status = http.StatusNotFound
+			return errors.New("Hub not found for location: " + request.Location)
+		}
+
+		for _, line := range request.Lines {
+			var taken []models.OrderPick
+			err := tx.Where("tenant_id = ? AND location = ? AND order_id = ? AND sku = ?", hub.TenantID, hub.Location, line.OrderID, line.SKU).
+				Order("id ASC").Find(&taken).Error
+			if err != nil {
+				return err
+			}
+			remaining := line.Quantity
+			for _, t := range taken {
+				if remaining == 0 {
+					break
+				}
+				pick := t.Pick
+				pick.Quantity = min(pick.Quantity, remaining)
+				picks = append(picks, pick)
+				remaining -= pick.Quantity
+			}
+			if remaining == 0 {
+				continue
+			}
+
+			var bins []models.BinQuantity
+			err = tx.Table("bin_stocks AS s").
+				Select("s.bin_id, b.code AS bin_code, s.quantity").
+				Joins("JOIN hub_bins b ON b.id = s.bin_id").
+				Where("b.hub_id = ? AND s.sku = ? AND s.quantity > 0", hub.ID, line.SKU).
+				Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "s"}}).
+				Scan(&bins).Error
+			if err != nil {
+				return err
+			}
+
+			linePicks, missing := models.PlanPicks(line.OrderID, line.SKU, bins, remaining)
+			for _, pick := range linePicks {
+				if _, err := changeBinStock(tx, pick.BinID, pick.SKU, -pick.Quantity); err != nil {
+					return err
+				}
+			}
+			if err := recordPicks(tx, hub.TenantID, hub.Location, line.OrderID, linePicks); err != nil {
+				return err
+			}
+			picks = append(picks, linePicks...)
+			if missing > 0 {
+				shortages = append(shortages, shortage{OrderID: line.OrderID, SKU: line.SKU, Missing: missing})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort.SliceStable(picks, func(i, j int) bool { return picks[i].BinCode < picks[j].BinCode })
+	c.JSON(http.StatusOK, gin.H{
+		"location":  request.Location,
+		"picks":     picks,
+		"shortages": shortages,
+	})
+}
+
+// changeBinStock applies delta to a SKU's stock in a bin, creating the row on first putaway.
+func changeBinStock(tx *gorm.DB, binID uint, sku string, delta int) (*models.BinStock, error) {
+	var stock models.BinStock
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("bin_id = ? AND sku = ?", binID, sku).Limit(1).Find(&stock)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		if delta < 0 {
+			return nil, fmt.Errorf("%w in bin %d for SKU: %s", errBinStock, binID, sku)
+		}
+		stock = models.BinStock{BinID: binID, SKU: sku, Quantity: delta, UpdatedAt: time.Now()}
+		return &stock, tx.Create(&stock).Error
+	}
+
+	if stock.Quantity+delta < 0 {
+		return nil, fmt.Errorf("%w in bin %d for SKU: %s", errBinStock, binID, sku)
+	}
+	stock.Quantity += delta
+	stock.UpdatedAt = time.Now()
+	err := tx.Model(&stock).Updates(map[string]interface{}{"quantity": stock.Quantity, "updated_at": stock.UpdatedAt}).Error
+	return &stock, err
+}
@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mausumi-ghadei-omniful/ims/db"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateCountSession opens a count for the listed SKUs at a location, or for every SKU
+// stocked there when no lines are given, capturing the current system quantities.
+func CreateCountSession(c *gin.Context) {
+	var session models.CountSession
+	if err := c.ShouldBindJSON(&session); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := session.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session.TenantID = tenantOf(c)
+	query := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ? AND location = ?", session.TenantID, session.Location)
+	if len(session.Lines) > 0 {
+		skus := make([]string, len(session.Lines))
+		for i, line := range session.Lines {
+			skus[i] = line.SKU
+		}
+		query = query.Where("sku IN ?", skus)
+	}
+	var inventories []models.Inventory
+	if err := query.Find(&inventories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inventory"})
+		return
+	}
+
+	system := make(map[string]int, len(inventories))
+	for _, inv := range inventories {
+		system[inv.SKU] = inv.Quantity
+	}
+	if len(session.Lines) == 0 {
+		for _, inv := range inventories {
+			session.Lines = append(session.Lines, models.CountLine{SKU: inv.SKU})
+		}
+		if len(session.Lines) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No inventory at location: " + session.Location})
+			return
+		}
+	}
+	for i := range session.Lines {
+		session.Lines[i] = models.CountLine{SKU: session.Lines[i].SKU, SystemQuantity: system[session.Lines[i].SKU]}
+	}
+	sort.Slice(session.Lines, func(i, j int) bool { return session.Lines[i].SKU < session.Lines[j].SKU })
+
+	session.ID = 0
+	session.Status = models.CountStatusOpen
+	session.ReasonCode = ""
+	session.ApprovedBy = ""
+	session.SubmittedAt = nil
+	session.ApprovedAt = nil
+
+	if err := db.DB.GetMasterDB(c.Request.Context()).Create(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create count session"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Count session created", "session": session})
+}
+
+// GetCountSessions
+func GetCountSessions(c *gin.Context) {
+	var sessions []models.CountSession
+
+	query := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c))
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
+	}
+
+	if err := query.Order("id ASC").Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch count sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// GetCountSession
+func GetCountSession(c *gin.Context) {
+	var session models.CountSession
+	if err := loadCountSession(db.DB.GetMasterDB(c.Request.Context()), tenantOf(c), c.Param("id"), &session); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Count session not found"})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// SubmitCounts records counted quantities; the session moves to submitted once every line is counted
+func SubmitCounts(c *gin.Context) {
+	var request struct {
+		Lines []struct {
+			SKU             string `json:"sku" binding:"required"`
+			CountedQuantity *int   `json:"counted_quantity" binding:"required"`
+		} `json:"lines" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	counts := make(map[string]int, len(request.Lines))
+	for _, line := range request.Lines {
+		counts[line.SKU] = *line.CountedQuantity
+	}
+
+	var session models.CountSession
+	status := http.StatusInternalServerError
+	err := db.DB.GetMasterDB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := loadCountSession(tx.Clauses(clause.Locking{Strength: "UPDATE"}), tenantOf(c), c.Param("id"), &session); err != nil {
+			status = http.StatusNotFound
+			return errors.New("Count session not found")
+		}
+		if session.Status == models.CountStatusApproved {
+			status = http.StatusConflict
+			return errors.New("count session is already approved")
+		}
+
+		complete, err := session.RecordCounts(counts)
+		if err != nil {
+			status = http.StatusBadRequest
+			return err
+		}
+		for _, line := range session.Lines {
+			if _, ok := counts[line.SKU]; !ok {
+				continue
+			}
+			err := tx.Model(&models.CountLine{}).Where("id = ?", line.ID).
+				Updates(map[string]interface{}{"counted_quantity": *line.CountedQuantity, "variance": line.Variance}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{"updated_at": time.Now()}
+		if complete && session.Status == models.CountStatusOpen {
+			now := time.Now()
+			session.Status = models.CountStatusSubmitted
+			session.SubmittedAt = &now
+			updates["status"] = session.Status
+			updates["submitted_at"] = now
+		}
+		return tx.Model(&models.CountSession{}).Where("id = ?", session.ID).Updates(updates).Error
+	})
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Counts recorded", "session": session})
+}
+
+// GetCountVariances lists counted lines that differ from the system quantity; ?format=csv downloads them
+func GetCountVariances(c *gin.Context) {
+	var session models.CountSession
+	if err := loadCountSession(db.DB.GetMasterDB(c.Request.Context()), tenantOf(c), c.Param("id"), &session); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Count session not found"})
+		return
+	}
+	variances := session.Variances()
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{"session_id": session.ID, "status": session.Status, "data": variances})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=count_%d_variances.csv", session.ID))
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"session_id", "location", "sku", "system_quantity", "counted_quantity", "variance", "reason_code"})
+	for _, line := range variances {
+		_ = w.Write([]string{
+			strconv.Itoa(int(session.ID)),
+			session.Location,
+			line.SKU,
+			strconv.Itoa(line.SystemQuantity),
+			strconv.Itoa(*line.CountedQuantity),
+			strconv.Itoa(line.Variance),
+			session.ReasonCode,
+		})
+	}
+	w.Flush()
+}
+
+// ApproveCountSession posts each variance as a stock adjustment with the given reason code.
+// Variances are applied as deltas so movements made while counting are kept.
+func ApproveCountSession(c *gin.Context) {
+	var request struct {
+		ReasonCode string `json:"reason_code" binding:"required"`
+		ApprovedBy string `json:"approved_by"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !models.AdjustmentReasons[request.ReasonCode] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown reason_code: " + request.ReasonCode})
+		return
+	}
+
+	var (
+		session     models.CountSession
+		adjustments []models.StockAdjustment
+		changes     []stockChange
+		status      = http.StatusInternalServerError
+	)
+	err := db.DB.GetMasterDB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := loadCountSession(tx.Clauses(clause.Locking{Strength: "UPDATE"}), tenantOf(c), c.Param("id"), &session); err != nil {
+			status = http.StatusNotFound
+			return errors.New("Count session not found")
+		}
+		if session.Status != models.CountStatusSubmitted {
+			status = http.StatusConflict
+			return fmt.Errorf("cannot approve count session in status %s", session.Status)
+		}
+
+		for _, line := range session.Variances() {
+			seed := models.Inventory{SellerID: session.SellerID}
+			change, err := adjustCountedInventory(tx, session.TenantID, line.SKU, session.Location, line.Variance, seed)
+			if err != nil {
+				if errors.Is(err, errInventoryNotFound) || errors.Is(err, errInsufficientStock) {
+					status = http.StatusBadRequest
+					return fmt.Errorf("%v for SKU: %s at location: %s", err, line.SKU, session.Location)
+				}
+				return err
+			}
+			changes = append(changes, *change)
+			adjustments = append(adjustments, models.StockAdjustment{
+				SessionID:  &session.ID,
+				SKU:        line.SKU,
+				Location:   session.Location,
+				Delta:      line.Variance,
+				ReasonCode: request.ReasonCode,
+			})
+		}
+		if len(adjustments) > 0 {
+			if err := tx.Create(&adjustments).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		session.Status = models.CountStatusApproved
+		session.ReasonCode = request.ReasonCode
+		session.ApprovedBy = request.ApprovedBy
+		session.ApprovedAt = &now
+		return tx.Model(&models.CountSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+			"status":      session.Status,
+			"reason_code": session.ReasonCode,
+			"approved_by": session.ApprovedBy,
+			"approved_at": now,
+			"updated_at":  now,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(changes) > 0 {
+		afterStockChange(changes)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Count session approved", "session": session, "adjustments": adjustments})
+}
+
+func loadCountSession(tx *gorm.DB, tenant, id string, session *models.CountSession) error {
+	return tx.Preload("Lines", func(q *gorm.DB) *gorm.DB {
+		return q.Order("sku ASC")
+	}).Where("tenant_id = ?", tenant).First(session, id).Error
+}
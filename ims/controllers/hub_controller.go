@@ -10,6 +10,7 @@ import (
 	"github.com/mausumi-ghadei-omniful/ims/db"
 	"github.com/mausumi-ghadei-omniful/ims/models"
 	"github.com/mausumi-ghadei-omniful/ims/redisclient"
+	"gorm.io/gorm"
 )
 
 // CreateHub
@@ -21,11 +22,19 @@ func CreateHub(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	hub.TenantID = tenantOf(c)
 
 	if err := hub.Validate(); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	for i := range hub.Bins {
+		if err := hub.Bins[i].Validate(); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		hub.Bins[i].Code = hub.Bins[i].BuildCode()
+	}
 
 	result := db.DB.GetMasterDB(c.Request.Context()).Create(&hub)
 	if result.Error != nil {
@@ -33,14 +42,7 @@ func CreateHub(c *gin.Context) {
 		return
 	}
 
-	go func() {
-		var updated []models.Hub
-		db.DB.GetMasterDB(context.Background()).Find(&updated)
-		data, err := json.Marshal(updated)
-		if err == nil {
-			redisclient.Client.Set(context.Background(), "All_hubs", string(data), 1*time.Hour)
-		}
-	}()
+	go invalidateHubCache(tenantOf(c))
 
 	c.JSON(200, gin.H{"message": "Hub created", "hub": hub})
 }
@@ -49,52 +51,93 @@ func CreateHub(c *gin.Context) {
 func GetHubs(c *gin.Context) {
 	var hubs []models.Hub
 	ctx := context.Background()
-	cacheKey := "All_hubs"
-
-	cached, err := redisclient.Client.Get(ctx, cacheKey)
-	if err == nil {
-		if err := json.Unmarshal([]byte(cached), &hubs); err == nil {
-			c.JSON(200, gin.H{
-				"source": "cache",
-				"data":   hubs,
-			})
-			return
+	tenantID := tenantOf(c)
+	cacheKey := hubCacheKey(tenantID)
+
+	// only the default first page is cached
+	cacheable := isPlainList(c)
+	if cacheable {
+		if cached, ok := redisclient.Get(ctx, cacheKey); ok {
+			var envelope listEnvelope
+			envelope.Data = &hubs
+			if err := json.Unmarshal([]byte(cached), &envelope); err == nil {
+				envelope.Source = "cache"
+				c.JSON(200, envelope)
+				return
+			}
 		}
 	}
 
-	query := db.DB.GetMasterDB(c.Request.Context())
+	page, err := models.ParsePage(c.Query("limit"), c.Query("cursor"), c.Query("sort"), map[string]string{
+		"id": "id", "name": "name", "location": "location", "updated_at": "updated_at",
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
-	tenantID := c.Query("tenant_id")
-	if tenantID != "" {
-		query = query.Where("tenant_id = ?", tenantID)
+	query := db.DB.GetMasterDB(c.Request.Context()).Model(&models.Hub{}).Where("tenant_id = ?", tenantID)
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
 	}
-	sellerID := c.Query("seller_id")
-	if sellerID != "" {
-		query = query.Where("seller_id = ?", sellerID)
+	query, err = applyCommonFilters(c, query, "name_prefix", "name")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
+	query = query.Session(&gorm.Session{})
 
-	res := query.Find(&hubs)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch hubs"})
+		return
+	}
+	res := applyPage(query, page).Find(&hubs)
 	if res.Error != nil {
 		c.JSON(500, gin.H{"error": "Failed to fetch hubs"})
 		return
 	}
+	hubs, next := models.TrimPage(hubs, page, func(hub models.Hub) (interface{}, uint) {
+		switch page.SortField {
+		case "name":
+			return hub.Name, hub.ID
+		case "location":
+			return hub.Location, hub.ID
+		case "updated_at":
+			return hub.UpdatedAt, hub.ID
+		}
+		return hub.ID, hub.ID
+	})
 
-	data, err := json.Marshal(hubs)
-	if err == nil {
-		_, _ = redisclient.Client.Set(ctx, cacheKey, string(data), 1*time.Hour)
+	envelope := listEnvelope{Source: "database", Data: hubs, NextCursor: next, Total: total}
+	if cacheable {
+		if data, err := json.Marshal(envelope); err == nil {
+			redisclient.Set(ctx, cacheKey, string(data), 1*time.Hour)
+		}
 	}
+	c.JSON(200, envelope)
+}
 
-	c.JSON(200, gin.H{
-		"source": "database",
-		"data":   hubs,
-	})
+// GetHub
+func GetHub(c *gin.Context) {
+	var hub models.Hub
+	if err := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c)).First(&hub, c.Param("id")).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Hub not found"})
+		return
+	}
+	c.Header("ETag", models.ETag(hub.Version))
+	c.JSON(200, hub)
 }
 
 // UpdateHub
 func UpdateHub(c *gin.Context) {
 	id := c.Param("id")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 	var hub models.Hub
-	find := db.DB.GetMasterDB(context.Background()).First(&hub, id)
+	find := db.DB.GetMasterDB(context.Background()).Where("tenant_id = ?", tenantOf(c)).First(&hub, id)
 	if find.Error != nil {
 		c.JSON(400, gin.H{"error": "Hub not found"})
 		return
@@ -105,45 +148,108 @@ func UpdateHub(c *gin.Context) {
 		c.JSON(400, gin.H{"error": update.Error()})
 		return
 	}
+	if err := models.ValidateCoordinates(updated.Latitude, updated.Longitude); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
-	hub.Name = updated.Name
-	hub.Location = updated.Location
-	hub.TenantID = updated.TenantID
-	hub.SellerID = updated.SellerID
+	saveHub(c, hub, version, map[string]interface{}{
+		"name":      updated.Name,
+		"location":  updated.Location,
+		"seller_id": updated.SellerID,
+		"latitude":  updated.Latitude,
+		"longitude": updated.Longitude,
+	})
+}
 
-	res := db.DB.GetMasterDB(context.Background()).Save(&hub)
-	if res.Error != nil {
-		c.JSON(500, gin.H{"error": "Failed to update hub"})
+// PatchHub
+func PatchHub(c *gin.Context) {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	var hub models.Hub
+	if err := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c)).First(&hub, c.Param("id")).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Hub not found"})
 		return
 	}
 
-	go func() {
-		var updated []models.Hub
-		db.DB.GetMasterDB(context.Background()).Find(&updated)
-		data, err := json.Marshal(updated)
-		if err == nil {
-			redisclient.Client.Set(context.Background(), "All_hubs", string(data), 1*time.Hour)
+	var patch struct {
+		Name     *string `json:"name"`
+		Location *string `json:"location"`
+		SellerID *string `json:"seller_id"`
+		// a coordinate pair replaces both values; send both to change either
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+	}
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if patch.Latitude != nil || patch.Longitude != nil {
+		if err := models.ValidateCoordinates(patch.Latitude, patch.Longitude); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
 		}
-	}()
+	}
+
+	updates := map[string]interface{}{}
+	if patch.Name != nil {
+		updates["name"] = *patch.Name
+	}
+	if patch.Location != nil {
+		updates["location"] = *patch.Location
+	}
+	if patch.SellerID != nil {
+		updates["seller_id"] = *patch.SellerID
+	}
+	if patch.Latitude != nil {
+		updates["latitude"] = *patch.Latitude
+		updates["longitude"] = *patch.Longitude
+	}
+	if len(updates) == 0 {
+		c.JSON(400, gin.H{"error": "no fields to update"})
+		return
+	}
+
+	saveHub(c, hub, version, updates)
+}
+
+func saveHub(c *gin.Context, previous models.Hub, version int, updates map[string]interface{}) {
+	dbconn := db.DB.GetMasterDB(c.Request.Context())
+	if err := updateIfVersion(dbconn, &models.Hub{}, previous.ID, version, updates); err != nil {
+		writeUpdateError(c, err, previous.Version, "Failed to update hub")
+		return
+	}
+
+	var hub models.Hub
+	if err := dbconn.First(&hub, previous.ID).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	go invalidateHubCache(hub.TenantID)
+	c.Header("ETag", models.ETag(hub.Version))
 	c.JSON(200, hub)
 }
 
 // DeleteHub
 func DeleteHub(c *gin.Context) {
 	id := c.Param("id")
-	res := db.DB.GetMasterDB(context.Background()).Delete(&models.Hub{}, id)
+	res := db.DB.GetMasterDB(context.Background()).Where("tenant_id = ?", tenantOf(c)).Delete(&models.Hub{}, id)
 	if res.Error != nil {
 		c.JSON(500, gin.H{"error": "Failed to delete hub"})
 		return
 	}
 
-	go func() {
-		var updated []models.Hub
-		db.DB.GetMasterDB(context.Background()).Find(&updated)
-		data, err := json.Marshal(updated)
-		if err == nil {
-			redisclient.Client.Set(context.Background(), "All_hubs", string(data), 1*time.Hour)
-		}
-	}()
+	go invalidateHubCache(tenantOf(c))
 	c.JSON(200, gin.H{"message": "Hub deleted"})
 }
+
+func hubCacheKey(tenantID string) string {
+	return "All_hubs:" + tenantID
+}
+
+func invalidateHubCache(tenantID string) {
+	redisclient.Del(context.Background(), hubCacheKey(tenantID))
+}
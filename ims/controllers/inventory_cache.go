@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mausumi-ghadei-omniful/ims/db"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"github.com/mausumi-ghadei-omniful/ims/redisclient"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+const (
+	inventoryRowTTL      = 10 * time.Minute
+	inventoryRowLockTTL  = 3 * time.Second
+	inventoryRowLockWait = 50 * time.Millisecond
+	inventoryRowLockPoll = 10
+)
+
+// inventoryRowGroup coalesces concurrent misses for the same row inside this process.
+var inventoryRowGroup singleflight.Group
+
+// cacheStore is the part of Redis the row cache uses.
+type cacheStore interface {
+	Available() bool
+	Get(ctx context.Context, key string) (string, bool)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) bool
+	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) bool
+}
+
+type redisStore struct{}
+
+func (redisStore) Available() bool { return redisclient.Available() }
+func (redisStore) Get(ctx context.Context, key string) (string, bool) {
+	return redisclient.Get(ctx, key)
+}
+func (redisStore) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) bool {
+	return redisclient.Set(ctx, key, value, ttl)
+}
+func (redisStore) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) bool {
+	return redisclient.SetNX(ctx, key, value, ttl)
+}
+
+// rowCache and loadRow are swapped out by tests.
+var (
+	rowCache cacheStore = redisStore{}
+	loadRow             = func(ctx context.Context, tenant, sku, location string) (*models.Inventory, error) {
+		var inv models.Inventory
+		err := db.DB.GetMasterDB(ctx).Where("tenant_id = ? AND sku = ? AND location = ?", tenant, sku, location).First(&inv).Error
+		return &inv, err
+	}
+)
+
+func inventoryRowKey(tenant, sku, location string) string {
+	return fmt.Sprintf("inventory:row:%s:%s:%s", tenant, sku, location)
+}
+
+// inventoryListKey caches GetInventories results for a tenant and its optional sku/location filters.
+func inventoryListKey(tenant, sku, location string) string {
+	return fmt.Sprintf("inventory:list:%s:%s:%s", tenant, sku, location)
+}
+
+// invalidateInventoryCache drops every list cache entry that can contain the given rows.
+func invalidateInventoryCache(ctx context.Context, rows ...models.Inventory) {
+	var keys []string
+	for _, row := range rows {
+		keys = append(keys,
+			inventoryListKey(row.TenantID, "", ""),
+			inventoryListKey(row.TenantID, row.SKU, ""),
+			inventoryListKey(row.TenantID, "", row.Location),
+			inventoryListKey(row.TenantID, row.SKU, row.Location),
+		)
+	}
+	redisclient.Del(ctx, keys...)
+}
+
+// getInventoryRow reads a single (sku, location) row of a tenant through the Redis row cache.
+// Misses are coalesced per process and guarded by a short Redis lock across
+// processes, so only one caller reloads a hot row from Postgres at a time. A row
+// deleted or moved away is answered with gorm.ErrRecordNotFound.
+func getInventoryRow(ctx context.Context, tenant, sku, location string) (*models.Inventory, string, error) {
+	key := inventoryRowKey(tenant, sku, location)
+	if inv, ok := cachedInventoryRow(ctx, key); ok {
+		if inv.ID == 0 {
+			return nil, "cache", gorm.ErrRecordNotFound
+		}
+		return inv, "cache", nil
+	}
+
+	// The load is shared by every caller waiting on key, so it must not be cancelled
+	// with whichever request happened to start it.
+	loadCtx := context.WithoutCancel(ctx)
+	v, err, _ := inventoryRowGroup.Do(key, func() (interface{}, error) {
+		return loadInventoryRow(loadCtx, key, tenant, sku, location)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return v.(*models.Inventory), "database", nil
+}
+
+// loadInventoryRow reloads a missed row. The lock is left to expire rather than deleted,
+// since by then it may belong to another process; callers waiting on it return as soon
+// as the row is cached.
+func loadInventoryRow(ctx context.Context, key, tenant, sku, location string) (*models.Inventory, error) {
+	if rowCache.Available() && !rowCache.SetNX(ctx, "lock:"+key, "1", inventoryRowLockTTL) {
+		// Someone else is reloading the row; give them a moment before going to Postgres.
+		for i := 0; i < inventoryRowLockPoll && rowCache.Available(); i++ {
+			time.Sleep(inventoryRowLockWait)
+			if inv, ok := cachedInventoryRow(ctx, key); ok {
+				if inv.ID == 0 {
+					return nil, gorm.ErrRecordNotFound
+				}
+				return inv, nil
+			}
+		}
+	}
+
+	inv, err := loadRow(ctx, tenant, sku, location)
+	if err != nil {
+		return nil, err
+	}
+	fillInventoryRow(ctx, inv)
+	return inv, nil
+}
+
+func cachedInventoryRow(ctx context.Context, key string) (*models.Inventory, bool) {
+	cached, ok := rowCache.Get(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	var inv models.Inventory
+	if err := json.Unmarshal([]byte(cached), &inv); err != nil {
+		return nil, false
+	}
+	return &inv, true
+}
+
+// fillInventoryRow caches a row just reloaded from Postgres, but only into an empty key:
+// a mutation committed while the row was loading has already written its newer row.
+func fillInventoryRow(ctx context.Context, inv *models.Inventory) {
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return
+	}
+	rowCache.SetNX(ctx, inventoryRowKey(inv.TenantID, inv.SKU, inv.Location), string(data), inventoryRowTTL)
+}
+
+// storeInventoryRow writes a committed row through to the cache unless the cached copy
+// of the same row is already as new. inv.Version must be the committed version.
+func storeInventoryRow(ctx context.Context, inv models.Inventory) {
+	key := inventoryRowKey(inv.TenantID, inv.SKU, inv.Location)
+	if cached, ok := cachedInventoryRow(ctx, key); ok && cached.ID == inv.ID && cached.Version >= inv.Version {
+		return
+	}
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return
+	}
+	rowCache.Set(ctx, key, string(data), inventoryRowTTL)
+}
+
+// dropInventoryRow marks the row's key as holding no row, after the row was deleted or
+// moved to another sku or location. The marker (a row without ID) keeps a reload that
+// started earlier from caching the old row; writing a row to the key replaces it.
+func dropInventoryRow(ctx context.Context, inv models.Inventory) {
+	data, err := json.Marshal(models.Inventory{Version: inv.Version})
+	if err != nil {
+		return
+	}
+	rowCache.Set(ctx, inventoryRowKey(inv.TenantID, inv.SKU, inv.Location), string(data), inventoryRowTTL)
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm"
+)
+
+// memCache is an in-process cacheStore; down makes it behave like an unreachable Redis
+type memCache struct {
+	mu     sync.Mutex
+	values map[string]string
+	down   bool
+}
+
+func (m *memCache) Available() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.down
+}
+
+func (m *memCache) Get(_ context.Context, key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.values[key]
+	return value, ok && !m.down
+}
+
+func (m *memCache) Set(_ context.Context, key string, value interface{}, _ time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return false
+	}
+	m.values[key] = value.(string)
+	return true
+}
+
+func (m *memCache) SetNX(_ context.Context, key string, value interface{}, _ time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.values[key]; ok || m.down {
+		return false
+	}
+	m.values[key] = value.(string)
+	return true
+}
+
+// useRowCache points the row cache at an empty memCache and loadRow at load, counting
+// loads, until the test ends
+func useRowCache(t *testing.T, load func() (*models.Inventory, error)) (*memCache, *int) {
+	cache := &memCache{values: map[string]string{}}
+	loads := 0
+	previousCache, previousLoad := rowCache, loadRow
+	rowCache = cache
+	loadRow = func(context.Context, string, string, string) (*models.Inventory, error) {
+		loads++
+		return load()
+	}
+	t.Cleanup(func() { rowCache, loadRow = previousCache, previousLoad })
+	return cache, &loads
+}
+
+func cachedRow() *models.Inventory {
+	return &models.Inventory{ID: 7, TenantID: "tenant1", SKU: "SKU001", Location: "A1", Quantity: 10, Version: 3}
+}
+
+// TestGetInventoryRowFillsOnMiss
+func TestGetInventoryRowFillsOnMiss(t *testing.T) {
+	_, loads := useRowCache(t, func() (*models.Inventory, error) { return cachedRow(), nil })
+	ctx := context.Background()
+
+	inv, source, err := getInventoryRow(ctx, "tenant1", "SKU001", "A1")
+	if err != nil || source != "database" || inv.Quantity != 10 {
+		t.Fatalf("first read = %+v, %q, %v; want the row from the database", inv, source, err)
+	}
+	inv, source, err = getInventoryRow(ctx, "tenant1", "SKU001", "A1")
+	if err != nil || source != "cache" || inv.Quantity != 10 {
+		t.Fatalf("second read = %+v, %q, %v; want the row from the cache", inv, source, err)
+	}
+	if *loads != 1 {
+		t.Errorf("loads = %d, want 1", *loads)
+	}
+}
+
+// TestGetInventoryRowWaitsForLockHolder
+func TestGetInventoryRowWaitsForLockHolder(t *testing.T) {
+	cache, loads := useRowCache(t, func() (*models.Inventory, error) { return cachedRow(), nil })
+	ctx := context.Background()
+	key := inventoryRowKey("tenant1", "SKU001", "A1")
+	cache.SetNX(ctx, "lock:"+key, "1", inventoryRowLockTTL)
+
+	// another process holds the lock and fills the row shortly after
+	go func() {
+		time.Sleep(inventoryRowLockWait)
+		storeInventoryRow(ctx, *cachedRow())
+	}()
+
+	inv, _, err := getInventoryRow(ctx, "tenant1", "SKU001", "A1")
+	if err != nil || inv.Quantity != 10 {
+		t.Fatalf("read = %+v, %v; want the row the lock holder cached", inv, err)
+	}
+	if *loads != 0 {
+		t.Errorf("loads = %d, want 0 while another process reloads the row", *loads)
+	}
+	if _, ok := cache.Get(ctx, "lock:"+key); !ok {
+		t.Error("waiter deleted a lock it does not own")
+	}
+}
+
+// TestGetInventoryRowWithoutRedis
+func TestGetInventoryRowWithoutRedis(t *testing.T) {
+	cache, loads := useRowCache(t, func() (*models.Inventory, error) { return cachedRow(), nil })
+	cache.down = true
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		inv, source, err := getInventoryRow(ctx, "tenant1", "SKU001", "A1")
+		if err != nil || source != "database" || inv.Quantity != 10 {
+			t.Fatalf("read %d = %+v, %q, %v; want the row from the database", i, inv, source, err)
+		}
+	}
+	if *loads != 2 {
+		t.Errorf("loads = %d, want every read to go to the database", *loads)
+	}
+}
+
+// TestStaleFillDoesNotOverwriteCommittedRow
+func TestStaleFillDoesNotOverwriteCommittedRow(t *testing.T) {
+	ctx := context.Background()
+	committed := *cachedRow()
+	committed.Quantity, committed.Version = 4, 4
+	// the stock changes and is written through while the reader is still loading
+	useRowCache(t, func() (*models.Inventory, error) {
+		storeInventoryRow(ctx, committed)
+		return cachedRow(), nil
+	})
+
+	if _, _, err := getInventoryRow(ctx, "tenant1", "SKU001", "A1"); err != nil {
+		t.Fatalf("getInventoryRow() error = %v", err)
+	}
+	inv, source, _ := getInventoryRow(ctx, "tenant1", "SKU001", "A1")
+	if source != "cache" || inv.Quantity != 4 {
+		t.Errorf("cached row = %+v from %q, want the committed quantity 4", inv, source)
+	}
+
+	// an older write arriving late is ignored as well
+	storeInventoryRow(ctx, *cachedRow())
+	if inv, _, _ := getInventoryRow(ctx, "tenant1", "SKU001", "A1"); inv.Quantity != 4 {
+		t.Errorf("cached quantity = %d after an older write, want 4", inv.Quantity)
+	}
+}
+
+// TestDroppedRowIsNotRefilled
+func TestDroppedRowIsNotRefilled(t *testing.T) {
+	ctx := context.Background()
+	// the row is deleted while the reader is still loading it
+	useRowCache(t, func() (*models.Inventory, error) {
+		dropInventoryRow(ctx, *cachedRow())
+		return cachedRow(), nil
+	})
+
+	if _, _, err := getInventoryRow(ctx, "tenant1", "SKU001", "A1"); err != nil {
+		t.Fatalf("getInventoryRow() error = %v", err)
+	}
+	if _, _, err := getInventoryRow(ctx, "tenant1", "SKU001", "A1"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("read after delete error = %v, want ErrRecordNotFound", err)
+	}
+
+	// a row created again at the key replaces the marker
+	created := *cachedRow()
+	created.ID, created.Version = 8, 1
+	storeInventoryRow(ctx, created)
+	if inv, _, err := getInventoryRow(ctx, "tenant1", "SKU001", "A1"); err != nil || inv.ID != 8 {
+		t.Errorf("read after re-create = %+v, %v; want row 8", inv, err)
+	}
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,26 +12,10 @@ import (
 	"github.com/mausumi-ghadei-omniful/ims/db"
 	"github.com/mausumi-ghadei-omniful/ims/models"
 	"github.com/mausumi-ghadei-omniful/ims/redisclient"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
-func invalidateInventoryCache(ctx context.Context) {
-
-	cacheKeys := []string{
-		"inventory:all",
-		"inventory::",
-		"inventory:s003:mausmi",
-		"inventory:s003:",
-		"inventory::mausmi",
-	}
-
-	for _, key := range cacheKeys {
-		redisclient.Client.Del(ctx, key)
-	}
-
-	redisclient.Client.Set(ctx, "inventory:clear", "", 1*time.Second)
-}
-
 // CreateInventory
 func CreateInventory(c *gin.Context) {
 	var item models.Inventory
@@ -39,6 +24,14 @@ func CreateInventory(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	item.TenantID = tenantOf(c)
+
+	if item.Quantity > 0 {
+		if err := rejectSerialless(db.DB.GetMasterDB(c.Request.Context()), item.TenantID, item.SKU); err != nil {
+			writeSerialError(c, err)
+			return
+		}
+	}
 
 	res := db.DB.GetMasterDB(c.Request.Context()).Create(&item)
 	if res.Error != nil {
@@ -46,7 +39,7 @@ func CreateInventory(c *gin.Context) {
 		return
 	}
 
-	go invalidateInventoryCache(context.Background())
+	afterStockChange([]stockChange{{Inventory: item, Previous: 0}})
 
 	c.JSON(200, gin.H{
 		"message": "Inventory item created successfully",
@@ -59,36 +52,104 @@ func GetInventories(c *gin.Context) {
 	var inv []models.Inventory
 
 	ctx := context.Background()
+	tenant := tenantOf(c)
 	sku := c.Query("sku")
 	location := c.Query("location")
 
-	var cacheKey string
-	if sku == "" && location == "" {
-		cacheKey = "inventory:all"
-	} else {
-		cacheKey = fmt.Sprintf("inventory:%s:%s", sku, location)
-	}
-	cached, err := redisclient.Client.Get(ctx, cacheKey)
-	if err == nil && cached != "" {
-		if err := json.Unmarshal([]byte(cached), &inv); err == nil {
-			c.JSON(200, gin.H{
-				"source": "cache",
-				"data":   inv,
-			})
+	if asOfParam := c.Query("as_of"); asOfParam != "" {
+		asOf, err := models.ParseAsOf(asOfParam)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		positions, err := inventoryAsOf(db.DB.GetMasterDB(c.Request.Context()), tenant, asOf, sku, location)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{
+			"source": "snapshot",
+			"as_of":  asOf,
+			"data":   positions,
+		})
+		return
+	}
+
+	// a sku+location pair is at most one row, so a first page of it (clients send their
+	// limit and sort on every request) is served from the row cache as well
+	if sku != "" && location != "" && isPlainList(c, "sku", "location", "limit", "sort") {
+		item, source, err := getInventoryRow(c.Request.Context(), tenant, sku, location)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(200, listEnvelope{Source: "database", Data: []models.Inventory{}})
+				return
+			}
+			c.JSON(500, gin.H{"error": err.Error()})
 			return
 		}
+		c.JSON(200, listEnvelope{Source: source, Data: []models.Inventory{*item}, Total: 1})
+		return
 	}
 
-	query := db.DB.GetMasterDB(c.Request.Context())
+	// only the default first page of a sku/location listing is cached
+	cacheable := isPlainList(c, "sku", "location")
+	cacheKey := inventoryListKey(tenant, sku, location)
+	if cacheable {
+		if cached, ok := redisclient.Get(ctx, cacheKey); ok {
+			var envelope listEnvelope
+			envelope.Data = &inv
+			if err := json.Unmarshal([]byte(cached), &envelope); err == nil {
+				envelope.Source = "cache"
+				c.JSON(200, envelope)
+				return
+			}
+		}
+	}
+
+	page, err := models.ParsePage(c.Query("limit"), c.Query("cursor"), c.Query("sort"), map[string]string{
+		"id": "id", "sku": "sku", "location": "location", "quantity": "quantity", "updated_at": "updated_at",
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	minQuantity, err := quantityFilter(c, "min_quantity")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	maxQuantity, err := quantityFilter(c, "max_quantity")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := db.DB.GetMasterDB(c.Request.Context()).Model(&models.Inventory{}).Where("tenant_id = ?", tenant)
 	if sku != "" {
 		query = query.Where("sku = ?", sku)
 	}
 	if location != "" {
 		query = query.Where("location = ?", location)
 	}
-	query = query.Order("id ASC")
+	if minQuantity != nil {
+		query = query.Where("quantity >= ?", *minQuantity)
+	}
+	if maxQuantity != nil {
+		query = query.Where("quantity <= ?", *maxQuantity)
+	}
+	query, err = applyCommonFilters(c, query, "sku_prefix", "sku")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	query = query.Session(&gorm.Session{})
 
-	res := query.Find(&inv)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	res := applyPage(query, page).Find(&inv)
 	if res.Error != nil {
 		c.JSON(500, gin.H{"error": res.Error.Error()})
 		return
@@ -97,30 +158,52 @@ func GetInventories(c *gin.Context) {
 		if inv[i].Quantity < 0 {
 			inv[i].Quantity = 0
 		}
-		if inv[i].SKU == "" {
-			inv[i].SKU = ""
+	}
+	inv, next := models.TrimPage(inv, page, func(item models.Inventory) (interface{}, uint) {
+		switch page.SortField {
+		case "sku":
+			return item.SKU, item.ID
+		case "location":
+			return item.Location, item.ID
+		case "quantity":
+			return item.Quantity, item.ID
+		case "updated_at":
+			return item.UpdatedAt, item.ID
 		}
-		if inv[i].Location == "" {
-			inv[i].Location = ""
+		return item.ID, item.ID
+	})
+
+	envelope := listEnvelope{Source: "database", Data: inv, NextCursor: next, Total: total}
+	if cacheable {
+		if data, err := json.Marshal(envelope); err == nil {
+			redisclient.Set(ctx, cacheKey, string(data), 1*time.Hour)
 		}
 	}
-	data, err := json.Marshal(inv)
-	if err == nil {
-		_, _ = redisclient.Client.Set(ctx, cacheKey, string(data), 1*time.Hour)
-	}
+	c.JSON(200, envelope)
+}
 
-	c.JSON(200, gin.H{
-		"source": "database",
-		"data":   inv,
-	})
+// GetInventory
+func GetInventory(c *gin.Context) {
+	var inventory models.Inventory
+	res := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c)).First(&inventory, c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Inventory not found"})
+		return
+	}
+	c.Header("ETag", models.ETag(inventory.Version))
+	c.JSON(http.StatusOK, inventory)
 }
 
 // UpdateInventory
 func UpdateInventory(c *gin.Context) {
 	id := c.Param("id")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
 	var inventory models.Inventory
-	res := db.DB.GetMasterDB(context.Background()).First(&inventory, id)
+	res := db.DB.GetMasterDB(context.Background()).Where("tenant_id = ?", tenantOf(c)).First(&inventory, id)
 	if res.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Inventory not found"})
 		return
@@ -133,33 +216,171 @@ func UpdateInventory(c *gin.Context) {
 		return
 	}
 
-	inventory.ProductID = updatedData.ProductID
-	inventory.SKU = updatedData.SKU
-	inventory.Quantity = updatedData.Quantity
-	inventory.Location = updatedData.Location
+	saveInventory(c, inventory, version, map[string]interface{}{
+		"product_id": updatedData.ProductID,
+		"sku":        updatedData.SKU,
+		"quantity":   updatedData.Quantity,
+		"location":   updatedData.Location,
+	})
+}
+
+// PatchInventory
+func PatchInventory(c *gin.Context) {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var inventory models.Inventory
+	res := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c)).First(&inventory, c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Inventory not found"})
+		return
+	}
 
-	save := db.DB.GetMasterDB(context.Background()).Save(&inventory)
-	if save.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var patch struct {
+		ProductID *string `json:"product_id"`
+		SKU       *string `json:"sku"`
+		Quantity  *int    `json:"quantity"`
+		Location  *string `json:"location"`
+		SellerID  *string `json:"seller_id"`
+	}
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	go invalidateInventoryCache(context.Background())
+	updates := map[string]interface{}{}
+	if patch.ProductID != nil {
+		updates["product_id"] = *patch.ProductID
+	}
+	if patch.SKU != nil {
+		updates["sku"] = *patch.SKU
+	}
+	if patch.Quantity != nil {
+		updates["quantity"] = *patch.Quantity
+	}
+	if patch.Location != nil {
+		updates["location"] = *patch.Location
+	}
+	if patch.SellerID != nil {
+		updates["seller_id"] = *patch.SellerID
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+		return
+	}
 
+	saveInventory(c, inventory, version, updates)
+}
+
+// saveInventory applies updates to previous if its version still matches and answers with the stored row.
+// A new quantity is applied as a stock delta, so lots and bins follow it, and the row may
+// only move to another sku or location while no lots, bins or serials are kept under it.
+func saveInventory(c *gin.Context, previous models.Inventory, version int, updates map[string]interface{}) {
+	dbconn := db.DB.GetMasterDB(c.Request.Context())
+	if arrivesWithoutSerials(previous, updates) {
+		sku := previous.SKU
+		if s, ok := updates["sku"].(string); ok && s != "" {
+			sku = s
+		}
+		if err := rejectSerialless(dbconn, previous.TenantID, sku); err != nil {
+			writeSerialError(c, err)
+			return
+		}
+	}
+	quantity, setsQuantity := updates["quantity"].(int)
+	delete(updates, "quantity")
+
+	current := previous
+	var change *stockChange
+	err := dbconn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, previous.ID).Error; err != nil {
+			return err
+		}
+		if version > 0 && current.Version != version {
+			return errStaleVersion
+		}
+		if rekeysInventory(current, updates) {
+			if err := rejectTrackedRekey(tx, current); err != nil {
+				return err
+			}
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&current).Clauses(clause.Returning{}).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		delta := 0
+		if setsQuantity {
+			delta = quantity - current.Quantity
+		}
+		var err error
+		change, err = setStockDelta(tx, current, delta)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errStaleVersion):
+			writeUpdateError(c, err, current.Version, "Failed to update inventory")
+		case errors.Is(err, errRekeyWithStock), errors.Is(err, errInsufficientStock):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update inventory"})
+		}
+		return
+	}
+
+	inventory := change.Inventory
+	if inventory.SKU != previous.SKU || inventory.Location != previous.Location {
+		dropInventoryRow(context.Background(), previous)
+		invalidateInventoryCache(context.Background(), previous)
+	}
+	afterStockChange([]stockChange{{Inventory: inventory, Previous: previous.Quantity}})
+
+	c.Header("ETag", models.ETag(inventory.Version))
 	c.JSON(http.StatusOK, inventory)
 }
 
+// arrivesWithoutSerials reports whether updates add stock to the row, by raising its
+// quantity or by moving its quantity to another SKU or hub
+func arrivesWithoutSerials(previous models.Inventory, updates map[string]interface{}) bool {
+	quantity := previous.Quantity
+	if q, ok := updates["quantity"].(int); ok {
+		quantity = q
+	}
+	if quantity <= 0 {
+		return false
+	}
+	if quantity > previous.Quantity {
+		return true
+	}
+	for field, current := range map[string]string{"sku": previous.SKU, "location": previous.Location} {
+		if v, ok := updates[field].(string); ok && v != "" && v != current {
+			return true
+		}
+	}
+	return false
+}
+
 // DeleteInventory
 func DeleteInventory(c *gin.Context) {
 	id := c.Param("id")
 
-	result := db.DB.GetMasterDB(context.Background()).Delete(&models.Inventory{}, id)
+	var inventory models.Inventory
+	if err := db.DB.GetMasterDB(context.Background()).Where("tenant_id = ?", tenantOf(c)).First(&inventory, id).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Inventory not found"})
+		return
+	}
+
+	result := db.DB.GetMasterDB(context.Background()).Delete(&inventory)
 	if result.Error != nil {
 		c.JSON(500, gin.H{"error": "Failed to delete inventory"})
 		return
 	}
 
-	go invalidateInventoryCache(context.Background())
+	invalidateInventoryCache(context.Background(), inventory)
+	dropInventoryRow(context.Background(), inventory)
 
 	c.JSON(200, gin.H{"message": "Inventory deleted successfully"})
 }
@@ -173,38 +394,82 @@ func UpsertInventory(c *gin.Context) {
 		c.JSON(400, gin.H{
 			"error": err.Error(),
 		})
+		return
 	}
+	inv.TenantID = tenantOf(c)
 
 	dbconn := db.DB.GetMasterDB(c.Request.Context())
 
-	res := dbconn.Clauses(clause.OnConflict{
-		Columns: []clause.Column{
-			{Name: "sku"},
-			{Name: "location"},
-		},
-		UpdateAll: true,
-	}).Create(&inv)
+	previous := 0
+	var existing models.Inventory
+	if dbconn.Where("tenant_id = ? AND sku = ? AND location = ?", inv.TenantID, inv.SKU, inv.Location).First(&existing).Error == nil {
+		previous = existing.Quantity
+	}
+	if inv.Quantity > previous {
+		if err := rejectSerialless(dbconn, inv.TenantID, inv.SKU); err != nil {
+			writeSerialError(c, err)
+			return
+		}
+	}
 
-	if res.Error != nil {
+	// the row is created empty when missing, then brought to the quantity as a stock delta
+	// so lots and bins follow it like any other stock change
+	var change *stockChange
+	err = dbconn.Transaction(func(tx *gorm.DB) error {
+		seed := inv
+		seed.ID, seed.Quantity = 0, 0
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "sku"}, {Name: "location"}},
+			DoNothing: true,
+		}).Create(&seed).Error
+		if err != nil {
+			return err
+		}
+
+		var current models.Inventory
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("tenant_id = ? AND sku = ? AND location = ?", inv.TenantID, inv.SKU, inv.Location).
+			First(&current).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&current).Clauses(clause.Returning{}).Updates(map[string]interface{}{
+			"product_id": inv.ProductID,
+			"seller_id":  inv.SellerID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		change, err = setStockDelta(tx, current, inv.Quantity-current.Quantity)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errInsufficientStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{
 			"error": "Failed to upsert",
 		})
 		return
 	}
-	go invalidateInventoryCache(context.Background())
+	afterStockChange([]stockChange{*change})
 
 	c.JSON(200, gin.H{
 		"message": "Inventory upserted successfully",
-		"item":    inv,
+		"item":    change.Inventory,
 	})
 }
 
-// ReduceInventory
+// ReduceInventory takes stock for an order. A reduction is applied once per order_id, so
+// OMS can retry a reduction it is unsure about without taking the stock twice; a retry
+// asking for another hub or quantity than the applied one is refused with 409.
 func ReduceInventory(c *gin.Context) {
 	var request struct {
 		SKU      string `json:"sku" binding:"required"`
 		Location string `json:"location" binding:"required"`
 		Quantity int    `json:"quantity" binding:"required,gt=0"`
+		OrderID  string `json:"order_id"`
 	}
 
 	err := c.ShouldBindJSON(&request)
@@ -212,58 +477,84 @@ func ReduceInventory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	tx := db.DB.GetMasterDB(c.Request.Context()).Begin()
-	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
-		return
-	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	tenant := tenantOf(c)
+	var (
+		change  *stockChange
+		serials []string
+	)
+	err = db.DB.GetMasterDB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if request.OrderID != "" {
+			reduction := models.OrderReduction{
+				TenantID: tenant,
+				OrderID:  request.OrderID,
+				SKU:      request.SKU,
+				Location: request.Location,
+				Quantity: request.Quantity,
+			}
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reduction)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				var applied models.OrderReduction
+				err := tx.Where("tenant_id = ? AND order_id = ? AND sku = ?", tenant, request.OrderID, request.SKU).First(&applied).Error
+				if err != nil {
+					return err
+				}
+				if applied.Location != request.Location || applied.Quantity != request.Quantity {
+					return fmt.Errorf("%w: order %s took %d at %s", errReductionMismatch, applied.OrderID, applied.Quantity, applied.Location)
+				}
+				return errAlreadyReduced
+			}
 		}
-	}()
-	var inventory models.Inventory
-	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-		Where("sku = ? AND location = ?", request.SKU, request.Location).
-		First(&inventory)
 
-	if result.Error != nil {
-		tx.Rollback()
-		if result.Error.Error() == "record not found" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Inventory not found for SKU: " + request.SKU + " at location: " + request.Location,
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		var adjustErr error
+		change, adjustErr = adjustInventory(tx, tenant, request.SKU, request.Location, -request.Quantity, models.Inventory{})
+		if adjustErr != nil {
+			return adjustErr
 		}
+		if err := recordPicks(tx, tenant, request.Location, request.OrderID, change.Picks); err != nil {
+			return err
+		}
+
+		serialized, err := isSerializedSKU(tx, tenant, request.SKU)
+		if err != nil || !serialized {
+			return err
+		}
+		serials, err = assignSerials(tx, tenant, request.SKU, request.Location, request.OrderID, request.Quantity)
+		return err
+	})
+	if errors.Is(err, errAlreadyReduced) {
+		alreadyReduced(c, tenant, request.SKU, request.Location, request.OrderID)
 		return
 	}
-	if inventory.Quantity < request.Quantity {
-		tx.Rollback()
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Insufficient inventory",
-			"details": gin.H{
-				"requested": request.Quantity,
-				"available": inventory.Quantity,
-				"sku":       request.SKU,
-				"location":  request.Location,
-			},
-		})
-		return
-	}
-	newQuantity := inventory.Quantity - request.Quantity
-	updateResult := tx.Model(&inventory).Update("quantity", newQuantity)
-	if updateResult.Error != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update inventory"})
+	if errors.Is(err, errReductionMismatch) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInventoryNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Inventory not found for SKU: " + request.SKU + " at location: " + request.Location,
+			})
+		case errors.Is(err, errInsufficientStock):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Insufficient inventory",
+				"details": gin.H{
+					"requested": request.Quantity,
+					"available": change.Available,
+					"sku":       request.SKU,
+					"location":  request.Location,
+				},
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update inventory"})
+		}
 		return
 	}
-	inventory.Quantity = newQuantity
-	go invalidateInventoryCache(context.Background())
+	inventory := change.Inventory
+	afterStockChange([]stockChange{*change})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Inventory reduced successfully",
@@ -271,8 +562,41 @@ func ReduceInventory(c *gin.Context) {
 			"sku":               request.SKU,
 			"location":          request.Location,
 			"reduced_by":        request.Quantity,
-			"new_quantity":      newQuantity,
-			"previous_quantity": inventory.Quantity + request.Quantity,
+			"new_quantity":      inventory.Quantity,
+			"previous_quantity": change.Previous,
+			"allocations":       change.Allocations,
+			"picks":             change.Picks,
+			"serials":           serials,
+		},
+		"inventory": inventory,
+	})
+}
+
+// alreadyReduced answers a retried reduction for an order whose stock was already taken
+func alreadyReduced(c *gin.Context, tenant, sku, location, orderID string) {
+	dbconn := db.DB.GetMasterDB(c.Request.Context())
+
+	var inventory models.Inventory
+	if err := dbconn.Where("tenant_id = ? AND sku = ? AND location = ?", tenant, sku, location).First(&inventory).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inventory"})
+		return
+	}
+	var serials []string
+	err := dbconn.Model(&models.SerialNumber{}).
+		Where("tenant_id = ? AND sku = ? AND order_id = ?", tenant, sku, orderID).
+		Order("id ASC").Pluck("serial_number", &serials).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch serials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Inventory already reduced for this order",
+		"details": gin.H{
+			"sku":      sku,
+			"location": location,
+			"order_id": orderID,
+			"serials":  serials,
 		},
 		"inventory": inventory,
 	})
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mausumi-ghadei-omniful/ims/db"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm"
+)
+
+// ReceiveLot
+func ReceiveLot(c *gin.Context) {
+	var request struct {
+		models.InventoryLot
+		ProductID string `json:"product_id"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	lot := request.InventoryLot
+	if err := lot.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if lot.IsExpired(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot receive an expired lot"})
+		return
+	}
+
+	lot.TenantID = tenantOf(c)
+
+	if err := rejectSerialless(db.DB.GetMasterDB(c.Request.Context()), lot.TenantID, lot.SKU); err != nil {
+		writeSerialError(c, err)
+		return
+	}
+
+	var change *stockChange
+	err := db.DB.GetMasterDB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := addLotStock(tx, &lot); err != nil {
+			return err
+		}
+
+		seed := models.Inventory{ProductID: request.ProductID, SellerID: lot.SellerID}
+		var err error
+		change, err = adjustInventory(tx, lot.TenantID, lot.SKU, lot.Location, request.Quantity, seed)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to receive lot"})
+		return
+	}
+
+	afterStockChange([]stockChange{*change})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Lot received",
+		"lot":       lot,
+		"inventory": change.Inventory,
+	})
+}
+
+// GetLots
+func GetLots(c *gin.Context) {
+	var lots []models.InventoryLot
+
+	query := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c))
+	if sku := c.Query("sku"); sku != "" {
+		query = query.Where("sku = ?", sku)
+	}
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
+	}
+	if c.Query("include_empty") != "true" {
+		query = query.Where("quantity > 0")
+	}
+
+	if err := query.Order("expires_at ASC NULLS LAST, id ASC").Find(&lots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lots"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": lots})
+}
+
+// GetExpiringLots lists lots with stock that expire within the given number of days, expired ones included
+func GetExpiringLots(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a non-negative integer"})
+		return
+	}
+
+	now := time.Now()
+	var lots []models.InventoryLot
+	query := db.DB.GetMasterDB(c.Request.Context()).
+		Where("tenant_id = ? AND quantity > 0 AND expires_at IS NOT NULL AND expires_at <= ?", tenantOf(c), now.AddDate(0, 0, days))
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
+	}
+	if err := query.Order("expires_at ASC").Find(&lots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch expiring lots"})
+		return
+	}
+
+	type expiringLot struct {
+		models.InventoryLot
+		Expired bool `json:"expired"`
+	}
+	report := make([]expiringLot, 0, len(lots))
+	for _, lot := range lots {
+		report = append(report, expiringLot{InventoryLot: lot, Expired: lot.IsExpired(now)})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"days": days, "data": report})
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm"
+)
+
+// listEnvelope is the standard shape of a paginated list response.
+type listEnvelope struct {
+	Source     string      `json:"source"`
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor"`
+	Total      int64       `json:"total"`
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// applyPage orders query by the page's sort column with id as tie-breaker, resumes after the
+// cursor and fetches one extra row so models.TrimPage can tell whether another page follows.
+func applyPage(query *gorm.DB, page models.PageRequest) *gorm.DB {
+	dir, op := "ASC", ">"
+	if page.Desc {
+		dir, op = "DESC", "<"
+	}
+	if page.Cursor != nil {
+		if page.SortField == "id" {
+			query = query.Where(fmt.Sprintf("id %s ?", op), page.Cursor.ID)
+		} else {
+			query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", page.SortField, op), page.Cursor.Value, page.Cursor.ID)
+		}
+	}
+	return query.Order(fmt.Sprintf("%s %s, id %s", page.SortField, dir, dir)).Limit(page.Limit + 1)
+}
+
+// isPlainList reports whether the request uses nothing beyond the given parameters, which
+// is when a list response may be served from or written to the cache.
+func isPlainList(c *gin.Context, allowed ...string) bool {
+	for key := range c.Request.URL.Query() {
+		ok := key == "tenant_id"
+		for _, a := range allowed {
+			ok = ok || key == a
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// applyCommonFilters adds the seller_id, updated_since and prefix filters shared by IMS lists.
+// prefixColumn must start with ?<prefixParam>, matched case-sensitively so the
+// text_pattern_ops name indexes serve it.
+func applyCommonFilters(c *gin.Context, query *gorm.DB, prefixParam, prefixColumn string) (*gorm.DB, error) {
+	if sellerID := c.Query("seller_id"); sellerID != "" {
+		query = query.Where("seller_id = ?", sellerID)
+	}
+	if since := c.Query("updated_since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, fmt.Errorf("updated_since must be an RFC3339 timestamp")
+		}
+		query = query.Where("updated_at >= ?", t)
+	}
+	if prefix := c.Query(prefixParam); prefix != "" {
+		query = query.Where(prefixColumn+" LIKE ?", likeEscaper.Replace(prefix)+"%")
+	}
+	return query, nil
+}
+
+// quantityFilter parses an optional non-negative integer query parameter.
+func quantityFilter(c *gin.Context, name string) (*int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return &n, nil
+}
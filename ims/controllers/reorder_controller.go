@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mausumi-ghadei-omniful/ims/db"
+	"github.com/mausumi-ghadei-omniful/ims/kafkaclient"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm/clause"
+)
+
+// UpsertReorderRule
+func UpsertReorderRule(c *gin.Context) {
+	var rule models.ReorderRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule.ID = 0
+	rule.TenantID = tenantOf(c)
+	rule.UpdatedAt = time.Now()
+	res := db.DB.GetMasterDB(c.Request.Context()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "sku"}, {Name: "location"}},
+		DoUpdates: clause.AssignmentColumns([]string{"seller_id", "reorder_point", "reorder_quantity", "updated_at"}),
+	}).Create(&rule)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reorder rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reorder rule saved", "rule": rule})
+}
+
+// GetReorderRules
+func GetReorderRules(c *gin.Context) {
+	var rules []models.ReorderRule
+
+	query := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c))
+	if sku := c.Query("sku"); sku != "" {
+		query = query.Where("sku = ?", sku)
+	}
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
+	}
+
+	if err := query.Order("id ASC").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reorder rules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// DeleteReorderRule
+func DeleteReorderRule(c *gin.Context) {
+	res := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c)).Delete(&models.ReorderRule{}, c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reorder rule"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reorder rule deleted"})
+}
+
+// GetStockAlerts lists every (sku, hub) currently at or below its reorder point
+func GetStockAlerts(c *gin.Context) {
+	var alerts []models.StockAlert
+
+	query := db.DB.GetMasterDB(c.Request.Context()).
+		Table("reorder_rules AS r").
+		Select("r.sku, r.location, r.tenant_id, r.seller_id, COALESCE(i.quantity, 0) AS quantity, r.reorder_point, r.reorder_quantity, now() AS created_at").
+		Joins("LEFT JOIN inventories i ON i.tenant_id = r.tenant_id AND i.sku = r.sku AND i.location = r.location AND i.deleted_at IS NULL").
+		Where("r.tenant_id = ? AND COALESCE(i.quantity, 0) <= r.reorder_point", tenantOf(c))
+	if location := c.Query("location"); location != "" {
+		query = query.Where("r.location = ?", location)
+	}
+
+	if err := query.Order("r.location, r.sku").Scan(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock alerts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": alerts})
+}
+
+// checkReorderThresholds publishes a low-stock alert for every change that dropped through its reorder point.
+func checkReorderThresholds(ctx context.Context, changes []stockChange) {
+	for _, change := range changes {
+		inv := change.Inventory
+		if inv.Quantity >= change.Previous {
+			continue
+		}
+
+		var rule models.ReorderRule
+		res := db.DB.GetMasterDB(ctx).Where("tenant_id = ? AND sku = ? AND location = ?", inv.TenantID, inv.SKU, inv.Location).Limit(1).Find(&rule)
+		if res.Error != nil || res.RowsAffected == 0 || !rule.Crossed(change.Previous, inv.Quantity) {
+			continue
+		}
+
+		alert := rule.Alert(inv.Quantity)
+		fmt.Printf("Low stock - SKU: %s, Location: %s, Quantity: %d, ReorderPoint: %d\n",
+			alert.SKU, alert.Location, alert.Quantity, alert.ReorderPoint)
+		key := fmt.Sprintf("%s:%s:%s", alert.TenantID, alert.SKU, alert.Location)
+		if err := kafkaclient.Publish(ctx, kafkaclient.LowStockTopic, key, alert); err != nil {
+			fmt.Println("Failed to publish low stock alert:", err)
+		}
+	}
+}
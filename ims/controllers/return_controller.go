@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mausumi-ghadei-omniful/ims/db"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm"
+)
+
+// ReceiveReturn records a returned line and restocks it when sellable. Receiving the
+// same return line again is a no-op so OMS can safely retry. A split order's line names
+// the sub-shipments it was reduced under in shipment_ids.
+func ReceiveReturn(c *gin.Context) {
+	var request struct {
+		models.StockReturn
+		ProductID   string   `json:"product_id"`
+		Serials     []string `json:"serials"`
+		ShipmentIDs []string `json:"shipment_ids"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ret := request.StockReturn
+	if err := ret.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ret.ID = 0
+	ret.TenantID = tenantOf(c)
+	ret.CreatedAt = time.Now()
+
+	var change *stockChange
+	var serials []string
+	duplicate := false
+	err := db.DB.GetMasterDB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var existing models.StockReturn
+		res := tx.Where("tenant_id = ? AND return_id = ? AND sku = ?", ret.TenantID, ret.ReturnID, ret.SKU).Limit(1).Find(&existing)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			ret, duplicate = existing, true
+			return nil
+		}
+		if err := tx.Create(&ret).Error; err != nil {
+			return err
+		}
+		if ret.Disposition != models.DispositionSellable {
+			return nil
+		}
+
+		serialized, err := isSerializedSKU(tx, ret.TenantID, ret.SKU)
+		if err != nil {
+			return err
+		}
+		if serialized {
+			orderIDs := request.ShipmentIDs
+			if ret.OrderID != "" {
+				orderIDs = append([]string{ret.OrderID}, orderIDs...)
+			}
+			serials, err = returnSerials(tx, ret.TenantID, ret.SKU, ret.Location, orderIDs, request.Serials, ret.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		seed := models.Inventory{ProductID: request.ProductID, SellerID: ret.SellerID}
+		change, err = adjustInventory(tx, ret.TenantID, ret.SKU, ret.Location, ret.Quantity, seed)
+		return err
+	})
+	if errors.Is(err, errSerialsRequired) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to receive return"})
+		return
+	}
+
+	if duplicate {
+		c.JSON(http.StatusOK, gin.H{"message": "Return already received", "return": ret})
+		return
+	}
+	if change == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Return quarantined", "return": ret})
+		return
+	}
+
+	afterStockChange([]stockChange{*change})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Return restocked",
+		"return":    ret,
+		"inventory": change.Inventory,
+		"serials":   serials,
+	})
+}
+
+// GetReturns
+func GetReturns(c *gin.Context) {
+	var returns []models.StockReturn
+
+	query := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c))
+	for _, field := range []string{"return_id", "order_id", "sku", "location", "disposition"} {
+		if value := c.Query(field); value != "" {
+			query = query.Where(field+" = ?", value)
+		}
+	}
+
+	if err := query.Order("created_at DESC, id DESC").Find(&returns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch returns"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": returns})
+}
+
+// GetQuarantine sums quarantined returns per SKU and hub
+func GetQuarantine(c *gin.Context) {
+	var stock []models.QuarantineStock
+
+	query := db.DB.GetMasterDB(c.Request.Context()).Model(&models.StockReturn{}).
+		Select("sku, location, SUM(quantity) AS quantity").
+		Where("tenant_id = ? AND disposition = ?", tenantOf(c), models.DispositionQuarantine)
+	if sku := c.Query("sku"); sku != "" {
+		query = query.Where("sku = ?", sku)
+	}
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
+	}
+
+	if err := query.Group("sku, location").Order("sku, location").Scan(&stock).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quarantined stock"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": stock})
+}
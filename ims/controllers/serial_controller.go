@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mausumi-ghadei-omniful/ims/db"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RegisterSerials
+func RegisterSerials(c *gin.Context) {
+	var request struct {
+		SKU       string   `json:"sku" binding:"required"`
+		Location  string   `json:"location" binding:"required"`
+		ProductID string   `json:"product_id"`
+		SellerID  string   `json:"seller_id"`
+		Serials   []string `json:"serials"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := models.ValidateSerials(request.Serials); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant := tenantOf(c)
+	dbconn := db.DB.GetMasterDB(c.Request.Context())
+	serialized, err := isSerializedSKU(dbconn, tenant, request.SKU)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !serialized {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "SKU is not serialized: " + request.SKU})
+		return
+	}
+
+	var (
+		registered []models.SerialNumber
+		change     *stockChange
+	)
+	err = dbconn.Transaction(func(tx *gorm.DB) error {
+		for _, serial := range request.Serials {
+			row := models.SerialNumber{
+				SerialNumber: strings.TrimSpace(serial),
+				SKU:          request.SKU,
+				Location:     request.Location,
+				TenantID:     tenant,
+				SellerID:     request.SellerID,
+				Status:       models.SerialStatusAvailable,
+			}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+			event := models.SerialEvent{SerialID: row.ID, Event: models.SerialEventReceived, Location: row.Location}
+			if err := tx.Create(&event).Error; err != nil {
+				return err
+			}
+			registered = append(registered, row)
+		}
+
+		seed := models.Inventory{ProductID: request.ProductID, SellerID: request.SellerID}
+		var err error
+		change, err = adjustInventory(tx, tenant, request.SKU, request.Location, len(request.Serials), seed)
+		return err
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "unique_tenant_sku_serial") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Serial number already registered for SKU: " + request.SKU})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register serials"})
+		return
+	}
+
+	afterStockChange([]stockChange{*change})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Serials registered",
+		"serials":   registered,
+		"inventory": change.Inventory,
+	})
+}
+
+// GetSerials
+func GetSerials(c *gin.Context) {
+	var serials []models.SerialNumber
+
+	query := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c))
+	if sku := c.Query("sku"); sku != "" {
+		query = query.Where("sku = ?", sku)
+	}
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if orderID := c.Query("order_id"); orderID != "" {
+		query = query.Where("order_id = ?", orderID)
+	}
+
+	if err := query.Order("id ASC").Find(&serials).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch serials"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": serials})
+}
+
+// GetSerial returns a serial's current location and status with its full history
+func GetSerial(c *gin.Context) {
+	dbconn := db.DB.GetMasterDB(c.Request.Context())
+
+	var serials []models.SerialNumber
+	query := dbconn.Where("tenant_id = ? AND serial_number = ?", tenantOf(c), c.Param("serial"))
+	if sku := c.Query("sku"); sku != "" {
+		query = query.Where("sku = ?", sku)
+	}
+	if err := query.Find(&serials).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch serial"})
+		return
+	}
+	if len(serials) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Serial not found"})
+		return
+	}
+
+	type serialHistory struct {
+		models.SerialNumber
+		History []models.SerialEvent `json:"history"`
+	}
+	result := make([]serialHistory, 0, len(serials))
+	for _, serial := range serials {
+		var events []models.SerialEvent
+		if err := dbconn.Where("serial_id = ?", serial.ID).Order("created_at ASC, id ASC").Find(&events).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch serial history"})
+			return
+		}
+		result = append(result, serialHistory{SerialNumber: serial, History: events})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func isSerializedSKU(tx *gorm.DB, tenant, sku string) (bool, error) {
+	var count int64
+	err := tx.Model(&models.SKU{}).Where("tenant_id = ? AND code = ? AND serialized = ?", tenant, sku, true).Count(&count).Error
+	return count > 0, err
+}
+
+// errSerialsRequired is returned when stock of a serialized SKU would arrive without serial numbers
+var errSerialsRequired = errors.New("serialized SKU stock must be registered with serial numbers")
+
+// rejectSerialless returns errSerialsRequired when sku is serialized. Paths that add stock
+// without serials call it, so every unit of a serialized SKU has a serial to assign.
+func rejectSerialless(tx *gorm.DB, tenant, sku string) error {
+	serialized, err := isSerializedSKU(tx, tenant, sku)
+	if err != nil {
+		return err
+	}
+	if serialized {
+		return fmt.Errorf("%w: %s", errSerialsRequired, sku)
+	}
+	return nil
+}
+
+// writeSerialError answers a failed rejectSerialless check
+func writeSerialError(c *gin.Context, err error) {
+	if errors.Is(err, errSerialsRequired) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+}
+
+// dispatchSerials puts quantity available serials of sku at the transfer's source hub on the transfer
+func dispatchSerials(tx *gorm.DB, transfer *models.StockTransfer, sku string, quantity int) error {
+	var serials []models.SerialNumber
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("tenant_id = ? AND sku = ? AND location = ? AND status = ?", transfer.TenantID, sku, transfer.SourceHub, models.SerialStatusAvailable).
+		Order("id ASC").Limit(quantity).Find(&serials)
+	if res.Error != nil {
+		return res.Error
+	}
+	if len(serials) < quantity {
+		return errInsufficientStock
+	}
+	return moveSerials(tx, serials, models.SerialEventDispatched, transfer.SourceHub, map[string]interface{}{
+		"status":      models.SerialStatusInTransit,
+		"transfer_id": transfer.ID,
+	})
+}
+
+// receiveSerials moves the transfer's serials of sku to its destination hub
+func receiveSerials(tx *gorm.DB, transfer *models.StockTransfer, sku string) error {
+	var serials []models.SerialNumber
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("transfer_id = ? AND sku = ?", transfer.ID, sku).Find(&serials)
+	if res.Error != nil {
+		return res.Error
+	}
+	return moveSerials(tx, serials, models.SerialEventReceived, transfer.DestinationHub, map[string]interface{}{
+		"status":      models.SerialStatusAvailable,
+		"location":    transfer.DestinationHub,
+		"transfer_id": nil,
+	})
+}
+
+// returnSerials puts quantity returned serials of sku back into stock at location. The
+// listed serials are used when given, otherwise those allocated to any of orderIDs (a
+// split order's serials are allocated to its sub-shipments).
+func returnSerials(tx *gorm.DB, tenant, sku, location string, orderIDs, serials []string, quantity int) ([]string, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND sku = ? AND status = ?", tenant, sku, models.SerialStatusAllocated)
+	switch {
+	case len(serials) > 0:
+		query = query.Where("serial_number IN ?", serials)
+	case len(orderIDs) > 0:
+		query = query.Where("order_id IN ?", orderIDs)
+	default:
+		return nil, fmt.Errorf("%w: %s", errSerialsRequired, sku)
+	}
+
+	var rows []models.SerialNumber
+	if err := query.Order("id ASC").Limit(quantity).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) < quantity {
+		return nil, fmt.Errorf("%w: %s has %d of %d returned serials allocated", errSerialsRequired, sku, len(rows), quantity)
+	}
+
+	returned := make([]string, 0, len(rows))
+	for _, row := range rows {
+		returned = append(returned, row.SerialNumber)
+	}
+	err := moveSerials(tx, rows, models.SerialEventReturned, location, map[string]interface{}{
+		"status":   models.SerialStatusAvailable,
+		"location": location,
+		"order_id": "",
+	})
+	return returned, err
+}
+
+// moveSerials applies updates to serials and records event at location in their history
+func moveSerials(tx *gorm.DB, serials []models.SerialNumber, event, location string, updates map[string]interface{}) error {
+	if len(serials) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(serials))
+	events := make([]models.SerialEvent, 0, len(serials))
+	for _, serial := range serials {
+		ids = append(ids, serial.ID)
+		events = append(events, models.SerialEvent{SerialID: serial.ID, Event: event, Location: location, OrderID: serial.OrderID})
+	}
+	if err := tx.Create(&events).Error; err != nil {
+		return err
+	}
+	updates["updated_at"] = time.Now()
+	return tx.Model(&models.SerialNumber{}).Where("id IN ?", ids).Updates(updates).Error
+}
+
+// assignSerials allocates quantity available serials at location to orderID, oldest first.
+func assignSerials(tx *gorm.DB, tenant, sku, location, orderID string, quantity int) ([]string, error) {
+	var serials []models.SerialNumber
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("tenant_id = ? AND sku = ? AND location = ? AND status = ?", tenant, sku, location, models.SerialStatusAvailable).
+		Order("id ASC").Limit(quantity).Find(&serials)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if len(serials) < quantity {
+		return nil, errInsufficientStock
+	}
+
+	assigned := make([]string, 0, len(serials))
+	ids := make([]uint, 0, len(serials))
+	for _, serial := range serials {
+		assigned = append(assigned, serial.SerialNumber)
+		ids = append(ids, serial.ID)
+		event := models.SerialEvent{SerialID: serial.ID, Event: models.SerialEventAssigned, Location: location, OrderID: orderID}
+		if err := tx.Create(&event).Error; err != nil {
+			return nil, err
+		}
+	}
+	err := tx.Model(&models.SerialNumber{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":     models.SerialStatusAllocated,
+		"order_id":   orderID,
+		"updated_at": time.Now(),
+	}).Error
+	return assigned, err
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/mausumi-ghadei-omniful/ims/db"
 	"github.com/mausumi-ghadei-omniful/ims/models"
 	"github.com/mausumi-ghadei-omniful/ims/redisclient"
+	"gorm.io/gorm"
 )
 
 // CreateSKU
@@ -19,20 +20,14 @@ func CreateSKU(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	sku.TenantID = tenantOf(c)
 	res := db.DB.GetMasterDB(context.Background()).Create(&sku)
 	if res.Error != nil {
 		c.JSON(500, gin.H{"error": "Failed to create SKU"})
 		return
 	}
 
-	go func() {
-		var updated []models.SKU
-		db.DB.GetMasterDB(context.Background()).Find(&updated)
-		data, err := json.Marshal(updated)
-		if err == nil {
-			redisclient.Client.Set(context.Background(), "All_skus", string(data), 1*time.Hour)
-		}
-	}()
+	go invalidateSKUCache(tenantOf(c))
 
 	c.JSON(200, gin.H{"message": "SKU created", "sku": sku})
 }
@@ -41,55 +36,94 @@ func CreateSKU(c *gin.Context) {
 func GetSKUs(c *gin.Context) {
 	var skus []models.SKU
 	ctx := context.Background()
-	cacheKey := "All_skus"
-	cached, err := redisclient.Client.Get(ctx, cacheKey)
-	if err == nil {
-		err := json.Unmarshal([]byte(cached), &skus)
-		if err == nil {
-			c.JSON(200, gin.H{
-				"source": "cache",
-				"data":   skus,
-			})
-			return
+	tenantID := tenantOf(c)
+	cacheKey := skuCacheKey(tenantID)
+
+	// only the default first page is cached
+	cacheable := isPlainList(c)
+	if cacheable {
+		if cached, ok := redisclient.Get(ctx, cacheKey); ok {
+			var envelope listEnvelope
+			envelope.Data = &skus
+			if err := json.Unmarshal([]byte(cached), &envelope); err == nil {
+				envelope.Source = "cache"
+				c.JSON(200, envelope)
+				return
+			}
 		}
 	}
-	query := db.DB.GetMasterDB(c.Request.Context())
 
-	tenantID := c.Query("tenant_id")
-	if tenantID != "" {
-		query = query.Where("tenant_id = ?", tenantID)
-	}
-	sellerID := c.Query("seller_id")
-	if sellerID != "" {
-		query = query.Where("seller_id = ?", sellerID)
+	page, err := models.ParsePage(c.Query("limit"), c.Query("cursor"), c.Query("sort"), map[string]string{
+		"id": "id", "sku_code": "code", "name": "name", "updated_at": "updated_at",
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
+
+	query := db.DB.GetMasterDB(c.Request.Context()).Model(&models.SKU{}).Where("tenant_id = ?", tenantID)
 	skuCode := c.Query("sku_code")
 	if skuCode != "" {
-		query = query.Where("sku_code = ?", skuCode)
+		query = query.Where("code = ?", skuCode)
 	}
+	query, err = applyCommonFilters(c, query, "name_prefix", "name")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	query = query.Session(&gorm.Session{})
 
-	res := query.Find(&skus)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch SKUs"})
+		return
+	}
+	res := applyPage(query, page).Find(&skus)
 	if res.Error != nil {
 		c.JSON(500, gin.H{"error": "Failed to fetch SKUs"})
 		return
 	}
+	skus, next := models.TrimPage(skus, page, func(sku models.SKU) (interface{}, uint) {
+		switch page.SortField {
+		case "code":
+			return sku.Code, sku.ID
+		case "name":
+			return sku.Name, sku.ID
+		case "updated_at":
+			return sku.UpdatedAt, sku.ID
+		}
+		return sku.ID, sku.ID
+	})
 
-	data, err := json.Marshal(skus)
-	if err == nil {
-		_, _ = redisclient.Client.Set(ctx, cacheKey, string(data), 1*time.Hour)
+	envelope := listEnvelope{Source: "database", Data: skus, NextCursor: next, Total: total}
+	if cacheable {
+		if data, err := json.Marshal(envelope); err == nil {
+			redisclient.Set(ctx, cacheKey, string(data), 1*time.Hour)
+		}
 	}
+	c.JSON(200, envelope)
+}
 
-	c.JSON(200, gin.H{
-		"source": "database",
-		"data":   skus,
-	})
+// GetSKU
+func GetSKU(c *gin.Context) {
+	var sku models.SKU
+	if err := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c)).First(&sku, c.Param("id")).Error; err != nil {
+		c.JSON(404, gin.H{"error": "SKU not found"})
+		return
+	}
+	c.Header("ETag", models.ETag(sku.Version))
+	c.JSON(200, sku)
 }
 
 // UpdateSKU
 func UpdateSKU(c *gin.Context) {
 	id := c.Param("id")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 	var sku models.SKU
-	if err := db.DB.GetMasterDB(context.Background()).First(&sku, id).Error; err != nil {
+	if err := db.DB.GetMasterDB(context.Background()).Where("tenant_id = ?", tenantOf(c)).First(&sku, id).Error; err != nil {
 		c.JSON(404, gin.H{"error": "SKU not found"})
 		return
 	}
@@ -99,44 +133,98 @@ func UpdateSKU(c *gin.Context) {
 		return
 	}
 
-	sku.Code = updated.Code
-	sku.Name = updated.Name
-	sku.Description = updated.Description
-	sku.TenantID = updated.TenantID
-	sku.SellerID = updated.SellerID
+	saveSKU(c, sku, version, map[string]interface{}{
+		"code":        updated.Code,
+		"name":        updated.Name,
+		"description": updated.Description,
+		"seller_id":   updated.SellerID,
+		"serialized":  updated.Serialized,
+	})
+}
+
+// PatchSKU
+func PatchSKU(c *gin.Context) {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	var sku models.SKU
+	if err := db.DB.GetMasterDB(c.Request.Context()).Where("tenant_id = ?", tenantOf(c)).First(&sku, c.Param("id")).Error; err != nil {
+		c.JSON(404, gin.H{"error": "SKU not found"})
+		return
+	}
+
+	var patch struct {
+		Code        *string `json:"sku_code"`
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		SellerID    *string `json:"seller_id"`
+		Serialized  *bool   `json:"serialized"`
+	}
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if patch.Code != nil {
+		updates["code"] = *patch.Code
+	}
+	if patch.Name != nil {
+		updates["name"] = *patch.Name
+	}
+	if patch.Description != nil {
+		updates["description"] = *patch.Description
+	}
+	if patch.SellerID != nil {
+		updates["seller_id"] = *patch.SellerID
+	}
+	if patch.Serialized != nil {
+		updates["serialized"] = *patch.Serialized
+	}
+	if len(updates) == 0 {
+		c.JSON(400, gin.H{"error": "no fields to update"})
+		return
+	}
+
+	saveSKU(c, sku, version, updates)
+}
 
-	if err := db.DB.GetMasterDB(context.Background()).Save(&sku).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to update SKU"})
+func saveSKU(c *gin.Context, previous models.SKU, version int, updates map[string]interface{}) {
+	dbconn := db.DB.GetMasterDB(c.Request.Context())
+	if err := updateIfVersion(dbconn, &models.SKU{}, previous.ID, version, updates); err != nil {
+		writeUpdateError(c, err, previous.Version, "Failed to update SKU")
 		return
 	}
 
-	go func() {
-		var updated []models.SKU
-		db.DB.GetMasterDB(context.Background()).Find(&updated)
-		data, err := json.Marshal(updated)
-		if err == nil {
-			redisclient.Client.Set(context.Background(), "All_skus", string(data), 1*time.Hour)
-		}
-	}()
+	var sku models.SKU
+	if err := dbconn.First(&sku, previous.ID).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	go invalidateSKUCache(sku.TenantID)
+	c.Header("ETag", models.ETag(sku.Version))
 	c.JSON(200, sku)
 }
 
 // DeleteSKU
 func DeleteSKU(c *gin.Context) {
 	id := c.Param("id")
-	res := db.DB.GetMasterDB(context.Background()).Delete(&models.SKU{}, id)
+	res := db.DB.GetMasterDB(context.Background()).Where("tenant_id = ?", tenantOf(c)).Delete(&models.SKU{}, id)
 	if res.Error != nil {
 		c.JSON(500, gin.H{"error": "Failed to delete SKU"})
 		return
 	}
 
-	go func() {
-		var updated []models.SKU
-		db.DB.GetMasterDB(context.Background()).Find(&updated)
-		data, err := json.Marshal(updated)
-		if err == nil {
-			redisclient.Client.Set(context.Background(), "All_skus", string(data), 1*time.Hour)
-		}
-	}()
+	go invalidateSKUCache(tenantOf(c))
 	c.JSON(200, gin.H{"message": "SKU deleted"})
 }
+
+func skuCacheKey(tenantID string) string {
+	return "All_skus:" + tenantID
+}
+
+func invalidateSKUCache(tenantID string) {
+	redisclient.Del(context.Background(), skuCacheKey(tenantID))
+}
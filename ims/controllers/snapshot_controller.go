@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mausumi-ghadei-omniful/ims/db"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"github.com/omniful/go_commons/config"
+	"gorm.io/gorm"
+)
+
+const defaultSnapshotInterval = 24 * time.Hour
+
+// CreateSnapshot takes an on-demand snapshot of every row, or of one location
+func CreateSnapshot(c *gin.Context) {
+	var request struct {
+		Location string `json:"location"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	takenAt, rows, err := takeSnapshot(c.Request.Context(), tenantOf(c), request.Location)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to take snapshot"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Snapshot taken", "taken_at": takenAt, "rows": rows})
+}
+
+// GetSnapshots
+func GetSnapshots(c *gin.Context) {
+	var rows []struct {
+		TakenAt  time.Time `json:"taken_at"`
+		RowCount int       `json:"rows"`
+	}
+
+	query := db.DB.GetMasterDB(c.Request.Context()).Model(&models.InventorySnapshot{}).
+		Select("taken_at, COUNT(*) AS row_count").
+		Where("tenant_id = ?", tenantOf(c))
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
+	}
+	if err := query.Group("taken_at").Order("taken_at DESC").Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch snapshots"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// GetSnapshotDiff compares stock as of ?from and ?to
+func GetSnapshotDiff(c *gin.Context) {
+	from, err := models.ParseAsOf(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from: " + err.Error()})
+		return
+	}
+	to, err := models.ParseAsOf(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to: " + err.Error()})
+		return
+	}
+
+	dbconn := db.DB.GetMasterDB(c.Request.Context())
+	before, err := inventoryAsOf(dbconn, tenantOf(c), from, c.Query("sku"), c.Query("location"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	after, err := inventoryAsOf(dbconn, tenantOf(c), to, c.Query("sku"), c.Query("location"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "data": models.DiffPositions(before, after)})
+}
+
+// RunSnapshotScheduler snapshots all inventory every SNAPSHOT_INTERVAL (default 24h) until ctx is done
+func RunSnapshotScheduler(ctx context.Context) {
+	interval := defaultSnapshotInterval
+	if value := config.GetString(ctx, "SNAPSHOT_INTERVAL"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			interval = d
+		} else {
+			fmt.Println("Invalid SNAPSHOT_INTERVAL, using default:", value)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			takenAt, rows, err := takeSnapshot(context.Background(), "", "")
+			if err != nil {
+				fmt.Println("Scheduled inventory snapshot failed:", err)
+				continue
+			}
+			fmt.Printf("Inventory snapshot taken at %s (%d rows)\n", takenAt.Format(time.RFC3339), rows)
+		}
+	}
+}
+
+// takeSnapshot copies current stock into inventory_snapshots; an empty tenant snapshots every tenant.
+// taken_at is read from Postgres inside the same repeatable-read transaction as the copy,
+// so it matches the clock movements are stamped with and the stock the copy sees.
+func takeSnapshot(ctx context.Context, tenant, location string) (time.Time, int64, error) {
+	query := `INSERT INTO inventory_snapshots (taken_at, sku, location, tenant_id, seller_id, quantity)
+		SELECT ?, sku, location, tenant_id, seller_id, quantity FROM inventories WHERE deleted_at IS NULL`
+	var args []interface{}
+	if tenant != "" {
+		query += " AND tenant_id = ?"
+		args = append(args, tenant)
+	}
+	if location != "" {
+		query += " AND location = ?"
+		args = append(args, location)
+	}
+
+	var takenAt time.Time
+	var rows int64
+	err := db.DB.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT now()").Scan(&takenAt).Error; err != nil {
+			return err
+		}
+		res := tx.Exec(query+" ON CONFLICT DO NOTHING", append([]interface{}{takenAt}, args...)...)
+		rows = res.RowsAffected
+		return res.Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	return takenAt.UTC(), rows, err
+}
+
+// inventoryAsOf rebuilds a tenant's stock at asOf from the latest snapshot at or before it plus the
+// movements recorded after that snapshot. Rows never snapshotted are rebuilt from movements alone.
+func inventoryAsOf(tx *gorm.DB, tenant string, asOf time.Time, sku, location string) ([]models.InventoryPosition, error) {
+	filters := []string{"tenant_id = @tenant"}
+	args := map[string]interface{}{"as_of": asOf, "tenant": tenant}
+	if sku != "" {
+		filters = append(filters, "sku = @sku")
+		args["sku"] = sku
+	}
+	if location != "" {
+		filters = append(filters, "location = @location")
+		args["location"] = location
+	}
+	where := " AND " + strings.Join(filters, " AND ")
+
+	query := `
+WITH snap AS (
+	SELECT DISTINCT ON (sku, location) sku, location, quantity, taken_at
+	FROM inventory_snapshots
+	WHERE taken_at <= @as_of` + where + `
+	ORDER BY sku, location, taken_at DESC
+), moves AS (
+	SELECT m.sku, m.location, SUM(m.delta) AS delta
+	FROM (SELECT * FROM inventory_movements WHERE created_at <= @as_of` + where + `) m
+	LEFT JOIN snap s ON s.sku = m.sku AND s.location = m.location
+	WHERE s.taken_at IS NULL OR m.created_at > s.taken_at
+	GROUP BY m.sku, m.location
+)
+SELECT COALESCE(s.sku, m.sku) AS sku,
+	COALESCE(s.location, m.location) AS location,
+	COALESCE(s.quantity, 0) + COALESCE(m.delta, 0) AS quantity,
+	s.taken_at AS snapshot_at
+FROM snap s
+FULL OUTER JOIN moves m ON m.sku = s.sku AND m.location = s.location
+ORDER BY 1, 2`
+
+	var positions []models.InventoryPosition
+	err := tx.Raw(query, args).Scan(&positions).Error
+	return positions, err
+}
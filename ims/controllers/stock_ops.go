@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mausumi-ghadei-omniful/ims/kafkaclient"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errInventoryNotFound = errors.New("inventory not found")
+	errInsufficientStock = errors.New("insufficient inventory")
+	errAlreadyReduced    = errors.New("inventory already reduced for order")
+	errReductionMismatch = errors.New("order was already reduced differently")
+	errRekeyWithStock    = errors.New("inventory with lots, bins or serials cannot change sku or location")
+)
+
+// stockChange records a committed quantity change so post-commit hooks can react to it.
+type stockChange struct {
+	Inventory   models.Inventory
+	Previous    int
+	Available   int
+	Allocations []models.LotAllocation
+	Picks       []models.Pick
+}
+
+// adjustInventory locks the tenant's (sku, location) row inside tx and applies delta to its quantity.
+// A missing row is created from seed when delta is positive. Reductions are drawn from
+// lots first-expire-first-out and never from expired lots, and take from the hub's bins
+// whatever the unbinned stock cannot cover.
+func adjustInventory(tx *gorm.DB, tenant, sku, location string, delta int, seed models.Inventory) (*stockChange, error) {
+	return applyStockDelta(tx, tenant, sku, location, delta, seed, models.AllocateFEFO)
+}
+
+// adjustCountedInventory applies an approved count variance like adjustInventory, except
+// that stock found missing is written off expired lots first instead of being refused
+// because only expired lots are left.
+func adjustCountedInventory(tx *gorm.DB, tenant, sku, location string, delta int, seed models.Inventory) (*stockChange, error) {
+	return applyStockDelta(tx, tenant, sku, location, delta, seed, models.AllocateWriteOff)
+}
+
+// setStockDelta applies delta to the locked row inv like adjustInventory. A zero delta
+// leaves the row as it is.
+func setStockDelta(tx *gorm.DB, inv models.Inventory, delta int) (*stockChange, error) {
+	if delta == 0 {
+		return &stockChange{Inventory: inv, Previous: inv.Quantity, Available: inv.Quantity}, nil
+	}
+	return adjustInventory(tx, inv.TenantID, inv.SKU, inv.Location, delta, inv)
+}
+
+// rekeysInventory reports whether updates move inv to another sku or location
+func rekeysInventory(inv models.Inventory, updates map[string]interface{}) bool {
+	for field, current := range map[string]string{"sku": inv.SKU, "location": inv.Location} {
+		if v, ok := updates[field].(string); ok && v != current {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectTrackedRekey returns errRekeyWithStock when lots, bins or available serials are
+// kept under inv's sku and location, which would stay behind if the row moved
+func rejectTrackedRekey(tx *gorm.DB, inv models.Inventory) error {
+	var lots, binned, serials int64
+	err := tx.Model(&models.InventoryLot{}).
+		Where("tenant_id = ? AND sku = ? AND location = ? AND quantity > 0", inv.TenantID, inv.SKU, inv.Location).
+		Count(&lots).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Table("bin_stocks AS s").
+		Joins("JOIN hub_bins b ON b.id = s.bin_id").
+		Joins("JOIN hubs h ON h.id = b.hub_id").
+		Where("h.tenant_id = ? AND h.location = ? AND s.sku = ? AND s.quantity > 0", inv.TenantID, inv.Location, inv.SKU).
+		Count(&binned).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&models.SerialNumber{}).
+		Where("tenant_id = ? AND sku = ? AND location = ? AND status = ?", inv.TenantID, inv.SKU, inv.Location, models.SerialStatusAvailable).
+		Count(&serials).Error
+	if err != nil {
+		return err
+	}
+	if lots+binned+serials > 0 {
+		return fmt.Errorf("%w: %s at %s", errRekeyWithStock, inv.SKU, inv.Location)
+	}
+	return nil
+}
+
+// lotAllocator plans which lots a reduction is taken from
+type lotAllocator func(lots []models.InventoryLot, untracked, quantity int, now time.Time) ([]models.LotAllocation, int)
+
+func applyStockDelta(tx *gorm.DB, tenant, sku, location string, delta int, seed models.Inventory, allocate lotAllocator) (*stockChange, error) {
+	var inventory models.Inventory
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND sku = ? AND location = ?", tenant, sku, location).
+		First(&inventory)
+	if res.Error != nil {
+		if !errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, res.Error
+		}
+		if delta < 0 {
+			return nil, errInventoryNotFound
+		}
+		inventory = seed
+		inventory.ID = 0
+		inventory.TenantID = tenant
+		inventory.SKU = sku
+		inventory.Location = location
+		inventory.Quantity = delta
+		if err := tx.Create(&inventory).Error; err != nil {
+			return nil, err
+		}
+		return &stockChange{Inventory: inventory, Previous: 0}, nil
+	}
+
+	change := &stockChange{Inventory: inventory, Previous: inventory.Quantity, Available: inventory.Quantity}
+	if delta < 0 {
+		if err := allocateLots(tx, change, -delta, allocate); err != nil {
+			return change, err
+		}
+	}
+	change.Inventory.Quantity += delta
+	// returning picks up the version the trigger bumped, which the row cache orders writes by
+	if err := tx.Model(&change.Inventory).Clauses(clause.Returning{}).Update("quantity", change.Inventory.Quantity).Error; err != nil {
+		return nil, err
+	}
+	if delta < 0 {
+		if err := releaseBinStock(tx, change); err != nil {
+			return nil, err
+		}
+	}
+	return change, nil
+}
+
+// releaseBinStock keeps the binned total of the row's SKU within its hub stock. When a
+// reduction leaves less stock than the bins hold, the difference is picked from the bins
+// in bin-code order and recorded on change.
+func releaseBinStock(tx *gorm.DB, change *stockChange) error {
+	inv := change.Inventory
+
+	var bins []models.BinQuantity
+	err := tx.Table("bin_stocks AS s").
+		Select("s.bin_id, b.code AS bin_code, s.quantity").
+		Joins("JOIN hub_bins b ON b.id = s.bin_id").
+		Joins("JOIN hubs h ON h.id = b.hub_id").
+		Where("h.tenant_id = ? AND h.location = ? AND s.sku = ? AND s.quantity > 0", inv.TenantID, inv.Location, inv.SKU).
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "s"}}).
+		Scan(&bins).Error
+	if err != nil {
+		return err
+	}
+
+	binned := 0
+	for _, bin := range bins {
+		binned += bin.Quantity
+	}
+	if binned <= inv.Quantity {
+		return nil
+	}
+
+	picks, _ := models.PlanPicks("", inv.SKU, bins, binned-inv.Quantity)
+	for _, pick := range picks {
+		if _, err := changeBinStock(tx, pick.BinID, pick.SKU, -pick.Quantity); err != nil {
+			return err
+		}
+	}
+	change.Picks = picks
+	return nil
+}
+
+// recordPicks stores picks taken for orderID at location so pick lists skip them
+func recordPicks(tx *gorm.DB, tenant, location, orderID string, picks []models.Pick) error {
+	if orderID == "" || len(picks) == 0 {
+		return nil
+	}
+	rows := make([]models.OrderPick, 0, len(picks))
+	for _, pick := range picks {
+		pick.OrderID = orderID
+		rows = append(rows, models.OrderPick{TenantID: tenant, Location: location, Pick: pick})
+	}
+	return tx.Create(&rows).Error
+}
+
+// allocateLots takes quantity out of the row's lots as allocate plans and records the allocations on change.
+func allocateLots(tx *gorm.DB, change *stockChange, quantity int, allocate lotAllocator) error {
+	inv := change.Inventory
+
+	var lots []models.InventoryLot
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND sku = ? AND location = ? AND quantity > 0", inv.TenantID, inv.SKU, inv.Location).
+		Find(&lots)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	lotted := 0
+	for _, lot := range lots {
+		lotted += lot.Quantity
+	}
+	untracked := max(inv.Quantity-lotted, 0)
+
+	allocations, available := allocate(lots, untracked, quantity, time.Now())
+	change.Available = min(available, inv.Quantity)
+	if change.Available < quantity {
+		return errInsufficientStock
+	}
+
+	for _, a := range allocations {
+		if a.LotID == 0 {
+			continue
+		}
+		err := tx.Model(&models.InventoryLot{}).Where("id = ?", a.LotID).
+			Updates(map[string]interface{}{"quantity": gorm.Expr("quantity - ?", a.Quantity), "updated_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+	}
+	change.Allocations = allocations
+	return nil
+}
+
+// addLotStock adds lot.Quantity to the tenant's lot at lot.Location, creating the lot on
+// first receipt. The increment happens in the upsert itself, so concurrent receipts of
+// the same lot add up; lot is refreshed with the stored row.
+func addLotStock(tx *gorm.DB, lot *models.InventoryLot) error {
+	lot.ID = 0
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "sku"}, {Name: "location"}, {Name: "lot_number"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"quantity":   gorm.Expr("inventory_lots.quantity + excluded.quantity"),
+			"updated_at": time.Now(),
+		}),
+	}, clause.Returning{}).Create(lot).Error
+}
+
+// afterStockChange runs once changes are committed. It writes the rows through to the
+// row cache and evicts their list caches before returning, so the request that changed
+// stock never leaves an older quantity cached behind it. Reorder alerts and stock
+// increase events are sent in the background.
+func afterStockChange(changes []stockChange) {
+	ctx := context.Background()
+	for i := range changes {
+		storeInventoryRow(ctx, changes[i].Inventory)
+		invalidateInventoryCache(ctx, changes[i].Inventory)
+	}
+	go func() {
+		checkReorderThresholds(ctx, changes)
+		publishStockIncreases(ctx, changes)
+	}()
+}
+
+// publishStockIncreases lets OMS retry orders waiting on stock that just arrived.
+func publishStockIncreases(ctx context.Context, changes []stockChange) {
+	for _, change := range changes {
+		inv := change.Inventory
+		if inv.Quantity <= change.Previous {
+			continue
+		}
+		key := fmt.Sprintf("%s:%s:%s", inv.TenantID, inv.SKU, inv.Location)
+		if err := kafkaclient.Publish(ctx, kafkaclient.StockIncreasedTopic, key, inv.Increase(change.Previous)); err != nil {
+			fmt.Println("Failed to publish stock increase:", err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHeader carries the calling tenant on every IMS request.
+const TenantHeader = "X-Tenant-ID"
+
+const tenantContextKey = "tenant_id"
+
+// RequireTenant resolves the tenant from X-Tenant-ID, falling back to the tenant_id query
+// parameter older clients send, and rejects requests that carry neither.
+func RequireTenant(c *gin.Context) {
+	tenant := strings.TrimSpace(c.GetHeader(TenantHeader))
+	if tenant == "" {
+		tenant = strings.TrimSpace(c.Query("tenant_id"))
+	}
+	if tenant == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": TenantHeader + " header is required"})
+		return
+	}
+	c.Set(tenantContextKey, tenant)
+	c.Next()
+}
+
+// tenantOf returns the tenant resolved by RequireTenant.
+func tenantOf(c *gin.Context) string {
+	return c.GetString(tenantContextKey)
+}
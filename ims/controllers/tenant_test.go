@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/scoped", RequireTenant, func(c *gin.Context) {
+		c.String(200, tenantOf(c))
+	})
+
+	tests := []struct {
+		name     string
+		url      string
+		header   string
+		wantCode int
+		wantBody string
+	}{
+		{"header", "/scoped", "tenant1", 200, "tenant1"},
+		{"query fallback", "/scoped?tenant_id=tenant2", "", 200, "tenant2"},
+		{"header wins", "/scoped?tenant_id=tenant2", "tenant1", 200, "tenant1"},
+		{"missing", "/scoped", "", 400, ""},
+		{"blank", "/scoped", "  ", 400, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			if tt.header != "" {
+				req.Header.Set(TenantHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == 200 && w.Body.String() != tt.wantBody {
+				t.Errorf("tenant = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mausumi-ghadei-omniful/ims/db"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateTransfer
+func CreateTransfer(c *gin.Context) {
+	var transfer models.StockTransfer
+	if err := c.ShouldBindJSON(&transfer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := transfer.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer.ID = 0
+	transfer.TenantID = tenantOf(c)
+	transfer.Status = models.TransferStatusDraft
+	transfer.DispatchedAt = nil
+	transfer.ReceivedAt = nil
+	for i := range transfer.Lines {
+		transfer.Lines[i].ID = 0
+		transfer.Lines[i].Lots = nil
+	}
+
+	if err := db.DB.GetMasterDB(c.Request.Context()).Create(&transfer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transfer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer created", "transfer": transfer})
+}
+
+// GetTransfers
+func GetTransfers(c *gin.Context) {
+	var transfers []models.StockTransfer
+
+	query := db.DB.GetMasterDB(c.Request.Context()).Preload("Lines.Lots").Where("tenant_id = ?", tenantOf(c))
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if source := c.Query("source_hub"); source != "" {
+		query = query.Where("source_hub = ?", source)
+	}
+	if destination := c.Query("destination_hub"); destination != "" {
+		query = query.Where("destination_hub = ?", destination)
+	}
+
+	if err := query.Order("id ASC").Find(&transfers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": transfers})
+}
+
+// GetTransfer
+func GetTransfer(c *gin.Context) {
+	var transfer models.StockTransfer
+	res := db.DB.GetMasterDB(c.Request.Context()).Preload("Lines.Lots").Where("tenant_id = ?", tenantOf(c)).First(&transfer, c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+		return
+	}
+	c.JSON(http.StatusOK, transfer)
+}
+
+// GetInTransit
+func GetInTransit(c *gin.Context) {
+	var rows []struct {
+		SKU            string `json:"sku"`
+		SourceHub      string `json:"source_hub"`
+		DestinationHub string `json:"destination_hub"`
+		Quantity       int    `json:"quantity"`
+	}
+
+	query := db.DB.GetMasterDB(c.Request.Context()).
+		Table("stock_transfer_lines AS l").
+		Select("l.sku, t.source_hub, t.destination_hub, SUM(l.quantity) AS quantity").
+		Joins("JOIN stock_transfers t ON t.id = l.transfer_id").
+		Where("t.tenant_id = ? AND t.status = ?", tenantOf(c), models.TransferStatusInTransit)
+	if sku := c.Query("sku"); sku != "" {
+		query = query.Where("l.sku = ?", sku)
+	}
+	if hub := c.Query("hub"); hub != "" {
+		query = query.Where("t.source_hub = ? OR t.destination_hub = ?", hub, hub)
+	}
+
+	res := query.Group("l.sku, t.source_hub, t.destination_hub").Order("l.sku").Scan(&rows)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch in-transit stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// DispatchTransfer
+func DispatchTransfer(c *gin.Context) {
+	moveTransfer(c, models.TransferStatusInTransit)
+}
+
+// ReceiveTransfer
+func ReceiveTransfer(c *gin.Context) {
+	moveTransfer(c, models.TransferStatusReceived)
+}
+
+// moveTransfer advances a transfer and applies its stock movement in one transaction:
+// dispatch takes stock out of the source hub, receipt puts it into the destination hub.
+// The lots drawn at dispatch are recorded on the line and re-created at the destination,
+// so lot numbers and expiry dates travel with the stock.
+func moveTransfer(c *gin.Context, next models.TransferStatus) {
+	var (
+		transfer models.StockTransfer
+		changes  []stockChange
+		status   = http.StatusInternalServerError
+	)
+
+	err := db.DB.GetMasterDB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines.Lots").
+			Where("tenant_id = ?", tenantOf(c)).First(&transfer, c.Param("id"))
+		if res.Error != nil {
+			status = http.StatusNotFound
+			return errors.New("Transfer not found")
+		}
+		if !transfer.CanTransitionTo(next) {
+			status = http.StatusConflict
+			return fmt.Errorf("cannot move transfer from %s to %s", transfer.Status, next)
+		}
+
+		for i, line := range transfer.Lines {
+			location, delta := transfer.SourceHub, -line.Quantity
+			if next == models.TransferStatusReceived {
+				location, delta = transfer.DestinationHub, line.Quantity
+			}
+			seed := models.Inventory{ProductID: line.ProductID, SellerID: transfer.SellerID}
+
+			change, err := adjustInventory(tx, transfer.TenantID, line.SKU, location, delta, seed)
+			if err != nil {
+				if errors.Is(err, errInventoryNotFound) || errors.Is(err, errInsufficientStock) {
+					status = http.StatusBadRequest
+					return fmt.Errorf("%v for SKU: %s at location: %s", err, line.SKU, location)
+				}
+				return err
+			}
+			if next == models.TransferStatusInTransit && line.ProductID == "" {
+				transfer.Lines[i].ProductID = change.Inventory.ProductID
+				if err := tx.Model(&transfer.Lines[i]).Update("product_id", change.Inventory.ProductID).Error; err != nil {
+					return err
+				}
+			}
+			if next == models.TransferStatusInTransit {
+				transfer.Lines[i].Lots, err = dispatchLots(tx, line.ID, change.Allocations)
+			} else {
+				err = receiveLots(tx, &transfer, line)
+			}
+			if err != nil {
+				return err
+			}
+			if err := transferSerials(tx, &transfer, line, next); err != nil {
+				if errors.Is(err, errInsufficientStock) {
+					status = http.StatusBadRequest
+					return fmt.Errorf("not enough available serials for SKU: %s at location: %s", line.SKU, location)
+				}
+				return err
+			}
+			changes = append(changes, *change)
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"status": next, "updated_at": now}
+		if next == models.TransferStatusInTransit {
+			updates["dispatched_at"] = now
+			transfer.DispatchedAt = &now
+		} else {
+			updates["received_at"] = now
+			transfer.ReceivedAt = &now
+		}
+		transfer.Status = next
+		return tx.Model(&models.StockTransfer{}).Where("id = ?", transfer.ID).Updates(updates).Error
+	})
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	afterStockChange(changes)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer " + string(next), "transfer": transfer})
+}
+
+// transferSerials moves a serialized line's serials with the transfer: dispatch takes them
+// out of the source hub, receipt makes them available at the destination
+func transferSerials(tx *gorm.DB, transfer *models.StockTransfer, line models.StockTransferLine, next models.TransferStatus) error {
+	serialized, err := isSerializedSKU(tx, transfer.TenantID, line.SKU)
+	if err != nil || !serialized {
+		return err
+	}
+	if next == models.TransferStatusInTransit {
+		return dispatchSerials(tx, transfer, line.SKU, line.Quantity)
+	}
+	return receiveSerials(tx, transfer, line.SKU)
+}
+
+// dispatchLots records the lots a dispatched line was drawn from
+func dispatchLots(tx *gorm.DB, lineID uint, allocations []models.LotAllocation) ([]models.StockTransferLot, error) {
+	var ids []uint
+	for _, a := range allocations {
+		if a.LotID != 0 {
+			ids = append(ids, a.LotID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var lots []models.InventoryLot
+	if err := tx.Where("id IN ?", ids).Find(&lots).Error; err != nil {
+		return nil, err
+	}
+	moved := models.TransferLots(lineID, allocations, lots)
+	if len(moved) == 0 {
+		return nil, nil
+	}
+	return moved, tx.Create(&moved).Error
+}
+
+// receiveLots re-creates a received line's lots at the destination hub
+func receiveLots(tx *gorm.DB, transfer *models.StockTransfer, line models.StockTransferLine) error {
+	for _, moved := range line.Lots {
+		lot := models.InventoryLot{
+			SKU:            line.SKU,
+			Location:       transfer.DestinationHub,
+			TenantID:       transfer.TenantID,
+			SellerID:       transfer.SellerID,
+			LotNumber:      moved.LotNumber,
+			ManufacturedAt: moved.ManufacturedAt,
+			ExpiresAt:      moved.ExpiresAt,
+			Quantity:       moved.Quantity,
+		}
+		if err := addLotStock(tx, &lot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
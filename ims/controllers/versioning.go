@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mausumi-ghadei-omniful/ims/models"
+	"gorm.io/gorm"
+)
+
+var errStaleVersion = errors.New("resource was modified by another request; reload and retry")
+
+// ifMatchVersion reads the If-Match header, answering 428 when it is missing or malformed.
+func ifMatchVersion(c *gin.Context) (int, bool) {
+	version, err := models.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	return version, true
+}
+
+// updateIfVersion writes updates to row id only while it still carries version (0 matches any).
+// The database trigger bumps the version, so the caller reloads the row for the new ETag.
+func updateIfVersion(tx *gorm.DB, model interface{}, id uint, version int, updates map[string]interface{}) error {
+	query := tx.Model(model).Where("id = ?", id)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+	res := query.Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errStaleVersion
+	}
+	return nil
+}
+
+// writeUpdateError maps an updateIfVersion failure to 412 or 500.
+func writeUpdateError(c *gin.Context, err error, current int, message string) {
+	if errors.Is(err, errStaleVersion) {
+		c.Header("ETag", models.ETag(current))
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error(), "current_version": current})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
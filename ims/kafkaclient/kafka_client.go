@@ -0,0 +1,66 @@
+package kafkaclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/kafka"
+	"github.com/omniful/go_commons/pubsub"
+)
+
+var Producer *kafka.ProducerClient
+
+// LowStockTopic receives an event whenever a (sku, hub) drops to its reorder point.
+var LowStockTopic = "inventory.low_stock"
+
+// StockIncreasedTopic receives an event whenever a (sku, hub) gains stock.
+var StockIncreasedTopic = "inventory.stock_increased"
+
+func InitKafka(ctx context.Context) error {
+	brokers := config.GetString(ctx, "KAFKA_BROKERS")
+	if brokers == "" {
+		brokers = "localhost:9092"
+	}
+	if topic := config.GetString(ctx, "KAFKA_LOW_STOCK_TOPIC"); topic != "" {
+		LowStockTopic = topic
+	}
+	if topic := config.GetString(ctx, "KAFKA_STOCK_INCREASED_TOPIC"); topic != "" {
+		StockIncreasedTopic = topic
+	}
+
+	Producer = kafka.NewProducer(
+		kafka.WithBrokers(strings.Split(brokers, ",")),
+		kafka.WithClientID("ims-service"),
+		kafka.WithKafkaVersion("2.8.1"),
+	)
+	return nil
+}
+
+// Publish marshals payload to JSON and sends it to topic keyed by key.
+func Publish(ctx context.Context, topic, key string, payload interface{}) error {
+	if Producer == nil {
+		return fmt.Errorf("kafka producer not initialized")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", topic, err)
+	}
+	err = Producer.Publish(ctx, &pubsub.Message{
+		Topic: topic,
+		Value: data,
+		Key:   key,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", topic, err)
+	}
+	return nil
+}
+
+func Close() {
+	if Producer != nil {
+		Producer.Close()
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"os"
 	"time"
 
+	"github.com/mausumi-ghadei-omniful/ims/controllers"
 	"github.com/mausumi-ghadei-omniful/ims/db"
+	"github.com/mausumi-ghadei-omniful/ims/kafkaclient"
 	"github.com/mausumi-ghadei-omniful/ims/redisclient"
 	"github.com/mausumi-ghadei-omniful/ims/routes"
 
@@ -58,6 +60,15 @@ func main() {
 		}
 	}()
 
+	// Initialize Kafka producer for inventory events
+	if err := kafkaclient.InitKafka(ctx); err != nil {
+		fmt.Println("Kafka initialization failed:", err)
+	}
+	defer kafkaclient.Close()
+
+	// Take scheduled inventory snapshots for as-of queries
+	go controllers.RunSnapshotScheduler(ctx)
+
 	// Initialize HTTP server
 	server := http.InitializeServer(
 		":8084",
@@ -0,0 +1,25 @@
+-- Create stock_transfers table
+CREATE TABLE stock_transfers (
+    id SERIAL PRIMARY KEY,
+    source_hub TEXT NOT NULL,
+    destination_hub TEXT NOT NULL,
+    tenant_id TEXT,
+    seller_id TEXT,
+    status TEXT NOT NULL DEFAULT 'draft',
+    dispatched_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- Create stock_transfer_lines table
+CREATE TABLE stock_transfer_lines (
+    id SERIAL PRIMARY KEY,
+    transfer_id INTEGER NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+    sku TEXT NOT NULL,
+    product_id TEXT,
+    quantity INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX idx_stock_transfers_status ON stock_transfers (status);
+CREATE INDEX idx_stock_transfer_lines_transfer ON stock_transfer_lines (transfer_id);
@@ -0,0 +1,14 @@
+-- Create reorder_rules table
+CREATE TABLE reorder_rules (
+    id SERIAL PRIMARY KEY,
+    sku TEXT NOT NULL,
+    location TEXT NOT NULL,
+    tenant_id TEXT,
+    seller_id TEXT,
+    reorder_point INTEGER NOT NULL CHECK (reorder_point >= 0),
+    reorder_quantity INTEGER NOT NULL CHECK (reorder_quantity > 0),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    CONSTRAINT unique_reorder_sku_location UNIQUE (sku, location)
+);
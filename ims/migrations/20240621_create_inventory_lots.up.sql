@@ -0,0 +1,18 @@
+-- Create inventory_lots table
+CREATE TABLE inventory_lots (
+    id SERIAL PRIMARY KEY,
+    sku TEXT NOT NULL,
+    location TEXT NOT NULL,
+    tenant_id TEXT,
+    seller_id TEXT,
+    lot_number TEXT NOT NULL,
+    manufactured_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    CONSTRAINT unique_lot_sku_location UNIQUE (sku, location, lot_number)
+);
+
+CREATE INDEX idx_inventory_lots_expires_at ON inventory_lots (expires_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS serial_events;
+DROP TABLE IF EXISTS serial_numbers;
+ALTER TABLE skus DROP COLUMN IF EXISTS serialized;
@@ -0,0 +1,32 @@
+ALTER TABLE skus ADD COLUMN IF NOT EXISTS serialized BOOLEAN NOT NULL DEFAULT false;
+
+-- Create serial_numbers table
+CREATE TABLE serial_numbers (
+    id SERIAL PRIMARY KEY,
+    serial_number TEXT NOT NULL,
+    sku TEXT NOT NULL,
+    location TEXT NOT NULL,
+    tenant_id TEXT,
+    seller_id TEXT,
+    status TEXT NOT NULL DEFAULT 'available',
+    order_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    CONSTRAINT unique_sku_serial UNIQUE (sku, serial_number)
+);
+
+CREATE INDEX idx_serial_numbers_lookup ON serial_numbers (serial_number);
+CREATE INDEX idx_serial_numbers_available ON serial_numbers (sku, location, status);
+
+-- Create serial_events table
+CREATE TABLE serial_events (
+    id SERIAL PRIMARY KEY,
+    serial_id INTEGER NOT NULL REFERENCES serial_numbers(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    location TEXT,
+    order_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX idx_serial_events_serial ON serial_events (serial_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bin_stocks;
+DROP TABLE IF EXISTS hub_bins;
@@ -0,0 +1,26 @@
+-- Create hub_bins table: zone > aisle > rack > bin under a hub
+CREATE TABLE hub_bins (
+    id SERIAL PRIMARY KEY,
+    hub_id INTEGER NOT NULL REFERENCES hubs(id) ON DELETE CASCADE,
+    zone TEXT NOT NULL,
+    aisle TEXT NOT NULL,
+    rack TEXT NOT NULL,
+    bin TEXT NOT NULL,
+    code TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+
+    CONSTRAINT unique_hub_bin_code UNIQUE (hub_id, code)
+);
+
+-- Create bin_stocks table
+CREATE TABLE bin_stocks (
+    id SERIAL PRIMARY KEY,
+    bin_id INTEGER NOT NULL REFERENCES hub_bins(id) ON DELETE CASCADE,
+    sku TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+
+    CONSTRAINT unique_bin_sku UNIQUE (bin_id, sku)
+);
+
+CREATE INDEX idx_bin_stocks_sku ON bin_stocks (sku);
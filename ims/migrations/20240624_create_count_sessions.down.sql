@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_adjustments;
+DROP TABLE IF EXISTS count_lines;
+DROP TABLE IF EXISTS count_sessions;
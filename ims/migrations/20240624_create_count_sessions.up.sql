@@ -0,0 +1,40 @@
+-- Create count_sessions table
+CREATE TABLE count_sessions (
+    id SERIAL PRIMARY KEY,
+    location TEXT NOT NULL,
+    tenant_id TEXT,
+    seller_id TEXT,
+    status TEXT NOT NULL DEFAULT 'open',
+    reason_code TEXT,
+    approved_by TEXT,
+    submitted_at TIMESTAMPTZ,
+    approved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- Create count_lines table
+CREATE TABLE count_lines (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES count_sessions(id) ON DELETE CASCADE,
+    sku TEXT NOT NULL,
+    system_quantity INTEGER NOT NULL,
+    counted_quantity INTEGER CHECK (counted_quantity >= 0),
+    variance INTEGER NOT NULL DEFAULT 0,
+
+    CONSTRAINT unique_count_line_sku UNIQUE (session_id, sku)
+);
+
+-- Create stock_adjustments table
+CREATE TABLE stock_adjustments (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER REFERENCES count_sessions(id),
+    sku TEXT NOT NULL,
+    location TEXT NOT NULL,
+    delta INTEGER NOT NULL,
+    reason_code TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX idx_count_sessions_status ON count_sessions (status);
+CREATE INDEX idx_stock_adjustments_sku_location ON stock_adjustments (sku, location);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS inventories_record_movement ON inventories;
+DROP FUNCTION IF EXISTS record_inventory_movement();
+DROP TABLE IF EXISTS inventory_snapshots;
+DROP TABLE IF EXISTS inventory_movements;
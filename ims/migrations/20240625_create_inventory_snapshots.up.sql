@@ -0,0 +1,70 @@
+-- Create inventory_movements ledger; every quantity change on inventories lands here
+CREATE TABLE inventory_movements (
+    id BIGSERIAL PRIMARY KEY,
+    sku TEXT NOT NULL,
+    location TEXT NOT NULL,
+    tenant_id TEXT,
+    delta INTEGER NOT NULL,
+    quantity_after INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_inventory_movements_sku_location ON inventory_movements (sku, location, created_at);
+CREATE INDEX idx_inventory_movements_created_at ON inventory_movements (created_at);
+
+-- Create inventory_snapshots table
+CREATE TABLE inventory_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    taken_at TIMESTAMPTZ NOT NULL,
+    sku TEXT NOT NULL,
+    location TEXT NOT NULL,
+    tenant_id TEXT,
+    seller_id TEXT,
+    quantity INTEGER NOT NULL,
+
+    CONSTRAINT unique_snapshot_sku_location UNIQUE (taken_at, sku, location)
+);
+
+CREATE INDEX idx_inventory_snapshots_sku_location ON inventory_snapshots (sku, location, taken_at);
+
+-- Record quantity changes, soft deletes and re-keyed rows as movements
+CREATE OR REPLACE FUNCTION record_inventory_movement() RETURNS trigger AS $$
+DECLARE
+    old_qty INTEGER := 0;
+    new_qty INTEGER := 0;
+BEGIN
+    IF TG_OP <> 'INSERT' AND OLD.deleted_at IS NULL THEN
+        old_qty := OLD.quantity;
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.deleted_at IS NULL THEN
+        new_qty := NEW.quantity;
+    END IF;
+
+    IF TG_OP = 'DELETE' OR (TG_OP = 'UPDATE' AND (OLD.sku, OLD.location) IS DISTINCT FROM (NEW.sku, NEW.location)) THEN
+        IF old_qty <> 0 THEN
+            INSERT INTO inventory_movements (sku, location, tenant_id, delta, quantity_after)
+            VALUES (OLD.sku, OLD.location, OLD.tenant_id, -old_qty, 0);
+        END IF;
+        IF TG_OP = 'DELETE' THEN
+            RETURN OLD;
+        END IF;
+        old_qty := 0;
+    END IF;
+
+    IF new_qty <> old_qty THEN
+        INSERT INTO inventory_movements (sku, location, tenant_id, delta, quantity_after)
+        VALUES (NEW.sku, NEW.location, NEW.tenant_id, new_qty - old_qty, new_qty);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER inventories_record_movement
+AFTER INSERT OR UPDATE OR DELETE ON inventories
+FOR EACH ROW EXECUTE FUNCTION record_inventory_movement();
+
+-- Baseline snapshot so as-of queries have a starting point for existing stock
+INSERT INTO inventory_snapshots (taken_at, sku, location, tenant_id, seller_id, quantity)
+SELECT now(), sku, location, tenant_id, seller_id, quantity
+FROM inventories
+WHERE deleted_at IS NULL;
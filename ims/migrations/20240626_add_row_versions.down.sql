@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS hubs_bump_version ON hubs;
+DROP TRIGGER IF EXISTS skus_bump_version ON skus;
+DROP TRIGGER IF EXISTS inventories_bump_version ON inventories;
+DROP FUNCTION IF EXISTS bump_row_version();
+
+ALTER TABLE hubs DROP COLUMN IF EXISTS version;
+ALTER TABLE skus DROP COLUMN IF EXISTS version;
+ALTER TABLE inventories DROP COLUMN IF EXISTS version;
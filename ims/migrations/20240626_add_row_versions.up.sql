@@ -0,0 +1,21 @@
+-- Add version columns for optimistic concurrency
+ALTER TABLE inventories ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE skus ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE hubs ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- Bump the version on every update so stock movements also invalidate outstanding ETags
+CREATE OR REPLACE FUNCTION bump_row_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER inventories_bump_version BEFORE UPDATE ON inventories
+FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+
+CREATE TRIGGER skus_bump_version BEFORE UPDATE ON skus
+FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+
+CREATE TRIGGER hubs_bump_version BEFORE UPDATE ON hubs
+FOR EACH ROW EXECUTE FUNCTION bump_row_version();
@@ -0,0 +1,32 @@
+DROP INDEX IF EXISTS idx_count_sessions_tenant;
+DROP INDEX IF EXISTS idx_stock_transfers_tenant;
+DROP INDEX IF EXISTS idx_inventory_movements_tenant;
+
+ALTER TABLE inventory_snapshots DROP CONSTRAINT unique_tenant_snapshot_sku_location;
+ALTER TABLE inventory_snapshots ADD CONSTRAINT unique_snapshot_sku_location UNIQUE (taken_at, sku, location);
+
+ALTER TABLE serial_numbers DROP CONSTRAINT unique_tenant_sku_serial;
+ALTER TABLE serial_numbers ADD CONSTRAINT unique_sku_serial UNIQUE (sku, serial_number);
+
+ALTER TABLE reorder_rules DROP CONSTRAINT unique_tenant_reorder_sku_location;
+ALTER TABLE reorder_rules ADD CONSTRAINT unique_reorder_sku_location UNIQUE (sku, location);
+
+ALTER TABLE inventory_lots DROP CONSTRAINT unique_tenant_lot_sku_location;
+ALTER TABLE inventory_lots ADD CONSTRAINT unique_lot_sku_location UNIQUE (sku, location, lot_number);
+
+ALTER TABLE skus DROP CONSTRAINT unique_tenant_sku_code;
+ALTER TABLE skus ADD CONSTRAINT skus_code_key UNIQUE (code);
+
+ALTER TABLE inventories DROP CONSTRAINT unique_tenant_sku_location;
+ALTER TABLE inventories ADD CONSTRAINT unique_sku_location UNIQUE (sku, location);
+
+ALTER TABLE count_sessions ALTER COLUMN tenant_id DROP NOT NULL;
+ALTER TABLE stock_transfers ALTER COLUMN tenant_id DROP NOT NULL;
+ALTER TABLE inventory_movements ALTER COLUMN tenant_id DROP NOT NULL;
+ALTER TABLE inventory_snapshots ALTER COLUMN tenant_id DROP NOT NULL;
+ALTER TABLE serial_numbers ALTER COLUMN tenant_id DROP NOT NULL;
+ALTER TABLE reorder_rules ALTER COLUMN tenant_id DROP NOT NULL;
+ALTER TABLE inventory_lots ALTER COLUMN tenant_id DROP NOT NULL;
+ALTER TABLE hubs ALTER COLUMN tenant_id DROP NOT NULL;
+ALTER TABLE skus ALTER COLUMN tenant_id DROP NOT NULL;
+ALTER TABLE inventories ALTER COLUMN tenant_id DROP NOT NULL;
@@ -0,0 +1,83 @@
+-- Backfill tenants: inventory inherits from its SKU, then from its hub; anything left goes to 'default'
+UPDATE inventories i SET tenant_id = s.tenant_id
+FROM skus s
+WHERE COALESCE(i.tenant_id, '') = '' AND s.code = i.sku AND COALESCE(s.tenant_id, '') <> '';
+
+UPDATE inventories i SET tenant_id = h.tenant_id
+FROM hubs h
+WHERE COALESCE(i.tenant_id, '') = '' AND h.location = i.location AND COALESCE(h.tenant_id, '') <> '';
+
+UPDATE inventories SET tenant_id = 'default' WHERE COALESCE(tenant_id, '') = '';
+UPDATE skus SET tenant_id = 'default' WHERE COALESCE(tenant_id, '') = '';
+UPDATE hubs SET tenant_id = 'default' WHERE COALESCE(tenant_id, '') = '';
+
+-- Rows keyed by (sku, location) take the tenant of the matching inventory row
+UPDATE inventory_lots l SET tenant_id = i.tenant_id
+FROM inventories i
+WHERE COALESCE(l.tenant_id, '') = '' AND i.sku = l.sku AND i.location = l.location;
+
+UPDATE reorder_rules r SET tenant_id = i.tenant_id
+FROM inventories i
+WHERE COALESCE(r.tenant_id, '') = '' AND i.sku = r.sku AND i.location = r.location;
+
+UPDATE serial_numbers n SET tenant_id = i.tenant_id
+FROM inventories i
+WHERE COALESCE(n.tenant_id, '') = '' AND i.sku = n.sku AND i.location = n.location;
+
+UPDATE inventory_snapshots n SET tenant_id = i.tenant_id
+FROM inventories i
+WHERE COALESCE(n.tenant_id, '') = '' AND i.sku = n.sku AND i.location = n.location;
+
+UPDATE inventory_movements m SET tenant_id = i.tenant_id
+FROM inventories i
+WHERE COALESCE(m.tenant_id, '') = '' AND i.sku = m.sku AND i.location = m.location;
+
+UPDATE stock_transfers t SET tenant_id = h.tenant_id
+FROM hubs h
+WHERE COALESCE(t.tenant_id, '') = '' AND h.location = t.source_hub;
+
+UPDATE count_sessions s SET tenant_id = h.tenant_id
+FROM hubs h
+WHERE COALESCE(s.tenant_id, '') = '' AND h.location = s.location;
+
+UPDATE inventory_lots SET tenant_id = 'default' WHERE COALESCE(tenant_id, '') = '';
+UPDATE reorder_rules SET tenant_id = 'default' WHERE COALESCE(tenant_id, '') = '';
+UPDATE serial_numbers SET tenant_id = 'default' WHERE COALESCE(tenant_id, '') = '';
+UPDATE inventory_snapshots SET tenant_id = 'default' WHERE COALESCE(tenant_id, '') = '';
+UPDATE inventory_movements SET tenant_id = 'default' WHERE COALESCE(tenant_id, '') = '';
+UPDATE stock_transfers SET tenant_id = 'default' WHERE COALESCE(tenant_id, '') = '';
+UPDATE count_sessions SET tenant_id = 'default' WHERE COALESCE(tenant_id, '') = '';
+
+ALTER TABLE inventories ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE skus ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE hubs ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE inventory_lots ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE reorder_rules ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE serial_numbers ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE inventory_snapshots ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE inventory_movements ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE stock_transfers ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE count_sessions ALTER COLUMN tenant_id SET NOT NULL;
+
+-- Rekey uniqueness per tenant
+ALTER TABLE inventories DROP CONSTRAINT unique_sku_location;
+ALTER TABLE inventories ADD CONSTRAINT unique_tenant_sku_location UNIQUE (tenant_id, sku, location);
+
+ALTER TABLE skus DROP CONSTRAINT skus_code_key;
+ALTER TABLE skus ADD CONSTRAINT unique_tenant_sku_code UNIQUE (tenant_id, code);
+
+ALTER TABLE inventory_lots DROP CONSTRAINT unique_lot_sku_location;
+ALTER TABLE inventory_lots ADD CONSTRAINT unique_tenant_lot_sku_location UNIQUE (tenant_id, sku, location, lot_number);
+
+ALTER TABLE reorder_rules DROP CONSTRAINT unique_reorder_sku_location;
+ALTER TABLE reorder_rules ADD CONSTRAINT unique_tenant_reorder_sku_location UNIQUE (tenant_id, sku, location);
+
+ALTER TABLE serial_numbers DROP CONSTRAINT unique_sku_serial;
+ALTER TABLE serial_numbers ADD CONSTRAINT unique_tenant_sku_serial UNIQUE (tenant_id, sku, serial_number);
+
+ALTER TABLE inventory_snapshots DROP CONSTRAINT unique_snapshot_sku_location;
+ALTER TABLE inventory_snapshots ADD CONSTRAINT unique_tenant_snapshot_sku_location UNIQUE (taken_at, tenant_id, sku, location);
+
+CREATE INDEX idx_inventory_movements_tenant ON inventory_movements (tenant_id, created_at);
+CREATE INDEX idx_stock_transfers_tenant ON stock_transfers (tenant_id, status);
+CREATE INDEX idx_count_sessions_tenant ON count_sessions (tenant_id, status);
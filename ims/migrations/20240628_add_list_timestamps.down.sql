@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_hubs_tenant_name;
+DROP INDEX IF EXISTS idx_skus_tenant_name;
+DROP INDEX IF EXISTS idx_hubs_tenant_updated;
+DROP INDEX IF EXISTS idx_skus_tenant_updated;
+DROP INDEX IF EXISTS idx_inventories_tenant_updated;
+
+ALTER TABLE hubs DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE hubs DROP COLUMN IF EXISTS created_at;
+ALTER TABLE skus DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE skus DROP COLUMN IF EXISTS created_at;
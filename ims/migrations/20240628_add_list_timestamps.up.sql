@@ -0,0 +1,11 @@
+-- Track timestamps on skus and hubs for updated_since filtering and sorting
+ALTER TABLE skus ADD COLUMN created_at TIMESTAMPTZ DEFAULT now();
+ALTER TABLE skus ADD COLUMN updated_at TIMESTAMPTZ DEFAULT now();
+ALTER TABLE hubs ADD COLUMN created_at TIMESTAMPTZ DEFAULT now();
+ALTER TABLE hubs ADD COLUMN updated_at TIMESTAMPTZ DEFAULT now();
+
+CREATE INDEX idx_inventories_tenant_updated ON inventories (tenant_id, updated_at, id);
+CREATE INDEX idx_skus_tenant_updated ON skus (tenant_id, updated_at, id);
+CREATE INDEX idx_hubs_tenant_updated ON hubs (tenant_id, updated_at, id);
+CREATE INDEX idx_skus_tenant_name ON skus (tenant_id, name text_pattern_ops);
+CREATE INDEX idx_hubs_tenant_name ON hubs (tenant_id, name text_pattern_ops);
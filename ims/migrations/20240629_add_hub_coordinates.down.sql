@@ -0,0 +1,3 @@
+ALTER TABLE hubs DROP CONSTRAINT IF EXISTS hubs_coordinates_check;
+ALTER TABLE hubs DROP COLUMN IF EXISTS longitude;
+ALTER TABLE hubs DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,8 @@
+-- Optional hub coordinates used by OMS nearest-hub allocation
+ALTER TABLE hubs ADD COLUMN latitude DOUBLE PRECISION;
+ALTER TABLE hubs ADD COLUMN longitude DOUBLE PRECISION;
+
+ALTER TABLE hubs ADD CONSTRAINT hubs_coordinates_check CHECK (
+    (latitude IS NULL AND longitude IS NULL)
+    OR (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
@@ -0,0 +1,18 @@
+-- Returned stock received from OMS returns; quarantined rows are held out of sellable inventory
+CREATE TABLE stock_returns (
+    id SERIAL PRIMARY KEY,
+    return_id TEXT NOT NULL,
+    order_id TEXT,
+    sku TEXT NOT NULL,
+    location TEXT NOT NULL,
+    tenant_id TEXT NOT NULL,
+    seller_id TEXT,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    disposition TEXT NOT NULL CHECK (disposition IN ('sellable', 'quarantine')),
+    reason TEXT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+
+    CONSTRAINT unique_stock_return_line UNIQUE (tenant_id, return_id, sku)
+);
+
+CREATE INDEX idx_stock_returns_quarantine ON stock_returns (tenant_id, location, sku) WHERE disposition = 'quarantine';
@@ -0,0 +1,11 @@
+-- Lots taken out of the source hub when a transfer is dispatched; receipt re-creates them at the destination
+CREATE TABLE stock_transfer_lots (
+    id SERIAL PRIMARY KEY,
+    line_id INTEGER NOT NULL REFERENCES stock_transfer_lines(id) ON DELETE CASCADE,
+    lot_number TEXT NOT NULL,
+    manufactured_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    quantity INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX idx_stock_transfer_lots_line ON stock_transfer_lots (line_id);
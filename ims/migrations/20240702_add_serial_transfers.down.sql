@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_serial_numbers_transfer;
+
+ALTER TABLE serial_numbers DROP COLUMN IF EXISTS transfer_id;
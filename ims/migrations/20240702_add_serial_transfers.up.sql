@@ -0,0 +1,4 @@
+-- Serials travel with stock transfers: dispatch marks them in transit on the transfer, receipt moves them to the destination
+ALTER TABLE serial_numbers ADD COLUMN transfer_id INTEGER REFERENCES stock_transfers(id);
+
+CREATE INDEX idx_serial_numbers_transfer ON serial_numbers (transfer_id) WHERE transfer_id IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Bin picks already taken for an order, by its stock reduction or a pick list
+CREATE TABLE order_picks (
+    id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    location TEXT NOT NULL,
+    order_id TEXT NOT NULL,
+    sku TEXT NOT NULL,
+    bin_id INTEGER NOT NULL REFERENCES hub_bins(id) ON DELETE CASCADE,
+    bin_code TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_picks_order ON order_picks (tenant_id, order_id, sku);
@@ -0,0 +1,13 @@
+-- One row per order a reduction was applied for, so a retried reduction for the same order is a no-op.
+-- Location and quantity are stored but not keyed: a retry naming another hub or quantity must be
+-- refused, not applied as a second reduction of the same order.
+CREATE TABLE order_reductions (
+    id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    order_id TEXT NOT NULL,
+    sku TEXT NOT NULL,
+    location TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_tenant_order_reduction UNIQUE (tenant_id, order_id, sku)
+);
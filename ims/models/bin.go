@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HubBin is the smallest storage location inside a hub: zone > aisle > rack > bin
+type HubBin struct {
+	ID        uint      `json:"id"`
+	HubID     uint      `json:"hub_id"`
+	Zone      string    `json:"zone"`
+	Aisle     string    `json:"aisle"`
+	Rack      string    `json:"rack"`
+	Bin       string    `json:"bin"`
+	Code      string    `json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BinStock struct {
+	ID        uint      `json:"id"`
+	BinID     uint      `json:"bin_id"`
+	SKU       string    `json:"sku"`
+	Quantity  int       `json:"quantity"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Pick tells a picker how many units of a SKU to take from which bin for an order
+type Pick struct {
+	OrderID  string `json:"order_id"`
+	SKU      string `json:"sku"`
+	BinID    uint   `json:"bin_id"`
+	BinCode  string `json:"bin_code"`
+	Quantity int    `json:"quantity"`
+}
+
+// OrderPick is a pick already taken out of its bin for an order, so a later pick list
+// does not take the same units again
+type OrderPick struct {
+	ID        uint      `json:"id"`
+	TenantID  string    `json:"tenant_id"`
+	Location  string    `json:"location"`
+	CreatedAt time.Time `json:"created_at"`
+	Pick      `gorm:"embedded"`
+}
+
+// BinQuantity is the stock of one SKU in one bin, used for pick planning
+type BinQuantity struct {
+	BinID    uint
+	BinCode  string
+	Quantity int
+}
+
+// Validate checks that every level of the hierarchy is set
+func (b *HubBin) Validate() error {
+	if strings.TrimSpace(b.Zone) == "" || strings.TrimSpace(b.Aisle) == "" ||
+		strings.TrimSpace(b.Rack) == "" || strings.TrimSpace(b.Bin) == "" {
+		return errors.New("zone, aisle, rack and bin are required")
+	}
+	return nil
+}
+
+// BuildCode returns the bin's address as ZONE-AISLE-RACK-BIN
+func (b *HubBin) BuildCode() string {
+	return strings.ToUpper(fmt.Sprintf("%s-%s-%s-%s",
+		strings.TrimSpace(b.Zone), strings.TrimSpace(b.Aisle), strings.TrimSpace(b.Rack), strings.TrimSpace(b.Bin)))
+}
+
+// PlanPicks takes quantity from bins in bin-code order, so the pick list follows the
+// walking path through the hub. It returns the picks and the quantity it could not cover.
+func PlanPicks(orderID, sku string, bins []BinQuantity, quantity int) ([]Pick, int) {
+	sorted := make([]BinQuantity, len(bins))
+	copy(sorted, bins)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].BinCode < sorted[j].BinCode })
+
+	var picks []Pick
+	remaining := quantity
+	for _, bin := range sorted {
+		if remaining == 0 {
+			break
+		}
+		if bin.Quantity <= 0 {
+			continue
+		}
+		take := min(bin.Quantity, remaining)
+		picks = append(picks, Pick{OrderID: orderID, SKU: sku, BinID: bin.BinID, BinCode: bin.BinCode, Quantity: take})
+		remaining -= take
+	}
+	return picks, remaining
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+)
+
+// TestHubBinCode
+func TestHubBinCode(t *testing.T) {
+	bin := HubBin{Zone: "a", Aisle: "01", Rack: "03", Bin: "b "}
+	if err := bin.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if got := bin.BuildCode(); got != "A-01-03-B" {
+		t.Errorf("BuildCode() = %s, want A-01-03-B", got)
+	}
+	if err := (&HubBin{Zone: "A", Aisle: "01"}).Validate(); err == nil {
+		t.Error("Validate() expected error for missing rack and bin")
+	}
+}
+
+// TestPlanPicks
+func TestPlanPicks(t *testing.T) {
+	bins := []BinQuantity{
+		{BinID: 2, BinCode: "B-01-01-A", Quantity: 5},
+		{BinID: 1, BinCode: "A-02-01-A", Quantity: 3},
+		{BinID: 3, BinCode: "A-01-01-A", Quantity: 0},
+	}
+
+	picks, short := PlanPicks("ORD-1", "SKU23", bins, 6)
+	if short != 0 || len(picks) != 2 {
+		t.Fatalf("PlanPicks() = %+v, short %d", picks, short)
+	}
+	if picks[0].BinID != 1 || picks[0].Quantity != 3 || picks[1].BinID != 2 || picks[1].Quantity != 3 {
+		t.Errorf("PlanPicks() = %+v, want 3 from bin 1 then 3 from bin 2", picks)
+	}
+
+	_, short = PlanPicks("ORD-2", "SKU23", bins, 10)
+	if short != 2 {
+		t.Errorf("PlanPicks() short = %d, want 2", short)
+	}
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type CountStatus string
+
+const (
+	CountStatusOpen      CountStatus = "open"
+	CountStatusSubmitted CountStatus = "submitted"
+	CountStatusApproved  CountStatus = "approved"
+)
+
+// AdjustmentReasons are the reason codes accepted when posting count variances
+var AdjustmentReasons = map[string]bool{
+	"miscount": true,
+	"damaged":  true,
+	"lost":     true,
+	"found":    true,
+	"theft":    true,
+	"expired":  true,
+}
+
+type CountSession struct {
+	ID          uint        `json:"id"`
+	Location    string      `json:"location"`
+	TenantID    string      `json:"tenant_id"`
+	SellerID    string      `json:"seller_id"`
+	Status      CountStatus `json:"status"`
+	ReasonCode  string      `json:"reason_code,omitempty"`
+	ApprovedBy  string      `json:"approved_by,omitempty"`
+	Lines       []CountLine `json:"lines" gorm:"foreignKey:SessionID"`
+	SubmittedAt *time.Time  `json:"submitted_at,omitempty"`
+	ApprovedAt  *time.Time  `json:"approved_at,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// CountLine holds the system quantity captured when the session was created and what was counted
+type CountLine struct {
+	ID              uint   `json:"id"`
+	SessionID       uint   `json:"session_id"`
+	SKU             string `json:"sku"`
+	SystemQuantity  int    `json:"system_quantity"`
+	CountedQuantity *int   `json:"counted_quantity"`
+	Variance        int    `json:"variance"`
+}
+
+// StockAdjustment is a posted quantity correction
+type StockAdjustment struct {
+	ID         uint      `json:"id"`
+	SessionID  *uint     `json:"session_id,omitempty"`
+	SKU        string    `json:"sku"`
+	Location   string    `json:"location"`
+	Delta      int       `json:"delta"`
+	ReasonCode string    `json:"reason_code"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Validate checks the session targets a location and lists each SKU once
+func (s *CountSession) Validate() error {
+	if strings.TrimSpace(s.Location) == "" {
+		return errors.New("location is required")
+	}
+	seen := make(map[string]bool)
+	for _, line := range s.Lines {
+		if strings.TrimSpace(line.SKU) == "" {
+			return errors.New("line sku is required")
+		}
+		if seen[line.SKU] {
+			return errors.New("duplicate sku in lines: " + line.SKU)
+		}
+		seen[line.SKU] = true
+	}
+	return nil
+}
+
+// RecordCounts stores counted quantities on matching lines and recomputes their variance.
+// It returns true once every line has been counted.
+func (s *CountSession) RecordCounts(counts map[string]int) (bool, error) {
+	known := make(map[string]bool, len(s.Lines))
+	for _, line := range s.Lines {
+		known[line.SKU] = true
+	}
+	for sku, counted := range counts {
+		if !known[sku] {
+			return false, fmt.Errorf("sku %s is not part of this count", sku)
+		}
+		if counted < 0 {
+			return false, fmt.Errorf("counted quantity for %s cannot be negative", sku)
+		}
+	}
+
+	complete := true
+	for i := range s.Lines {
+		line := &s.Lines[i]
+		if counted, ok := counts[line.SKU]; ok {
+			line.CountedQuantity = &counted
+			line.Variance = counted - line.SystemQuantity
+		}
+		if line.CountedQuantity == nil {
+			complete = false
+		}
+	}
+	return complete, nil
+}
+
+// Variances returns the lines whose counted quantity differs from the system quantity
+func (s *CountSession) Variances() []CountLine {
+	variances := []CountLine{}
+	for _, line := range s.Lines {
+		if line.CountedQuantity != nil && line.Variance != 0 {
+			variances = append(variances, line)
+		}
+	}
+	return variances
+}
@@ -0,0 +1,79 @@
+package models
+
+import "testing"
+
+func intPtr(v int) *int { return &v }
+
+// TestCountSessionValidation
+func TestCountSessionValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		session CountSession
+		wantErr bool
+	}{
+		{"whole hub", CountSession{Location: "HU001"}, false},
+		{"sku set", CountSession{Location: "HU001", Lines: []CountLine{{SKU: "S1"}, {SKU: "S2"}}}, false},
+		{"missing location", CountSession{Lines: []CountLine{{SKU: "S1"}}}, true},
+		{"blank sku", CountSession{Location: "HU001", Lines: []CountLine{{SKU: " "}}}, true},
+		{"duplicate sku", CountSession{Location: "HU001", Lines: []CountLine{{SKU: "S1"}, {SKU: "S1"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.session.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRecordCounts
+func TestRecordCounts(t *testing.T) {
+	session := CountSession{Lines: []CountLine{
+		{SKU: "S1", SystemQuantity: 10},
+		{SKU: "S2", SystemQuantity: 5},
+		{SKU: "S3", SystemQuantity: 0},
+	}}
+
+	complete, err := session.RecordCounts(map[string]int{"S1": 8, "S2": 5})
+	if err != nil || complete {
+		t.Fatalf("first submission: complete = %v, err = %v", complete, err)
+	}
+	complete, err = session.RecordCounts(map[string]int{"S3": 2})
+	if err != nil || !complete {
+		t.Fatalf("second submission: complete = %v, err = %v", complete, err)
+	}
+
+	variances := session.Variances()
+	if len(variances) != 2 {
+		t.Fatalf("variances = %+v, want S1 and S3", variances)
+	}
+	if variances[0].SKU != "S1" || variances[0].Variance != -2 {
+		t.Errorf("S1 variance = %+v, want -2", variances[0])
+	}
+	if variances[1].SKU != "S3" || variances[1].Variance != 2 {
+		t.Errorf("S3 variance = %+v, want 2", variances[1])
+	}
+}
+
+// TestRecordCountsRejects
+func TestRecordCountsRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts map[string]int
+	}{
+		{"unknown sku", map[string]int{"S9": 1}},
+		{"negative count", map[string]int{"S1": -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := CountSession{Lines: []CountLine{{SKU: "S1", SystemQuantity: 3, CountedQuantity: intPtr(3)}}}
+			if _, err := session.RecordCounts(tt.counts); err == nil {
+				t.Error("RecordCounts() error = nil, want error")
+			}
+			if *session.Lines[0].CountedQuantity != 3 {
+				t.Errorf("rejected submission changed line: %+v", session.Lines[0])
+			}
+		})
+	}
+}
@@ -3,14 +3,21 @@ package models
 import (
 	"errors"
 	"strings"
+	"time"
 )
 
 type Hub struct {
-	ID       uint   `json:"id"`
-	Name     string `json:"name"`
-	Location string `json:"location"`
-	TenantID string `json:"tenant_id"`
-	SellerID string `json:"seller_id"`
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Location  string    `json:"location"`
+	TenantID  string    `json:"tenant_id"`
+	SellerID  string    `json:"seller_id"`
+	Latitude  *float64  `json:"latitude,omitempty"`
+	Longitude *float64  `json:"longitude,omitempty"`
+	Version   int       `json:"version" gorm:"default:1"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Bins      []HubBin  `json:"bins,omitempty" gorm:"foreignKey:HubID"`
 }
 
 // Validate checks if the hub has required fields
@@ -24,5 +31,22 @@ func (h *Hub) Validate() error {
 	if strings.TrimSpace(h.TenantID) == "" {
 		return errors.New("tenant_id is required")
 	}
+	return ValidateCoordinates(h.Latitude, h.Longitude)
+}
+
+// ValidateCoordinates checks that latitude and longitude are given together and in range
+func ValidateCoordinates(latitude, longitude *float64) error {
+	if (latitude == nil) != (longitude == nil) {
+		return errors.New("latitude and longitude must be given together")
+	}
+	if latitude == nil {
+		return nil
+	}
+	if *latitude < -90 || *latitude > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if *longitude < -180 || *longitude > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
 	return nil
 }
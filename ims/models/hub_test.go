@@ -4,6 +4,8 @@ import (
 	"testing"
 )
 
+func floatPtr(v float64) *float64 { return &v }
+
 // TestHubValidation
 func TestHubValidation(t *testing.T) {
 	tests := []struct {
@@ -51,6 +53,38 @@ func TestHubValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "with coordinates",
+			hub: Hub{
+				Name:      "Test Hub",
+				Location:  "New York",
+				TenantID:  "tenant123",
+				Latitude:  floatPtr(40.7128),
+				Longitude: floatPtr(-74.006),
+			},
+			wantErr: false,
+		},
+		{
+			name: "latitude without longitude",
+			hub: Hub{
+				Name:     "Test Hub",
+				Location: "New York",
+				TenantID: "tenant123",
+				Latitude: floatPtr(40.7128),
+			},
+			wantErr: true,
+		},
+		{
+			name: "latitude out of range",
+			hub: Hub{
+				Name:      "Test Hub",
+				Location:  "New York",
+				TenantID:  "tenant123",
+				Latitude:  floatPtr(95.0),
+				Longitude: floatPtr(-74.006),
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
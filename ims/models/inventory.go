@@ -16,7 +16,43 @@ type Inventory struct {
 	SellerID string `json:"seller_id"`
 
 	Quantity  int            `json:"quantity"`
+	Version   int            `json:"version" gorm:"default:1"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-"`
 }
+
+// OrderReduction records that an order's stock was already reduced
+type OrderReduction struct {
+	ID        uint      `json:"id"`
+	TenantID  string    `json:"tenant_id"`
+	OrderID   string    `json:"order_id"`
+	SKU       string    `json:"sku"`
+	Location  string    `json:"location"`
+	Quantity  int       `json:"quantity"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StockIncrease is emitted when a (sku, hub) gains stock so waiting orders can be retried
+type StockIncrease struct {
+	SKU       string    `json:"sku"`
+	Location  string    `json:"location"`
+	TenantID  string    `json:"tenant_id"`
+	SellerID  string    `json:"seller_id"`
+	Previous  int       `json:"previous"`
+	Quantity  int       `json:"quantity"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Increase builds the stock increase event for a change from previous to the row's quantity
+func (i *Inventory) Increase(previous int) StockIncrease {
+	return StockIncrease{
+		SKU:       i.SKU,
+		Location:  i.Location,
+		TenantID:  i.TenantID,
+		SellerID:  i.SellerID,
+		Previous:  previous,
+		Quantity:  i.Quantity,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+type InventoryLot struct {
+	ID             uint       `json:"id"`
+	SKU            string     `json:"sku"`
+	Location       string     `json:"location"`
+	TenantID       string     `json:"tenant_id"`
+	SellerID       string     `json:"seller_id"`
+	LotNumber      string     `json:"lot_number"`
+	ManufacturedAt *time.Time `json:"manufactured_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	Quantity       int        `json:"quantity"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// LotAllocation is the quantity taken from one lot; an empty LotNumber means untracked stock
+type LotAllocation struct {
+	LotID     uint   `json:"lot_id,omitempty"`
+	LotNumber string `json:"lot_number"`
+	Quantity  int    `json:"quantity"`
+}
+
+// Validate checks if the lot has required fields and consistent dates
+func (l *InventoryLot) Validate() error {
+	if strings.TrimSpace(l.SKU) == "" {
+		return errors.New("sku is required")
+	}
+	if strings.TrimSpace(l.Location) == "" {
+		return errors.New("location is required")
+	}
+	if strings.TrimSpace(l.LotNumber) == "" {
+		return errors.New("lot_number is required")
+	}
+	if l.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+	if l.ManufacturedAt != nil && l.ExpiresAt != nil && !l.ExpiresAt.After(*l.ManufacturedAt) {
+		return errors.New("expires_at must be after manufactured_at")
+	}
+	return nil
+}
+
+// IsExpired reports whether the lot can no longer be allocated at now
+func (l *InventoryLot) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !l.ExpiresAt.After(now)
+}
+
+// AllocateFEFO picks quantity units first-expire-first-out across lots, skipping expired
+// lots and using untracked stock last. It returns the allocations and the total
+// allocatable quantity; the allocation is only complete when available >= quantity.
+func AllocateFEFO(lots []InventoryLot, untracked, quantity int, now time.Time) ([]LotAllocation, int) {
+	return allocateLots(lots, untracked, quantity, func(lot InventoryLot) bool { return !lot.IsExpired(now) })
+}
+
+// AllocateWriteOff picks quantity units to write off the same way AllocateFEFO does, but
+// takes expired lots too, first, since stock found missing on a count may be expired.
+func AllocateWriteOff(lots []InventoryLot, untracked, quantity int, now time.Time) ([]LotAllocation, int) {
+	return allocateLots(lots, untracked, quantity, func(InventoryLot) bool { return true })
+}
+
+func allocateLots(lots []InventoryLot, untracked, quantity int, eligible func(InventoryLot) bool) ([]LotAllocation, int) {
+	usable := make([]InventoryLot, 0, len(lots))
+	available := 0
+	for _, lot := range lots {
+		if lot.Quantity > 0 && eligible(lot) {
+			usable = append(usable, lot)
+			available += lot.Quantity
+		}
+	}
+	if untracked > 0 {
+		available += untracked
+	}
+
+	sort.SliceStable(usable, func(i, j int) bool {
+		a, b := usable[i].ExpiresAt, usable[j].ExpiresAt
+		switch {
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		default:
+			return a.Before(*b)
+		}
+	})
+
+	var allocations []LotAllocation
+	remaining := quantity
+	for _, lot := range usable {
+		if remaining == 0 {
+			break
+		}
+		take := min(lot.Quantity, remaining)
+		allocations = append(allocations, LotAllocation{LotID: lot.ID, LotNumber: lot.LotNumber, Quantity: take})
+		remaining -= take
+	}
+	if remaining > 0 && untracked > 0 {
+		allocations = append(allocations, LotAllocation{Quantity: min(untracked, remaining)})
+	}
+	return allocations, available
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func datePtr(t time.Time) *time.Time { return &t }
+
+// TestAllocateFEFO
+func TestAllocateFEFO(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	lots := []InventoryLot{
+		{ID: 1, LotNumber: "LATE", Quantity: 10, ExpiresAt: datePtr(now.AddDate(0, 3, 0))},
+		{ID: 2, LotNumber: "EXPIRED", Quantity: 10, ExpiresAt: datePtr(now.AddDate(0, 0, -1))},
+		{ID: 3, LotNumber: "SOON", Quantity: 4, ExpiresAt: datePtr(now.AddDate(0, 0, 7))},
+		{ID: 4, LotNumber: "NO-EXPIRY", Quantity: 5},
+	}
+
+	allocations, available := AllocateFEFO(lots, 2, 16, now)
+	if available != 21 {
+		t.Fatalf("available = %d, want 21", available)
+	}
+	want := []LotAllocation{
+		{LotID: 3, LotNumber: "SOON", Quantity: 4},
+		{LotID: 1, LotNumber: "LATE", Quantity: 10},
+		{LotID: 4, LotNumber: "NO-EXPIRY", Quantity: 2},
+	}
+	if len(allocations) != len(want) {
+		t.Fatalf("allocations = %+v, want %+v", allocations, want)
+	}
+	for i := range want {
+		if allocations[i] != want[i] {
+			t.Errorf("allocation %d = %+v, want %+v", i, allocations[i], want[i])
+		}
+	}
+}
+
+// TestAllocateFEFOUntrackedLast
+func TestAllocateFEFOUntrackedLast(t *testing.T) {
+	now := time.Now()
+	lots := []InventoryLot{{ID: 1, LotNumber: "L1", Quantity: 3, ExpiresAt: datePtr(now.Add(time.Hour))}}
+
+	allocations, available := AllocateFEFO(lots, 5, 6, now)
+	if available != 8 || len(allocations) != 2 {
+		t.Fatalf("got %+v (available %d)", allocations, available)
+	}
+	if allocations[1].LotNumber != "" || allocations[1].Quantity != 3 {
+		t.Errorf("untracked allocation = %+v, want 3 untracked units", allocations[1])
+	}
+}
+
+// TestAllocateWriteOff
+func TestAllocateWriteOff(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	lots := []InventoryLot{
+		{ID: 1, LotNumber: "LATE", Quantity: 10, ExpiresAt: datePtr(now.AddDate(0, 3, 0))},
+		{ID: 2, LotNumber: "EXPIRED", Quantity: 6, ExpiresAt: datePtr(now.AddDate(0, 0, -1))},
+	}
+
+	allocations, available := AllocateWriteOff(lots, 1, 8, now)
+	if available != 17 {
+		t.Fatalf("available = %d, want 17", available)
+	}
+	want := []LotAllocation{
+		{LotID: 2, LotNumber: "EXPIRED", Quantity: 6},
+		{LotID: 1, LotNumber: "LATE", Quantity: 2},
+	}
+	if len(allocations) != len(want) {
+		t.Fatalf("allocations = %+v, want %+v", allocations, want)
+	}
+	for i := range want {
+		if allocations[i] != want[i] {
+			t.Errorf("allocation %d = %+v, want %+v", i, allocations[i], want[i])
+		}
+	}
+}
+
+// TestInventoryLotValidation
+func TestInventoryLotValidation(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		lot     InventoryLot
+		wantErr bool
+	}{
+		{"valid lot", InventoryLot{SKU: "SKU23", Location: "HU001", LotNumber: "L1", Quantity: 5}, false},
+		{"missing lot number", InventoryLot{SKU: "SKU23", Location: "HU001", Quantity: 5}, true},
+		{"zero quantity", InventoryLot{SKU: "SKU23", Location: "HU001", LotNumber: "L1"}, true},
+		{"expiry before manufacture", InventoryLot{SKU: "SKU23", Location: "HU001", LotNumber: "L1", Quantity: 5,
+			ManufacturedAt: datePtr(now), ExpiresAt: datePtr(now.Add(-time.Hour))}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.lot.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InventoryLot.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
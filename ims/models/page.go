@@ -0,0 +1,104 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// Cursor marks the last row of a page: the sort it was issued for, the row's sort value
+// and its id as tie-breaker
+type Cursor struct {
+	Sort  string      `json:"s"`
+	Value interface{} `json:"v"`
+	ID    uint        `json:"id"`
+}
+
+// PageRequest is a parsed limit/cursor/sort query
+type PageRequest struct {
+	Limit     int
+	Cursor    *Cursor
+	SortField string
+	Desc      bool
+}
+
+// Sort is the page's order as the column and direction, e.g. "-name"
+func (p PageRequest) Sort() string {
+	if p.Desc {
+		return "-" + p.SortField
+	}
+	return p.SortField
+}
+
+// EncodeCursor returns an opaque cursor for the row with the given sort value and id
+// under sort (PageRequest.Sort)
+func EncodeCursor(sort string, value interface{}, id uint) string {
+	data, _ := json.Marshal(Cursor{Sort: sort, Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reverses EncodeCursor
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// ParsePage reads limit, cursor and sort ("field" or "-field" for descending). sortable maps
+// accepted sort names to column names; an empty sort orders by id.
+func ParsePage(limit, cursor, sort string, sortable map[string]string) (PageRequest, error) {
+	page := PageRequest{Limit: DefaultPageLimit, SortField: "id"}
+
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return page, errors.New("limit must be a positive integer")
+		}
+		page.Limit = min(n, MaxPageLimit)
+	}
+
+	if sort != "" {
+		page.Desc = strings.HasPrefix(sort, "-")
+		column, ok := sortable[strings.TrimPrefix(sort, "-")]
+		if !ok {
+			return page, errors.New("unsupported sort field: " + strings.TrimPrefix(sort, "-"))
+		}
+		page.SortField = column
+	}
+
+	if cursor != "" {
+		c, err := DecodeCursor(cursor)
+		if err != nil {
+			return page, err
+		}
+		// the cursor's value is compared with the sort column, so it must come from that sort
+		if c.Sort != page.Sort() {
+			return page, errors.New("cursor does not match sort")
+		}
+		page.Cursor = c
+	}
+	return page, nil
+}
+
+// TrimPage drops the extra row fetched to detect another page and returns the cursor for it
+func TrimPage[T any](rows []T, page PageRequest, key func(T) (interface{}, uint)) ([]T, string) {
+	if len(rows) <= page.Limit {
+		return rows, ""
+	}
+	rows = rows[:page.Limit]
+	value, id := key(rows[page.Limit-1])
+	return rows, EncodeCursor(page.Sort(), value, id)
+}
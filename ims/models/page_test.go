@@ -0,0 +1,77 @@
+package models
+
+import "testing"
+
+// TestParsePage
+func TestParsePage(t *testing.T) {
+	sortable := map[string]string{"id": "id", "quantity": "quantity", "sku_code": "code"}
+	cursor := EncodeCursor("quantity", 12, 7)
+
+	tests := []struct {
+		name     string
+		limit    string
+		cursor   string
+		sort     string
+		want     PageRequest
+		wantErr  bool
+		wantNext bool
+	}{
+		{"defaults", "", "", "", PageRequest{Limit: DefaultPageLimit, SortField: "id"}, false, false},
+		{"limit capped", "10000", "", "", PageRequest{Limit: MaxPageLimit, SortField: "id"}, false, false},
+		{"descending alias", "10", "", "-sku_code", PageRequest{Limit: 10, SortField: "code", Desc: true}, false, false},
+		{"cursor", "", cursor, "quantity", PageRequest{Limit: DefaultPageLimit, SortField: "quantity"}, false, true},
+		{"bad limit", "0", "", "", PageRequest{}, true, false},
+		{"unknown sort", "", "", "password", PageRequest{}, true, false},
+		{"bad cursor", "", "not-a-cursor", "", PageRequest{}, true, false},
+		{"cursor of another sort", "", cursor, "-quantity", PageRequest{}, true, false},
+		{"cursor without its sort", "", cursor, "", PageRequest{}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePage(tt.limit, tt.cursor, tt.sort, sortable)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Limit != tt.want.Limit || got.SortField != tt.want.SortField || got.Desc != tt.want.Desc {
+				t.Errorf("ParsePage() = %+v, want %+v", got, tt.want)
+			}
+			if (got.Cursor != nil) != tt.wantNext {
+				t.Errorf("cursor = %+v, want present %v", got.Cursor, tt.wantNext)
+			}
+		})
+	}
+}
+
+// TestCursorRoundTrip
+func TestCursorRoundTrip(t *testing.T) {
+	c, err := DecodeCursor(EncodeCursor("-code", "SKU-9", 42))
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if c.Sort != "-code" || c.Value != "SKU-9" || c.ID != 42 {
+		t.Errorf("DecodeCursor() = %+v, want -code/SKU-9/42", c)
+	}
+}
+
+// TestTrimPage
+func TestTrimPage(t *testing.T) {
+	rows := []Inventory{{ID: 1, Quantity: 5}, {ID: 2, Quantity: 6}, {ID: 3, Quantity: 7}}
+	key := func(inv Inventory) (interface{}, uint) { return inv.Quantity, inv.ID }
+
+	page, next := TrimPage(rows, PageRequest{Limit: 2, SortField: "quantity"}, key)
+	if len(page) != 2 || next == "" {
+		t.Fatalf("TrimPage() = %d rows, next %q; want 2 rows and a cursor", len(page), next)
+	}
+	c, _ := DecodeCursor(next)
+	if c.Sort != "quantity" || c.ID != 2 || c.Value != float64(6) {
+		t.Errorf("next cursor = %+v, want quantity 6 id 2", c)
+	}
+
+	page, next = TrimPage(rows, PageRequest{Limit: 3, SortField: "quantity"}, key)
+	if len(page) != 3 || next != "" {
+		t.Errorf("last page = %d rows, next %q; want 3 rows and no cursor", len(page), next)
+	}
+}
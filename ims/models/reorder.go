@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type ReorderRule struct {
+	ID              uint      `json:"id"`
+	SKU             string    `json:"sku"`
+	Location        string    `json:"location"`
+	TenantID        string    `json:"tenant_id"`
+	SellerID        string    `json:"seller_id"`
+	ReorderPoint    int       `json:"reorder_point"`
+	ReorderQuantity int       `json:"reorder_quantity"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// StockAlert is emitted when a (sku, hub) drops to or below its reorder point
+type StockAlert struct {
+	SKU             string    `json:"sku"`
+	Location        string    `json:"location"`
+	TenantID        string    `json:"tenant_id"`
+	SellerID        string    `json:"seller_id"`
+	Quantity        int       `json:"quantity"`
+	ReorderPoint    int       `json:"reorder_point"`
+	ReorderQuantity int       `json:"reorder_quantity"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Validate checks if the rule has required fields
+func (r *ReorderRule) Validate() error {
+	if strings.TrimSpace(r.SKU) == "" {
+		return errors.New("sku is required")
+	}
+	if strings.TrimSpace(r.Location) == "" {
+		return errors.New("location is required")
+	}
+	if r.ReorderPoint < 0 {
+		return errors.New("reorder_point cannot be negative")
+	}
+	if r.ReorderQuantity <= 0 {
+		return errors.New("reorder_quantity must be greater than zero")
+	}
+	return nil
+}
+
+// IsLow reports whether quantity is at or below the reorder point
+func (r *ReorderRule) IsLow(quantity int) bool {
+	return quantity <= r.ReorderPoint
+}
+
+// Crossed reports whether a change from before to after took stock down through the reorder point
+func (r *ReorderRule) Crossed(before, after int) bool {
+	return !r.IsLow(before) && r.IsLow(after)
+}
+
+// Alert builds the alert for the rule at the given quantity
+func (r *ReorderRule) Alert(quantity int) StockAlert {
+	return StockAlert{
+		SKU:             r.SKU,
+		Location:        r.Location,
+		TenantID:        r.TenantID,
+		SellerID:        r.SellerID,
+		Quantity:        quantity,
+		ReorderPoint:    r.ReorderPoint,
+		ReorderQuantity: r.ReorderQuantity,
+		CreatedAt:       time.Now(),
+	}
+}
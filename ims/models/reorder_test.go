@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+)
+
+// TestReorderRuleValidation
+func TestReorderRuleValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    ReorderRule
+		wantErr bool
+	}{
+		{"valid rule", ReorderRule{SKU: "SKU23", Location: "HU001", ReorderPoint: 5, ReorderQuantity: 20}, false},
+		{"zero reorder point", ReorderRule{SKU: "SKU23", Location: "HU001", ReorderPoint: 0, ReorderQuantity: 20}, false},
+		{"empty sku", ReorderRule{Location: "HU001", ReorderPoint: 5, ReorderQuantity: 20}, true},
+		{"empty location", ReorderRule{SKU: "SKU23", ReorderPoint: 5, ReorderQuantity: 20}, true},
+		{"negative reorder point", ReorderRule{SKU: "SKU23", Location: "HU001", ReorderPoint: -1, ReorderQuantity: 20}, true},
+		{"zero reorder quantity", ReorderRule{SKU: "SKU23", Location: "HU001", ReorderPoint: 5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReorderRule.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestReorderRuleCrossed
+func TestReorderRuleCrossed(t *testing.T) {
+	rule := ReorderRule{ReorderPoint: 5}
+	tests := []struct {
+		before, after int
+		want          bool
+	}{
+		{10, 6, false},
+		{10, 5, true},
+		{6, 0, true},
+		{5, 3, false},
+		{3, 10, false},
+	}
+
+	for _, tt := range tests {
+		if got := rule.Crossed(tt.before, tt.after); got != tt.want {
+			t.Errorf("Crossed(%d, %d) = %v, want %v", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
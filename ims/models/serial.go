@@ -0,0 +1,66 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type SerialStatus string
+
+const (
+	SerialStatusAvailable SerialStatus = "available"
+	SerialStatusAllocated SerialStatus = "allocated"
+	// SerialStatusInTransit is a serial on a dispatched transfer, not yet received
+	SerialStatusInTransit SerialStatus = "in_transit"
+)
+
+type SerialNumber struct {
+	ID           uint         `json:"id"`
+	SerialNumber string       `json:"serial_number"`
+	SKU          string       `json:"sku"`
+	Location     string       `json:"location"`
+	TenantID     string       `json:"tenant_id"`
+	SellerID     string       `json:"seller_id"`
+	Status       SerialStatus `json:"status"`
+	OrderID      string       `json:"order_id,omitempty"`
+	TransferID   *uint        `json:"transfer_id,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// SerialEvent is one entry in a serial number's history
+type SerialEvent struct {
+	ID        uint      `json:"id"`
+	SerialID  uint      `json:"serial_id"`
+	Event     string    `json:"event"`
+	Location  string    `json:"location"`
+	OrderID   string    `json:"order_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	SerialEventReceived   = "received"
+	SerialEventAssigned   = "assigned"
+	SerialEventDispatched = "dispatched"
+	SerialEventReturned   = "returned"
+)
+
+// ValidateSerials checks that serials are non-empty and unique within the batch
+func ValidateSerials(serials []string) error {
+	if len(serials) == 0 {
+		return errors.New("at least one serial number is required")
+	}
+	seen := make(map[string]bool, len(serials))
+	for _, serial := range serials {
+		serial = strings.TrimSpace(serial)
+		if serial == "" {
+			return errors.New("serial number cannot be empty")
+		}
+		if seen[serial] {
+			return errors.New("duplicate serial number: " + serial)
+		}
+		seen[serial] = true
+	}
+	return nil
+}
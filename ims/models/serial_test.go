@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+)
+
+// TestValidateSerials
+func TestValidateSerials(t *testing.T) {
+	tests := []struct {
+		name    string
+		serials []string
+		wantErr bool
+	}{
+		{"valid serials", []string{"SN-1", "SN-2"}, false},
+		{"empty batch", nil, true},
+		{"blank serial", []string{"SN-1", " "}, true},
+		{"duplicate serial", []string{"SN-1", "SN-1"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSerials(tt.serials)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSerials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,10 +1,16 @@
 package models
 
+import "time"
+
 type SKU struct {
-	ID          uint   `json:"id"`
-	Code        string `json:"sku_code"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	TenantID    string `json:"tenant_id"`
-	SellerID    string `json:"seller_id"`
+	ID          uint      `json:"id"`
+	Code        string    `json:"sku_code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	TenantID    string    `json:"tenant_id"`
+	SellerID    string    `json:"seller_id"`
+	Serialized  bool      `json:"serialized"`
+	Version     int       `json:"version" gorm:"default:1"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
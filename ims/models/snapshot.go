@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+type InventorySnapshot struct {
+	ID       uint      `json:"id"`
+	TakenAt  time.Time `json:"taken_at"`
+	SKU      string    `json:"sku"`
+	Location string    `json:"location"`
+	TenantID string    `json:"tenant_id"`
+	SellerID string    `json:"seller_id"`
+	Quantity int       `json:"quantity"`
+}
+
+// InventoryMovement is a ledger row written by the inventories trigger
+type InventoryMovement struct {
+	ID            uint      `json:"id"`
+	SKU           string    `json:"sku"`
+	Location      string    `json:"location"`
+	TenantID      string    `json:"tenant_id"`
+	Delta         int       `json:"delta"`
+	QuantityAfter int       `json:"quantity_after"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// InventoryPosition is the quantity of a (sku, location) at a point in time
+type InventoryPosition struct {
+	SKU        string     `json:"sku"`
+	Location   string     `json:"location"`
+	Quantity   int        `json:"quantity"`
+	SnapshotAt *time.Time `json:"snapshot_at,omitempty"`
+}
+
+type SnapshotDiff struct {
+	SKU      string `json:"sku"`
+	Location string `json:"location"`
+	From     int    `json:"from_quantity"`
+	To       int    `json:"to_quantity"`
+	Change   int    `json:"change"`
+}
+
+// ParseAsOf accepts an RFC3339 timestamp or a plain date; a date means the end of that day in UTC
+func ParseAsOf(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.Parse("2006-01-02", value); err == nil {
+		return d.Add(24*time.Hour - time.Nanosecond), nil
+	}
+	return time.Time{}, errors.New("invalid timestamp, use RFC3339 or YYYY-MM-DD: " + value)
+}
+
+// DiffPositions compares two sets of positions and returns the (sku, location) pairs whose quantity changed
+func DiffPositions(from, to []InventoryPosition) []SnapshotDiff {
+	type key struct{ sku, location string }
+	diffs := make(map[key]*SnapshotDiff)
+	get := func(p InventoryPosition) *SnapshotDiff {
+		k := key{p.SKU, p.Location}
+		if diffs[k] == nil {
+			diffs[k] = &SnapshotDiff{SKU: p.SKU, Location: p.Location}
+		}
+		return diffs[k]
+	}
+	for _, p := range from {
+		get(p).From += p.Quantity
+	}
+	for _, p := range to {
+		get(p).To += p.Quantity
+	}
+
+	result := []SnapshotDiff{}
+	for _, d := range diffs {
+		d.Change = d.To - d.From
+		if d.Change != 0 {
+			result = append(result, *d)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].SKU != result[j].SKU {
+			return result[i].SKU < result[j].SKU
+		}
+		return result[i].Location < result[j].Location
+	})
+	return result
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseAsOf
+func TestParseAsOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"rfc3339", "2024-05-31T18:30:00Z", time.Date(2024, 5, 31, 18, 30, 0, 0, time.UTC), false},
+		{"date is end of day", "2024-05-31", time.Date(2024, 5, 31, 23, 59, 59, 999999999, time.UTC), false},
+		{"garbage", "last month", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAsOf(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAsOf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseAsOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDiffPositions
+func TestDiffPositions(t *testing.T) {
+	from := []InventoryPosition{
+		{SKU: "S1", Location: "HU001", Quantity: 10},
+		{SKU: "S2", Location: "HU001", Quantity: 4},
+		{SKU: "S3", Location: "HU002", Quantity: 7},
+	}
+	to := []InventoryPosition{
+		{SKU: "S1", Location: "HU001", Quantity: 6},
+		{SKU: "S2", Location: "HU001", Quantity: 4},
+		{SKU: "S4", Location: "HU002", Quantity: 3},
+	}
+
+	got := DiffPositions(from, to)
+	want := []SnapshotDiff{
+		{SKU: "S1", Location: "HU001", From: 10, To: 6, Change: -4},
+		{SKU: "S3", Location: "HU002", From: 7, To: 0, Change: -7},
+		{SKU: "S4", Location: "HU002", From: 0, To: 3, Change: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("DiffPositions() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("diff %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Disposition says where returned stock goes on receipt
+type Disposition string
+
+const (
+	DispositionSellable   Disposition = "sellable"
+	DispositionQuarantine Disposition = "quarantine"
+)
+
+// StockReturn is one returned line received at a hub. Sellable returns are added back
+// to inventory; quarantined ones are only recorded here until they are inspected.
+type StockReturn struct {
+	ID          uint        `json:"id"`
+	ReturnID    string      `json:"return_id"`
+	OrderID     string      `json:"order_id,omitempty"`
+	SKU         string      `json:"sku"`
+	Location    string      `json:"location"`
+	TenantID    string      `json:"tenant_id"`
+	SellerID    string      `json:"seller_id"`
+	Quantity    int         `json:"quantity"`
+	Disposition Disposition `json:"disposition"`
+	Reason      string      `json:"reason,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// QuarantineStock is the quarantined quantity of a SKU at a hub
+type QuarantineStock struct {
+	SKU      string `json:"sku"`
+	Location string `json:"location"`
+	Quantity int    `json:"quantity"`
+}
+
+// Validate checks the return has a reference, SKU, location, positive quantity and known disposition
+func (r *StockReturn) Validate() error {
+	if strings.TrimSpace(r.ReturnID) == "" {
+		return errors.New("return_id is required")
+	}
+	if strings.TrimSpace(r.SKU) == "" {
+		return errors.New("sku is required")
+	}
+	if strings.TrimSpace(r.Location) == "" {
+		return errors.New("location is required")
+	}
+	if r.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+	if r.Disposition != DispositionSellable && r.Disposition != DispositionQuarantine {
+		return errors.New("disposition must be sellable or quarantine")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+// TestStockReturnValidation
+func TestStockReturnValidation(t *testing.T) {
+	valid := StockReturn{ReturnID: "RMA-1", SKU: "SKU-1", Location: "hub-a", Quantity: 2, Disposition: DispositionSellable}
+
+	tests := []struct {
+		name    string
+		mutate  func(r *StockReturn)
+		wantErr bool
+	}{
+		{"valid sellable", func(r *StockReturn) {}, false},
+		{"valid quarantine", func(r *StockReturn) { r.Disposition = DispositionQuarantine }, false},
+		{"missing return id", func(r *StockReturn) { r.ReturnID = " " }, true},
+		{"missing sku", func(r *StockReturn) { r.SKU = "" }, true},
+		{"missing location", func(r *StockReturn) { r.Location = "" }, true},
+		{"zero quantity", func(r *StockReturn) { r.Quantity = 0 }, true},
+		{"unknown disposition", func(r *StockReturn) { r.Disposition = "scrap" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid
+			tt.mutate(&r)
+			if err := r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type TransferStatus string
+
+const (
+	TransferStatusDraft     TransferStatus = "draft"
+	TransferStatusInTransit TransferStatus = "in_transit"
+	TransferStatusReceived  TransferStatus = "received"
+)
+
+type StockTransfer struct {
+	ID             uint                `json:"id"`
+	SourceHub      string              `json:"source_hub"`
+	DestinationHub string              `json:"destination_hub"`
+	TenantID       string              `json:"tenant_id"`
+	SellerID       string              `json:"seller_id"`
+	Status         TransferStatus      `json:"status"`
+	Lines          []StockTransferLine `json:"lines" gorm:"foreignKey:TransferID"`
+	DispatchedAt   *time.Time          `json:"dispatched_at,omitempty"`
+	ReceivedAt     *time.Time          `json:"received_at,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+type StockTransferLine struct {
+	ID         uint               `json:"id"`
+	TransferID uint               `json:"transfer_id"`
+	SKU        string             `json:"sku"`
+	ProductID  string             `json:"product_id"`
+	Quantity   int                `json:"quantity"`
+	Lots       []StockTransferLot `json:"lots,omitempty" gorm:"foreignKey:LineID"`
+}
+
+// StockTransferLot is the part of a line taken from one lot at dispatch; receipt
+// re-creates the lot at the destination hub
+type StockTransferLot struct {
+	ID             uint       `json:"id"`
+	LineID         uint       `json:"line_id"`
+	LotNumber      string     `json:"lot_number"`
+	ManufacturedAt *time.Time `json:"manufactured_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	Quantity       int        `json:"quantity"`
+}
+
+// TransferLots turns the lot allocations of a dispatched line into its transfer lots,
+// copying each lot's dates. Allocations of untracked stock are skipped.
+func TransferLots(lineID uint, allocations []LotAllocation, lots []InventoryLot) []StockTransferLot {
+	byID := make(map[uint]InventoryLot, len(lots))
+	for _, lot := range lots {
+		byID[lot.ID] = lot
+	}
+	var moved []StockTransferLot
+	for _, a := range allocations {
+		lot, ok := byID[a.LotID]
+		if a.LotID == 0 || !ok {
+			continue
+		}
+		moved = append(moved, StockTransferLot{
+			LineID:         lineID,
+			LotNumber:      lot.LotNumber,
+			ManufacturedAt: lot.ManufacturedAt,
+			ExpiresAt:      lot.ExpiresAt,
+			Quantity:       a.Quantity,
+		})
+	}
+	return moved
+}
+
+// Validate checks that the transfer moves a positive quantity of each SKU between two different hubs
+func (t *StockTransfer) Validate() error {
+	if strings.TrimSpace(t.SourceHub) == "" {
+		return errors.New("source_hub is required")
+	}
+	if strings.TrimSpace(t.DestinationHub) == "" {
+		return errors.New("destination_hub is required")
+	}
+	if t.SourceHub == t.DestinationHub {
+		return errors.New("source_hub and destination_hub must differ")
+	}
+	if len(t.Lines) == 0 {
+		return errors.New("at least one line is required")
+	}
+	seen := make(map[string]bool)
+	for _, line := range t.Lines {
+		if strings.TrimSpace(line.SKU) == "" {
+			return errors.New("line sku is required")
+		}
+		if line.Quantity <= 0 {
+			return errors.New("line quantity must be greater than zero")
+		}
+		if seen[line.SKU] {
+			return errors.New("duplicate sku in lines: " + line.SKU)
+		}
+		seen[line.SKU] = true
+	}
+	return nil
+}
+
+// CanTransitionTo reports whether the transfer may move to next; transfers only go forward
+func (t *StockTransfer) CanTransitionTo(next TransferStatus) bool {
+	switch t.Status {
+	case TransferStatusDraft:
+		return next == TransferStatusInTransit
+	case TransferStatusInTransit:
+		return next == TransferStatusReceived
+	default:
+		return false
+	}
+}
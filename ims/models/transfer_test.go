@@ -0,0 +1,110 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// TestStockTransferValidation
+func TestStockTransferValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		transfer StockTransfer
+		wantErr  bool
+	}{
+		{
+			name: "valid transfer",
+			transfer: StockTransfer{
+				SourceHub:      "HU001",
+				DestinationHub: "HU002",
+				Lines:          []StockTransferLine{{SKU: "SKU23", Quantity: 5}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "same hub",
+			transfer: StockTransfer{
+				SourceHub:      "HU001",
+				DestinationHub: "HU001",
+				Lines:          []StockTransferLine{{SKU: "SKU23", Quantity: 5}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "no lines",
+			transfer: StockTransfer{
+				SourceHub:      "HU001",
+				DestinationHub: "HU002",
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero quantity",
+			transfer: StockTransfer{
+				SourceHub:      "HU001",
+				DestinationHub: "HU002",
+				Lines:          []StockTransferLine{{SKU: "SKU23", Quantity: 0}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate sku",
+			transfer: StockTransfer{
+				SourceHub:      "HU001",
+				DestinationHub: "HU002",
+				Lines:          []StockTransferLine{{SKU: "SKU23", Quantity: 1}, {SKU: "SKU23", Quantity: 2}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.transfer.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StockTransfer.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestStockTransferTransitions
+func TestStockTransferTransitions(t *testing.T) {
+	tests := []struct {
+		from TransferStatus
+		to   TransferStatus
+		want bool
+	}{
+		{TransferStatusDraft, TransferStatusInTransit, true},
+		{TransferStatusDraft, TransferStatusReceived, false},
+		{TransferStatusInTransit, TransferStatusReceived, true},
+		{TransferStatusInTransit, TransferStatusDraft, false},
+		{TransferStatusReceived, TransferStatusInTransit, false},
+	}
+
+	for _, tt := range tests {
+		transfer := StockTransfer{Status: tt.from}
+		if got := transfer.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("CanTransitionTo(%s -> %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+// TestTransferLots
+func TestTransferLots(t *testing.T) {
+	expires := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	lots := []InventoryLot{{ID: 7, LotNumber: "L7", ExpiresAt: &expires, Quantity: 10}}
+	allocations := []LotAllocation{
+		{LotID: 7, LotNumber: "L7", Quantity: 4},
+		{Quantity: 2},
+	}
+
+	moved := TransferLots(3, allocations, lots)
+	if len(moved) != 1 {
+		t.Fatalf("TransferLots() = %d lots, want 1", len(moved))
+	}
+	got := moved[0]
+	if got.LineID != 3 || got.LotNumber != "L7" || got.Quantity != 4 || got.ExpiresAt == nil || !got.ExpiresAt.Equal(expires) {
+		t.Errorf("TransferLots() = %+v", got)
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ETag formats a row version as a strong entity tag
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ParseIfMatch reads the version from an If-Match header. A "*" header matches any
+// version and is returned as 0.
+func ParseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, errors.New("If-Match header is required")
+	}
+	if header == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, errors.New("weak entity tags cannot be used with If-Match")
+	}
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, errors.New("invalid If-Match header: " + header)
+	}
+	return version, nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import "testing"
+
+// TestParseIfMatch
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int
+		wantErr bool
+	}{
+		{"round trip", ETag(7), 7, false},
+		{"any", "*", 0, false},
+		{"missing", "", 0, true},
+		{"unquoted", "7", 0, true},
+		{"weak", `W/"7"`, 0, true},
+		{"not a number", `"abc"`, 0, true},
+		{"zero", `"0"`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIfMatch(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIfMatch(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIfMatch(%q) = %d, want %d", tt.header, got, tt.want)
+			}
+		})
+	}
+}
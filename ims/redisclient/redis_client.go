@@ -2,15 +2,26 @@ package redisclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/omniful/go_commons/config"
 	"github.com/omniful/go_commons/redis"
 )
 
+// downBackoff is how long callers skip Redis after a failed call before trying it again.
+const downBackoff = 5 * time.Second
+
 var Client *redis.Client
 
+var (
+	mu        sync.RWMutex
+	downUntil time.Time
+)
+
 func InitRedis(ctx context.Context) error {
 	host := config.GetString(ctx, "REDIS_HOST")
 	port := config.GetString(ctx, "REDIS_PORT")
@@ -37,6 +48,7 @@ func InitRedis(ctx context.Context) error {
 
 	success, err := Client.Set(ctxBg, "test_connection", "ping", 10*time.Second)
 	if err != nil {
+		MarkDown()
 		return fmt.Errorf("Redis connection test failed: %v", err)
 	}
 
@@ -45,13 +57,92 @@ func InitRedis(ctx context.Context) error {
 		fmt.Println("Redis connection test successful")
 		return nil
 	} else {
+		MarkDown()
 		return fmt.Errorf("Redis connection test failed")
 	}
 }
 
+// Available reports whether Redis should be used. It is false while the client
+// is missing or within downBackoff of a failed call.
+func Available() bool {
+	if Client == nil {
+		return false
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	return time.Now().After(downUntil)
+}
+
+// MarkDown makes Available return false for downBackoff.
+func MarkDown() {
+	mu.Lock()
+	downUntil = time.Now().Add(downBackoff)
+	mu.Unlock()
+}
+
+// IsNil reports whether err is the "key does not exist" reply rather than a failure.
+func IsNil(err error) bool {
+	return errors.Is(err, goredis.Nil)
+}
+
+// Get returns the cached value for key. ok is false on a miss or when Redis is unavailable.
+func Get(ctx context.Context, key string) (string, bool) {
+	if !Available() {
+		return "", false
+	}
+	val, err := Client.Get(ctx, key)
+	if err != nil {
+		if !IsNil(err) {
+			fmt.Println("Redis get failed, falling back:", err)
+			MarkDown()
+		}
+		return "", false
+	}
+	return val, val != ""
+}
+
+// Set stores value under key, ignoring the call when Redis is unavailable.
+func Set(ctx context.Context, key string, value interface{}, ttl time.Duration) bool {
+	if !Available() {
+		return false
+	}
+	ok, err := Client.Set(ctx, key, value, ttl)
+	if err != nil {
+		fmt.Println("Redis set failed:", err)
+		MarkDown()
+		return false
+	}
+	return ok
+}
+
+// SetNX stores value under key only if it does not exist yet.
+func SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) bool {
+	if !Available() {
+		return false
+	}
+	ok, err := Client.SetNX(ctx, key, value, ttl)
+	if err != nil {
+		fmt.Println("Redis setnx failed:", err)
+		MarkDown()
+		return false
+	}
+	return ok
+}
+
+// Del removes keys, ignoring the call when Redis is unavailable.
+func Del(ctx context.Context, keys ...string) {
+	if !Available() || len(keys) == 0 {
+		return
+	}
+	if _, err := Client.Del(ctx, keys...); err != nil {
+		fmt.Println("Redis del failed:", err)
+		MarkDown()
+	}
+}
+
 func Close() error {
 	if Client != nil {
 		return Client.Close()
 	}
 	return nil
-}
\ No newline at end of file
+}
@@ -7,25 +7,81 @@ import (
 
 func RegisterRoutes(server *http.Server) {
 	// Inventory routes
-	inv := server.Group("/inventory")
+	inv := server.Group("/inventory", controllers.RequireTenant)
 	inv.POST("/", controllers.CreateInventory)
 	inv.GET("/", controllers.GetInventories)
+	inv.GET("/:id", controllers.GetInventory)
 	inv.PUT("/:id", controllers.UpdateInventory)
+	inv.PATCH("/:id", controllers.PatchInventory)
 	inv.DELETE("/:id", controllers.DeleteInventory)
 	inv.POST("/upsert", controllers.UpsertInventory)
 	inv.POST("/reduce", controllers.ReduceInventory)
 
+	// stock transfer routes
+	inv.POST("/transfers", controllers.CreateTransfer)
+	inv.GET("/transfers", controllers.GetTransfers)
+	inv.GET("/transfers/in-transit", controllers.GetInTransit)
+	inv.GET("/transfers/:id", controllers.GetTransfer)
+	inv.POST("/transfers/:id/dispatch", controllers.DispatchTransfer)
+	inv.POST("/transfers/:id/receive", controllers.ReceiveTransfer)
+
+	// reorder rule and low-stock alert routes
+	inv.POST("/reorder-rules", controllers.UpsertReorderRule)
+	inv.GET("/reorder-rules", controllers.GetReorderRules)
+	inv.DELETE("/reorder-rules/:id", controllers.DeleteReorderRule)
+	inv.GET("/alerts", controllers.GetStockAlerts)
+
+	// lot and expiry routes
+	inv.POST("/lots", controllers.ReceiveLot)
+	inv.GET("/lots", controllers.GetLots)
+	inv.GET("/lots/expiring", controllers.GetExpiringLots)
+
+	// serial number routes
+	inv.POST("/serials", controllers.RegisterSerials)
+	inv.GET("/serials", controllers.GetSerials)
+	inv.GET("/serials/:serial", controllers.GetSerial)
+
+	// customer return routes
+	inv.POST("/returns", controllers.ReceiveReturn)
+	inv.GET("/returns", controllers.GetReturns)
+	inv.GET("/quarantine", controllers.GetQuarantine)
+
+	// bin stock and picking routes
+	inv.POST("/putaway", controllers.Putaway)
+	inv.POST("/bins/move", controllers.MoveBinStock)
+	inv.POST("/pick-lists", controllers.GeneratePickList)
+
+	// cycle count routes
+	inv.POST("/counts", controllers.CreateCountSession)
+	inv.GET("/counts", controllers.GetCountSessions)
+	inv.GET("/counts/:id", controllers.GetCountSession)
+	inv.POST("/counts/:id/submit", controllers.SubmitCounts)
+	inv.GET("/counts/:id/variances", controllers.GetCountVariances)
+	inv.POST("/counts/:id/approve", controllers.ApproveCountSession)
+
+	// snapshot routes
+	inv.POST("/snapshots", controllers.CreateSnapshot)
+	inv.GET("/snapshots", controllers.GetSnapshots)
+	inv.GET("/snapshots/diff", controllers.GetSnapshotDiff)
+
 	// sku routes
-	sku := server.Group("/sku")
+	sku := server.Group("/sku", controllers.RequireTenant)
 	sku.POST("/", controllers.CreateSKU)
 	sku.GET("/", controllers.GetSKUs)
+	sku.GET("/:id", controllers.GetSKU)
 	sku.PUT("/:id", controllers.UpdateSKU)
+	sku.PATCH("/:id", controllers.PatchSKU)
 	sku.DELETE("/:id", controllers.DeleteSKU)
 
 	// hub routes
-	hub := server.Group("/hub")
+	hub := server.Group("/hub", controllers.RequireTenant)
 	hub.POST("/", controllers.CreateHub)
 	hub.GET("/", controllers.GetHubs)
+	hub.GET("/:id", controllers.GetHub)
 	hub.PUT("/:id", controllers.UpdateHub)
+	hub.PATCH("/:id", controllers.PatchHub)
 	hub.DELETE("/:id", controllers.DeleteHub)
+	hub.POST("/:id/bins", controllers.CreateHubBin)
+	hub.GET("/:id/bins", controllers.GetHubBins)
+	hub.GET("/:id/stock", controllers.GetHubStock)
 }
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"oms/database"
+	"oms/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllocationController manages the per-tenant rules used to allocate hubs
+type AllocationController struct {
+	RuleRepo *database.AllocationRuleRepository
+}
+
+// getallocationrule
+func (h *AllocationController) GetAllocationRule(c *gin.Context) {
+	tenantID := c.Param("tenantID")
+
+	rule, err := h.RuleRepo.GetRule(c.Request.Context(), tenantID)
+	if err != nil {
+		fmt.Println("ERROR: Failed to retrieve allocation rule:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve allocation rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rule": rule})
+}
+
+// putallocationrule
+func (h *AllocationController) PutAllocationRule(c *gin.Context) {
+	tenantID := c.Param("tenantID")
+
+	var rule models.AllocationRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	rule.TenantID = tenantID
+	if err := rule.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.RuleRepo.SaveRule(c.Request.Context(), &rule); err != nil {
+		fmt.Println("ERROR: Failed to save allocation rule:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save allocation rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Allocation rule saved",
+		"rule":    rule,
+	})
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"oms/database"
+	"oms/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BackorderController manages how long each tenant's orders may wait for stock
+type BackorderController struct {
+	PolicyRepo *database.BackorderPolicyRepository
+}
+
+// getbackorderpolicy
+func (h *BackorderController) GetBackorderPolicy(c *gin.Context) {
+	tenantID := c.Param("tenantID")
+
+	policy, err := h.PolicyRepo.GetPolicy(c.Request.Context(), tenantID)
+	if err != nil {
+		fmt.Println("ERROR: Failed to retrieve backorder policy:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve backorder policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy})
+}
+
+// putbackorderpolicy
+func (h *BackorderController) PutBackorderPolicy(c *gin.Context) {
+	tenantID := c.Param("tenantID")
+
+	var policy models.BackorderPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	policy.TenantID = tenantID
+	if err := policy.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.PolicyRepo.SavePolicy(c.Request.Context(), &policy); err != nil {
+		fmt.Println("ERROR: Failed to save backorder policy:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save backorder policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Backorder policy saved",
+		"policy":  policy,
+	})
+}
@@ -2,12 +2,16 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"oms/database"
 	"oms/models"
@@ -17,10 +21,52 @@ import (
 	"github.com/omniful/go_commons/i18n"
 )
 
+// RowCreator creates one order from an intake row; utils.OrderCreator implements it
+type RowCreator interface {
+	CreateOrder(ctx context.Context, row utils.CSVRow) (*models.Order, error)
+}
+
 type OrderController struct {
 	S3Uploader   *utils.S3UploaderImpl
 	SQSPublisher *utils.SQSPublisherImpl
 	OrderRepo    *database.OrderRepository
+	OrderCreator RowCreator
+	// UploadResults keeps what became of each uploaded file's rows
+	UploadResults *database.UploadResultRepository
+	Exporter      *utils.OrderExporter
+	// ExportSyncLimit is the most orders an export streams directly; larger ones run as jobs
+	ExportSyncLimit int64
+}
+
+// maxBatchOrders caps how many orders one batch request may create
+const maxBatchOrders = 500
+
+// OrderRequest is one order in a JSON intake request. It takes the CSV columns, except
+// that quantities, amounts and coordinates may be sent as JSON numbers or as strings.
+type OrderRequest struct {
+	utils.CSVRow
+	Quantity   json.Number `json:"quantity"`
+	UnitPrice  json.Number `json:"unit_price"`
+	Discount   json.Number `json:"discount"`
+	TaxRate    json.Number `json:"tax_rate"`
+	OrderTotal json.Number `json:"order_total"`
+
+	ShippingLatitude  json.Number `json:"shipping_latitude"`
+	ShippingLongitude json.Number `json:"shipping_longitude"`
+}
+
+// Row maps the request onto the intake row at rowNumber; numbers keep their exact text
+func (r OrderRequest) Row(rowNumber int) utils.CSVRow {
+	row := r.CSVRow
+	row.RowNumber = rowNumber
+	row.Quantity = r.Quantity.String()
+	row.UnitPrice = r.UnitPrice.String()
+	row.Discount = r.Discount.String()
+	row.TaxRate = r.TaxRate.String()
+	row.OrderTotal = r.OrderTotal.String()
+	row.ShippingLatitude = r.ShippingLatitude.String()
+	row.ShippingLongitude = r.ShippingLongitude.String()
+	return row
 }
 
 // validatecsv
@@ -35,7 +81,8 @@ func (h *OrderController) validateCSVContent(fileContent []byte) error {
 
 	fmt.Println("Found headers:", header)
 
-	requiredColumns := []string{"sku", "location", "tenant_id", "seller_id"}
+	// location is optional; orders without one are allocated a hub
+	requiredColumns := []string{"sku", "tenant_id", "seller_id"}
 
 	headerMap := make(map[string]bool)
 	for _, col := range header {
@@ -69,6 +116,22 @@ func (h *OrderController) validateCSVContent(fileContent []byte) error {
 	return nil
 }
 
+// validateupload
+func (h *OrderController) validateUploadContent(ctx context.Context, fileContent []byte, format utils.UploadFormat, sheet string) error {
+	if format == utils.UploadCSV {
+		return h.validateCSVContent(fileContent)
+	}
+	result, err := utils.NewCSVParser(0).ParseUpload(ctx, fileContent, format, sheet)
+	if err != nil {
+		return err
+	}
+	if result.TotalRows == 0 {
+		return fmt.Errorf("%s file must contain at least one data row", strings.ToUpper(string(format)))
+	}
+	fmt.Println(strings.ToUpper(string(format)), "validation passed: found", result.TotalRows, "data rows with required columns")
+	return nil
+}
+
 // uploadcsv
 func (h *OrderController) UploadCSV(c *gin.Context) {
 	file, header, err := c.Request.FormFile("file")
@@ -82,12 +145,18 @@ func (h *OrderController) UploadCSV(c *gin.Context) {
 
 	fmt.Println("File received:", header.Filename, "Size:", header.Size, "bytes")
 
-	if !strings.HasSuffix(strings.ToLower(header.Filename), ".csv") {
+	format, err := utils.DetectUploadFormat(header.Filename)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": i18n.Translate(c.Request.Context(), "csv.invalid_format"),
 		})
 		return
 	}
+	// sheet picks an XLSX workbook's sheet; the first sheet is imported without it
+	sheet := strings.TrimSpace(c.PostForm("sheet"))
+	if format != utils.UploadXLSX {
+		sheet = ""
+	}
 
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
@@ -100,7 +169,7 @@ func (h *OrderController) UploadCSV(c *gin.Context) {
 
 	fmt.Println("File content read:", len(fileContent), "bytes")
 
-	if err := h.validateCSVContent(fileContent); err != nil {
+	if err := h.validateUploadContent(c.Request.Context(), fileContent, format, sheet); err != nil {
 		fmt.Println("ERROR: CSV validation failed:", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": i18n.Translate(c.Request.Context(), "csv.validation_failed") + ": " + err.Error(),
@@ -108,7 +177,7 @@ func (h *OrderController) UploadCSV(c *gin.Context) {
 		return
 	}
 
-	fmt.Println("CSV validation completed successfully for file:", header.Filename)
+	fmt.Println("Validation completed successfully for file:", header.Filename)
 
 	s3Path, err := h.S3Uploader.UploadFile(c.Request.Context(), fileContent, header.Filename)
 	if err != nil {
@@ -120,7 +189,7 @@ func (h *OrderController) UploadCSV(c *gin.Context) {
 	}
 
 	fmt.Println("Publishing S3 path to SQS:", s3Path)
-	err = h.SQSPublisher.PublishS3Path(c.Request.Context(), s3Path)
+	requestID, err := h.SQSPublisher.PublishUpload(c.Request.Context(), s3Path, sheet)
 	if err != nil {
 		fmt.Println("ERROR: Failed to publish S3 path to SQS:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -129,42 +198,183 @@ func (h *OrderController) UploadCSV(c *gin.Context) {
 		return
 	}
 
-	fmt.Println("Successfully queued CSV for processing:", s3Path)
+	fmt.Println("Successfully queued upload for processing:", s3Path)
+	if h.UploadResults != nil {
+		_ = h.UploadResults.QueueUploadResult(c.Request.Context(), models.NewUploadResult(requestID, s3Path, models.UploadQueued))
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "CSV file uploaded and queued for processing successfully",
+		"message":    "File uploaded and queued for processing successfully",
+		"request_id": requestID,
+		"status_url": "/api/v1/orders/uploads/" + requestID,
 		"s3_path":    s3Path,
 		"filename":   header.Filename,
+		"format":     format,
+		"sheet":      sheet,
 		"size":       len(fileContent),
 		"queued":     true,
 		"queue_name": h.SQSPublisher.GetQueueName(),
 	})
 }
 
-// Listorders
-func (h *OrderController) ListOrders(c *gin.Context) {
-	filters := make(map[string]string)
+// GetUploadResult reports the orders created, the duplicates and the rejected rows of
+// an upload, by the request_id the upload answered with
+func (h *OrderController) GetUploadResult(c *gin.Context) {
+	result, err := h.UploadResults.GetUploadResult(c.Request.Context(), c.Param("requestID"))
+	if err != nil {
+		if errors.Is(err, database.ErrUploadNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		fmt.Println("ERROR: Failed to retrieve upload result:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve upload result"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"upload": result})
+}
 
-	if tenantID := c.Query("tenant_id"); tenantID != "" {
-		filters["tenant_id"] = tenantID
+// createorder
+func (h *OrderController) CreateOrder(c *gin.Context) {
+	var request OrderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
 	}
-	if sellerID := c.Query("seller_id"); sellerID != "" {
-		filters["seller_id"] = sellerID
+
+	order, err := h.OrderCreator.CreateOrder(c.Request.Context(), request.Row(1))
+	if err != nil {
+		fmt.Println("ERROR: Failed to create order:", err)
+		if errors.Is(err, database.ErrDuplicateOrder) {
+			response := gin.H{"error": err.Error(), "duplicate": true}
+			if order != nil {
+				response["order_id"] = order.ID
+			}
+			c.JSON(http.StatusConflict, response)
+			return
+		}
+		if errors.Is(err, utils.ErrOrderRejected) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.Translate(c.Request.Context(), "general.internal_error"),
+		})
+		return
 	}
-	if status := c.Query("status"); status != "" {
-		filters["status"] = status
+
+	fmt.Println("Order created via API - OrderID:", order.ID)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Order created successfully",
+		"order_id": order.ID,
+		"order":    order,
+	})
+}
+
+// createordersbatch
+func (h *OrderController) CreateOrdersBatch(c *gin.Context) {
+	var request struct {
+		Orders []OrderRequest `json:"orders" binding:"required"`
 	}
-	if startDate := c.Query("start_date"); startDate != "" {
-		filters["start_date"] = startDate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
 	}
-	if endDate := c.Query("end_date"); endDate != "" {
-		filters["end_date"] = endDate
+	if len(request.Orders) == 0 || len(request.Orders) > maxBatchOrders {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("a batch must contain between 1 and %d orders", maxBatchOrders),
+		})
+		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	type failure struct {
+		Index int    `json:"index"`
+		Error string `json:"error"`
+	}
+	type duplicate struct {
+		Index           int    `json:"index"`
+		ExternalOrderID string `json:"external_order_id"`
+		OrderID         string `json:"order_id,omitempty"`
+	}
+	created := make([]string, 0, len(request.Orders))
+	failed := make([]failure, 0)
+	duplicates := make([]duplicate, 0)
+
+	for i, orderRequest := range request.Orders {
+		row := orderRequest.Row(i + 1)
+		order, err := h.OrderCreator.CreateOrder(c.Request.Context(), row)
+		if err != nil {
+			fmt.Printf("Batch order %d failed: %v\n", i, err)
+			if errors.Is(err, database.ErrDuplicateOrder) {
+				d := duplicate{Index: i, ExternalOrderID: row.ExternalOrderID}
+				if order != nil {
+					d.OrderID = order.ID
+				}
+				duplicates = append(duplicates, d)
+				continue
+			}
+			failed = append(failed, failure{Index: i, Error: err.Error()})
+			continue
+		}
+		created = append(created, order.ID)
+	}
 
-	offset := (page - 1) * limit
+	status := http.StatusCreated
+	if len(failed) > 0 || len(duplicates) > 0 {
+		status = http.StatusMultiStatus
+	}
+	if len(created) == 0 && len(duplicates) == 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	fmt.Println("Batch order creation finished - Created:", len(created), "Duplicates:", len(duplicates), "Failed:", len(failed))
+	c.JSON(status, gin.H{
+		"message":    fmt.Sprintf("%d of %d orders created", len(created), len(request.Orders)),
+		"order_ids":  created,
+		"duplicates": duplicates,
+		"failed":     failed,
+	})
+}
+
+// orderFilterParams are the ListOrders query parameters passed through to the repository
+var orderFilterParams = []string{
+	"tenant_id", "seller_id", "status", "sku", "location", "order_id_prefix", "external_order_id", "priority",
+	"start_date", "end_date", "updated_start_date", "updated_end_date",
+}
+
+// orderDateParams must parse as YYYY-MM-DD or RFC3339; end dates are inclusive
+var orderDateParams = map[string]bool{
+	"start_date": false, "end_date": true, "updated_start_date": false, "updated_end_date": true,
+}
+
+// orderFilters reads the ListOrders filter parameters. It answers 400 and returns false
+// when a date filter does not parse.
+func orderFilters(c *gin.Context) (map[string]string, bool) {
+	filters := make(map[string]string)
+	for _, param := range orderFilterParams {
+		if value := strings.TrimSpace(c.Query(param)); value != "" {
+			filters[param] = value
+		}
+	}
+
+	for param, upper := range orderDateParams {
+		value, ok := filters[param]
+		if !ok {
+			continue
+		}
+		if _, err := models.ParseDateBound(value, upper); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": i18n.Translate(c.Request.Context(), "date.invalid_format") + ": " + param + "=" + value,
+			})
+			return nil, false
+		}
+	}
+	return filters, true
+}
+
+// pageParams reads page and limit, falling back to page 1 of 10
+func pageParams(c *gin.Context) (page, limit, offset int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", "10"))
 
 	if page < 1 {
 		page = 1
@@ -172,6 +382,16 @@ func (h *OrderController) ListOrders(c *gin.Context) {
 	if limit < 1 || limit > 100 {
 		limit = 10
 	}
+	return page, limit, (page - 1) * limit
+}
+
+// Listorders
+func (h *OrderController) ListOrders(c *gin.Context) {
+	filters, ok := orderFilters(c)
+	if !ok {
+		return
+	}
+	page, limit, offset := pageParams(c)
 
 	fmt.Println("Retrieving orders with filters:", filters, "Page:", page, "Limit:", limit)
 
@@ -184,20 +404,111 @@ func (h *OrderController) ListOrders(c *gin.Context) {
 		return
 	}
 
+	total, err := h.OrderRepo.CountOrdersByFilter(c.Request.Context(), filters)
+	if err != nil {
+		fmt.Println("ERROR: Failed to count orders in database:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve orders",
+		})
+		return
+	}
+
 	response := gin.H{
 		"orders": orders,
 		"pagination": gin.H{
-			"page":  page,
-			"limit": limit,
-			"total": len(orders),
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
 		},
 		"filters": filters,
 	}
 
-	fmt.Println("Successfully retrieved", len(orders), "orders")
+	fmt.Println("Successfully retrieved", len(orders), "of", total, "orders")
 	c.JSON(http.StatusOK, response)
 }
 
+// slaorders
+func (h *OrderController) GetSLAOrders(c *gin.Context) {
+	state := c.Query("state")
+	if state != "" && state != models.SLAAtRisk && state != models.SLABreached {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state must be at_risk or breached"})
+		return
+	}
+	filters, ok := orderFilters(c)
+	if !ok {
+		return
+	}
+	page, limit, offset := pageParams(c)
+	now := time.Now()
+
+	orders, total, err := h.OrderRepo.GetOrdersBySLA(c.Request.Context(), filters, state, now, limit, offset)
+	if err != nil {
+		fmt.Println("ERROR: Failed to retrieve SLA orders:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.Translate(c.Request.Context(), "database.query_failed"),
+		})
+		return
+	}
+
+	hits := make([]gin.H, 0, len(orders))
+	for i := range orders {
+		hits = append(hits, gin.H{"order": orders[i], "sla_state": orders[i].SLAState(now)})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"orders": hits,
+		"as_of":  now.UTC().Format(time.RFC3339),
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+		"filters": filters,
+	})
+}
+
+// searchorders
+func (h *OrderController) SearchOrders(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": i18n.Translate(c.Request.Context(), "search.query_required"),
+		})
+		return
+	}
+	filters, ok := orderFilters(c)
+	if !ok {
+		return
+	}
+	page, limit, offset := pageParams(c)
+
+	fmt.Println("Searching orders - Filters:", filters, "Page:", page, "Limit:", limit)
+
+	result, err := h.OrderRepo.SearchOrders(c.Request.Context(), query, filters, limit, offset)
+	if err != nil {
+		fmt.Println("ERROR: Failed to search orders:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": i18n.Translate(c.Request.Context(), "database.query_failed"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":  query,
+		"mode":   result.Mode,
+		"hits":   result.Hits,
+		"facets": result.Facets,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       result.Total,
+			"total_pages": (result.Total + int64(limit) - 1) / int64(limit),
+		},
+		"filters": filters,
+	})
+}
+
 // getorderbyid
 func (h *OrderController) GetOrderByID(c *gin.Context) {
 	orderID := c.Param("orderID")
@@ -233,6 +544,37 @@ func (h *OrderController) GetOrderByID(c *gin.Context) {
 	})
 }
 
+// getorderbyexternalid
+func (h *OrderController) GetOrderByExternalID(c *gin.Context) {
+	ref := c.Param("ref")
+	tenantID := c.Query("tenant_id")
+	if ref == "" || tenantID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "external reference and tenant_id are required",
+		})
+		return
+	}
+
+	order, err := h.OrderRepo.GetOrderByExternalID(c.Request.Context(), tenantID, ref)
+	if err != nil {
+		fmt.Println("ERROR: Failed to retrieve order by external ID:", err)
+		if strings.Contains(err.Error(), "order not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("Order not found with external ID: %s", ref),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve order",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order": order,
+	})
+}
+
 // updateorder
 func (h *OrderController) UpdateOrderStatus(c *gin.Context) {
 	orderID := c.Param("orderID")
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"bytes"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -119,3 +121,96 @@ func TestGetOrders(t *testing.T) {
 		t.Errorf("Test order not found in response. Response: %s", w.Body.String())
 	}
 }
+
+// fakeRowCreator records the rows it is given and rejects those for rejectedSKU
+type fakeRowCreator struct {
+	rows        []utils.CSVRow
+	rejectedSKU string
+}
+
+func (f *fakeRowCreator) CreateOrder(_ context.Context, row utils.CSVRow) (*models.Order, error) {
+	f.rows = append(f.rows, row)
+	if row.SKU == f.rejectedSKU {
+		return nil, fmt.Errorf("%w: unknown sku %s", utils.ErrOrderRejected, row.SKU)
+	}
+	return models.NewOrder(row.SKU, row.Location, row.TenantID, row.SellerID), nil
+}
+
+func postOrders(t *testing.T, creator *fakeRowCreator, path, body string) (int, map[string]interface{}) {
+	gin.SetMode(gin.TestMode)
+	controller := &OrderController{OrderCreator: creator}
+	r := gin.New()
+	r.POST("/orders", controller.CreateOrder)
+	r.POST("/orders/batch", controller.CreateOrdersBatch)
+
+	req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+func TestCreateOrderTakesJSONNumbers(t *testing.T) {
+	creator := &fakeRowCreator{}
+	code, resp := postOrders(t, creator, "/orders",
+		`{"sku":"SKU-1","location":"hub-a","tenant_id":"t1","seller_id":"s1","quantity":2,"unit_price":10.50,"tax_rate":"18","shipping_latitude":12.97}`)
+
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %v", code, resp)
+	}
+	row := creator.rows[0]
+	if row.Quantity != "2" || row.UnitPrice != "10.50" || row.TaxRate != "18" || row.ShippingLatitude != "12.97" || row.RowNumber != 1 {
+		t.Errorf("Row = %+v, want quantity 2, unit_price 10.50, tax_rate 18, latitude 12.97 on row 1", row)
+	}
+}
+
+func TestCreateOrderRejected(t *testing.T) {
+	creator := &fakeRowCreator{rejectedSKU: "SKU-X"}
+	code, resp := postOrders(t, creator, "/orders", `{"sku":"SKU-X","tenant_id":"t1","seller_id":"s1","quantity":1}`)
+	if code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d: %v", code, resp)
+	}
+
+	code, _ = postOrders(t, creator, "/orders", `{"sku":"SKU-1","quantity":"two"}`)
+	if code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a non-numeric quantity, got %d", code)
+	}
+}
+
+func TestCreateOrdersBatchPartial(t *testing.T) {
+	creator := &fakeRowCreator{rejectedSKU: "SKU-X"}
+	code, resp := postOrders(t, creator, "/orders/batch", `{"orders":[
+		{"sku":"SKU-1","tenant_id":"t1","seller_id":"s1","quantity":1},
+		{"sku":"SKU-X","tenant_id":"t1","seller_id":"s1","quantity":3}]}`)
+
+	if code != http.StatusMultiStatus {
+		t.Fatalf("Expected status 207, got %d: %v", code, resp)
+	}
+	if ids := resp["order_ids"].([]interface{}); len(ids) != 1 {
+		t.Errorf("order_ids = %v, want one created order", ids)
+	}
+	failed := resp["failed"].([]interface{})
+	if len(failed) != 1 || failed[0].(map[string]interface{})["index"] != float64(1) {
+		t.Errorf("failed = %v, want the order at index 1", failed)
+	}
+	if creator.rows[1].Quantity != "3" || creator.rows[1].RowNumber != 2 {
+		t.Errorf("Second row = %+v, want quantity 3 on row 2", creator.rows[1])
+	}
+}
+
+func TestCreateOrdersBatchAllRejected(t *testing.T) {
+	creator := &fakeRowCreator{rejectedSKU: "SKU-X"}
+	code, resp := postOrders(t, creator, "/orders/batch", `{"orders":[{"sku":"SKU-X","quantity":1}]}`)
+
+	if code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d: %v", code, resp)
+	}
+	if len(resp["failed"].([]interface{})) != 1 {
+		t.Errorf("failed = %v, want the rejected order", resp["failed"])
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"oms/database"
+	"oms/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportorders
+func (h *OrderController) ExportOrders(c *gin.Context) {
+	filters, ok := orderFilters(c)
+	if !ok {
+		return
+	}
+	format, err := models.ParseExportFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total, err := h.OrderRepo.CountOrdersByFilter(c.Request.Context(), filters)
+	if err != nil {
+		fmt.Println("ERROR: Failed to count orders for export:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export orders"})
+		return
+	}
+
+	// large exports are written to S3 in the background instead of held open
+	if c.Query("async") == "true" || total > h.ExportSyncLimit {
+		job, err := h.Exporter.StartJob(c.Request.Context(), format, filters, total)
+		if err != nil {
+			fmt.Println("ERROR: Failed to start export job:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":    "Export started",
+			"job":        job,
+			"status_url": "/api/v1/orders/exports/" + job.ID,
+		})
+		return
+	}
+
+	fmt.Println("Streaming", total, "orders as", format, "with filters:", filters)
+	filename := fmt.Sprintf("orders-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	rows, err := h.Exporter.Stream(c.Request.Context(), c.Writer, format, filters)
+	if err != nil {
+		// the response has started, so the client only sees a truncated file
+		fmt.Printf("ERROR: Export stopped after %d orders: %v\n", rows, err)
+		return
+	}
+	fmt.Println("Exported", rows, "orders")
+}
+
+// getexportjob
+func (h *OrderController) GetExportJob(c *gin.Context) {
+	job, link, err := h.Exporter.GetJob(c.Request.Context(), c.Param("jobID"))
+	if err != nil {
+		if errors.Is(err, database.ErrExportJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		fmt.Println("ERROR: Failed to retrieve export job:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve export job"})
+		return
+	}
+
+	response := gin.H{"job": job}
+	if link != "" {
+		response["download_url"] = link
+	}
+	c.JSON(http.StatusOK, response)
+}
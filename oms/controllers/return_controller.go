@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"oms/database"
+	"oms/models"
+	"oms/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReturnController exposes the returns (RMA) workflow
+type ReturnController struct {
+	ReturnRepo    *database.ReturnRepository
+	ReturnManager *utils.ReturnManager
+}
+
+// returnError answers with the status matching a return workflow error
+func returnError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, database.ErrReturnNotFound), strings.Contains(err.Error(), "order not found"):
+		status = http.StatusNotFound
+	case errors.Is(err, models.ErrOrderNotReturnable), errors.Is(err, models.ErrReturnTransition), errors.Is(err, database.ErrReturnChanged):
+		status = http.StatusConflict
+	case errors.Is(err, utils.ErrInvalidReturn):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, utils.ErrRestockFailed):
+		status = http.StatusBadGateway
+	}
+	if status == http.StatusInternalServerError {
+		fmt.Println("ERROR: Return request failed:", err)
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// requestreturn
+func (h *ReturnController) RequestReturn(c *gin.Context) {
+	var request struct {
+		Lines []models.ReturnLine `json:"lines" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	ret, err := h.ReturnManager.Request(c.Request.Context(), c.Param("orderID"), request.Lines)
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Return requested",
+		"return":  ret,
+	})
+}
+
+// orderreturns
+func (h *ReturnController) GetOrderReturns(c *gin.Context) {
+	returns, err := h.ReturnRepo.GetReturnsByOrder(c.Request.Context(), c.Param("orderID"))
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"returns": returns})
+}
+
+// getreturn
+func (h *ReturnController) GetReturn(c *gin.Context) {
+	ret, err := h.ReturnRepo.GetReturnByID(c.Request.Context(), c.Param("returnID"))
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"return": ret})
+}
+
+// approvereturn
+func (h *ReturnController) ApproveReturn(c *gin.Context) {
+	ret, err := h.ReturnManager.Approve(c.Request.Context(), c.Param("returnID"))
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Return approved", "return": ret})
+}
+
+// rejectreturn
+func (h *ReturnController) RejectReturn(c *gin.Context) {
+	var request struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	ret, err := h.ReturnManager.Reject(c.Request.Context(), c.Param("returnID"), request.Reason)
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Return rejected", "return": ret})
+}
+
+// receivereturn
+func (h *ReturnController) ReceiveReturn(c *gin.Context) {
+	// disposition applies to every line not listed in lines; it defaults to sellable
+	var request struct {
+		Location    string             `json:"location"`
+		Disposition models.Disposition `json:"disposition"`
+		Lines       []struct {
+			SKU         string             `json:"sku"`
+			Disposition models.Disposition `json:"disposition"`
+		} `json:"lines"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if request.Disposition == "" {
+		request.Disposition = models.DispositionSellable
+	}
+	bySKU := make(map[string]models.Disposition)
+	for _, line := range request.Lines {
+		bySKU[line.SKU] = line.Disposition
+	}
+
+	ret, err := h.ReturnManager.Receive(c.Request.Context(), c.Param("returnID"), request.Location, request.Disposition, bySKU)
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Return received", "return": ret})
+}
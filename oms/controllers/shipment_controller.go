@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"oms/database"
+	"oms/models"
+	"oms/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShipmentController books orders with carriers and exposes their tracking
+type ShipmentController struct {
+	ShipmentRepo    *database.ShipmentRepository
+	ShipmentManager *utils.ShipmentManager
+	Carriers        *utils.CarrierRegistry
+}
+
+// shipmentError answers with the status matching a shipment error
+func shipmentError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, database.ErrShipmentNotFound), strings.Contains(err.Error(), "order not found"):
+		status = http.StatusNotFound
+	case errors.Is(err, utils.ErrUnknownCarrier):
+		status = http.StatusBadRequest
+	case errors.Is(err, models.ErrOrderNotShippable), errors.Is(err, utils.ErrAlreadyShipped):
+		status = http.StatusConflict
+	case errors.Is(err, utils.ErrCarrierFailed):
+		status = http.StatusBadGateway
+	}
+	if status == http.StatusInternalServerError {
+		fmt.Println("ERROR: Shipment request failed:", err)
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// carriers
+func (h *ShipmentController) ListCarriers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"carriers": h.Carriers.Names()})
+}
+
+// shippingrates
+func (h *ShipmentController) GetShippingRates(c *gin.Context) {
+	quotes, err := h.ShipmentManager.Quote(c.Request.Context(), c.Param("orderID"), c.Query("carrier"))
+	if err != nil {
+		shipmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rates": quotes})
+}
+
+// createshipment
+func (h *ShipmentController) CreateShipment(c *gin.Context) {
+	var request struct {
+		Carrier string `json:"carrier" binding:"required"`
+		Service string `json:"service" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	shipment, err := h.ShipmentManager.Ship(c.Request.Context(), c.Param("orderID"), request.Carrier, request.Service)
+	if err != nil {
+		shipmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Shipment created",
+		"shipment": shipment,
+	})
+}
+
+// ordershipments
+func (h *ShipmentController) GetOrderShipments(c *gin.Context) {
+	shipments, err := h.ShipmentRepo.GetShipmentsByOrder(c.Request.Context(), c.Param("orderID"))
+	if err != nil {
+		shipmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shipments": shipments})
+}
+
+// getshipment
+func (h *ShipmentController) GetShipment(c *gin.Context) {
+	shipment, err := h.ShipmentRepo.GetShipmentByID(c.Request.Context(), c.Param("shipmentID"))
+	if err != nil {
+		shipmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shipment": shipment})
+}
+
+// refreshshipment
+func (h *ShipmentController) RefreshShipment(c *gin.Context) {
+	shipment, err := h.ShipmentManager.Refresh(c.Request.Context(), c.Param("shipmentID"))
+	if err != nil {
+		shipmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shipment": shipment})
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"oms/database"
+	"oms/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SLAController manages the per-tenant dispatch cut-offs behind SLA deadlines
+type SLAController struct {
+	PolicyRepo *database.SLAPolicyRepository
+}
+
+// getslapolicy
+func (h *SLAController) GetSLAPolicy(c *gin.Context) {
+	tenantID := c.Param("tenantID")
+
+	policy, err := h.PolicyRepo.GetPolicy(c.Request.Context(), tenantID)
+	if err != nil {
+		fmt.Println("ERROR: Failed to retrieve SLA policy:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve SLA policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy})
+}
+
+// putslapolicy
+func (h *SLAController) PutSLAPolicy(c *gin.Context) {
+	tenantID := c.Param("tenantID")
+
+	// fields left out of the body keep their defaults
+	policy := models.DefaultSLAPolicy(tenantID)
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	policy.TenantID = tenantID
+	if err := policy.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.PolicyRepo.SavePolicy(c.Request.Context(), &policy); err != nil {
+		fmt.Println("ERROR: Failed to save SLA policy:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save SLA policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "SLA policy saved",
+		"policy":  policy,
+	})
+}
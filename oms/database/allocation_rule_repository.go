@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"oms/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AllocationRuleRepository stores one allocation rule per tenant
+type AllocationRuleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAllocationRuleRepository(db *Database) *AllocationRuleRepository {
+	return &AllocationRuleRepository{collection: db.GetCollection("allocation_rules")}
+}
+
+// GetRule returns the tenant's rule, or the default rule when none is configured
+func (r *AllocationRuleRepository) GetRule(ctx context.Context, tenantID string) (models.AllocationRule, error) {
+	var rule models.AllocationRule
+	err := r.collection.FindOne(ctx, bson.M{"tenant_id": tenantID}).Decode(&rule)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.DefaultAllocationRule(tenantID), nil
+		}
+		fmt.Printf("ERROR: Failed to query allocation rule - Tenant: %s: %v\n", tenantID, err)
+		return rule, fmt.Errorf("failed to query allocation rule: %w", err)
+	}
+	return rule, nil
+}
+
+// SaveRule replaces the tenant's rule
+func (r *AllocationRuleRepository) SaveRule(ctx context.Context, rule *models.AllocationRule) error {
+	rule.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"tenant_id": rule.TenantID}, rule, options.Replace().SetUpsert(true))
+	if err != nil {
+		fmt.Printf("ERROR: Failed to save allocation rule - Tenant: %s: %v\n", rule.TenantID, err)
+		return fmt.Errorf("failed to save allocation rule: %w", err)
+	}
+	fmt.Printf("Allocation rule saved - Tenant: %s, Strategy: %s\n", rule.TenantID, rule.Strategy)
+	return nil
+}
+
+// EnsureIndexes makes tenant_id unique so each tenant has at most one rule
+func (r *AllocationRuleRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+		Options: options.Index().SetName("tenant_id_unique").SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create allocation rule indexes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"oms/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BackorderPolicyRepository stores one backorder policy per tenant
+type BackorderPolicyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewBackorderPolicyRepository(db *Database) *BackorderPolicyRepository {
+	return &BackorderPolicyRepository{collection: db.GetCollection("backorder_policies")}
+}
+
+// GetPolicy returns the tenant's policy, or the default policy when none is configured
+func (r *BackorderPolicyRepository) GetPolicy(ctx context.Context, tenantID string) (models.BackorderPolicy, error) {
+	var policy models.BackorderPolicy
+	err := r.collection.FindOne(ctx, bson.M{"tenant_id": tenantID}).Decode(&policy)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.DefaultBackorderPolicy(tenantID), nil
+		}
+		fmt.Printf("ERROR: Failed to query backorder policy - Tenant: %s: %v\n", tenantID, err)
+		return policy, fmt.Errorf("failed to query backorder policy: %w", err)
+	}
+	return policy, nil
+}
+
+// SavePolicy replaces the tenant's policy
+func (r *BackorderPolicyRepository) SavePolicy(ctx context.Context, policy *models.BackorderPolicy) error {
+	policy.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"tenant_id": policy.TenantID}, policy, options.Replace().SetUpsert(true))
+	if err != nil {
+		fmt.Printf("ERROR: Failed to save backorder policy - Tenant: %s: %v\n", policy.TenantID, err)
+		return fmt.Errorf("failed to save backorder policy: %w", err)
+	}
+	fmt.Printf("Backorder policy saved - Tenant: %s, Backorder: %t\n", policy.TenantID, policy.Backorder)
+	return nil
+}
+
+// EnsureIndexes makes tenant_id unique so each tenant has at most one policy
+func (r *BackorderPolicyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+		Options: options.Index().SetName("tenant_id_unique").SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create backorder policy indexes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"oms/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrExportJobNotFound is returned when no export job has the given ID
+var ErrExportJobNotFound = errors.New("export job not found")
+
+// ExportJobRepository stores asynchronous order export jobs
+type ExportJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewExportJobRepository(db *Database) *ExportJobRepository {
+	return &ExportJobRepository{collection: db.GetCollection("export_jobs")}
+}
+
+// SaveJob inserts a new export job
+func (r *ExportJobRepository) SaveJob(ctx context.Context, job *models.ExportJob) error {
+	if _, err := r.collection.InsertOne(ctx, job); err != nil {
+		fmt.Printf("ERROR: Failed to save export job - JobID: %s: %v\n", job.ID, err)
+		return fmt.Errorf("failed to save export job: %w", err)
+	}
+	fmt.Printf("Export job saved - JobID: %s, Format: %s, Total: %d\n", job.ID, job.Format, job.Total)
+	return nil
+}
+
+// GetJob finds an export job by its ID
+func (r *ExportJobRepository) GetJob(ctx context.Context, jobID string) (*models.ExportJob, error) {
+	job := &models.ExportJob{}
+	err := r.collection.FindOne(ctx, bson.M{"job_id": jobID}).Decode(job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrExportJobNotFound, jobID)
+		}
+		fmt.Printf("ERROR: Failed to query export job - JobID: %s: %v\n", jobID, err)
+		return nil, fmt.Errorf("failed to query export job: %w", err)
+	}
+	return job, nil
+}
+
+// UpdateJob replaces the stored job with job
+func (r *ExportJobRepository) UpdateJob(ctx context.Context, job *models.ExportJob) error {
+	if _, err := r.collection.ReplaceOne(ctx, bson.M{"job_id": job.ID}, job); err != nil {
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+	return nil
+}
+
+// EnsureIndexes makes job_id unique
+func (r *ExportJobRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "job_id", Value: 1}},
+		Options: options.Index().SetName("job_id_unique").SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create export job indexes: %w", err)
+	}
+	return nil
+}
@@ -2,13 +2,15 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"oms/models"
 
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -27,12 +29,42 @@ func (r *OrderRepository) SaveOrder(ctx context.Context, order *models.Order) er
 		"tenant_id":  order.TenantID,
 		"seller_id":  order.SellerID,
 		"status":     order.Status,
+		"priority":   order.Priority,
 		"created_at": order.CreatedAt,
 		"updated_at": order.UpdatedAt,
 	}
+	if order.SLADeadline != nil {
+		doc["sla_deadline"] = order.SLADeadline
+		doc["sla_at_risk_at"] = order.SLAAtRiskAt
+	}
+	if order.ExternalOrderID != "" {
+		doc["external_order_id"] = order.ExternalOrderID
+	}
+	if order.Customer != nil {
+		doc["customer"] = order.Customer
+	}
+	if order.ShippingAddress != nil {
+		doc["shipping_address"] = order.ShippingAddress
+	}
+	if order.BillingAddress != nil {
+		doc["billing_address"] = order.BillingAddress
+	}
+	doc["quantity"] = order.OrderedQuantity()
+	if order.Totals != nil {
+		doc["currency"] = order.Currency
+		doc["lines"] = order.Lines
+		doc["totals"] = order.Totals
+	}
+	if order.Allocation != nil {
+		doc["allocation"] = order.Allocation
+	}
 
 	result, err := r.collection.InsertOne(ctx, doc)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) && order.ExternalOrderID != "" {
+			fmt.Printf("Duplicate order skipped - Tenant: %s, ExternalOrderID: %s\n", order.TenantID, order.ExternalOrderID)
+			return fmt.Errorf("%w: %s", ErrDuplicateOrder, order.ExternalOrderID)
+		}
 		fmt.Println("ERROR: Failed to save order to MongoDB:", err)
 		return err
 	}
@@ -65,6 +97,50 @@ func (r *OrderRepository) GetOrders(ctx context.Context, limit, offset int) ([]m
 	return orders, nil
 }
 
+// orderDateRanges maps date filter keys to the field and bound they constrain
+var orderDateRanges = []struct {
+	key   string
+	field string
+	op    string
+}{
+	{"start_date", "created_at", "$gte"},
+	{"end_date", "created_at", "$lte"},
+	{"updated_start_date", "updated_at", "$gte"},
+	{"updated_end_date", "updated_at", "$lte"},
+}
+
+// buildOrderFilter turns ListOrders filters into a Mongo query
+func buildOrderFilter(filters map[string]string) (bson.M, error) {
+	filter := bson.M{}
+
+	for _, field := range []string{"tenant_id", "seller_id", "status", "sku", "location", "external_order_id", "priority"} {
+		if value := filters[field]; value != "" {
+			filter[field] = value
+		}
+	}
+	if prefix := filters["order_id_prefix"]; prefix != "" {
+		filter["order_id"] = bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}
+	}
+
+	for _, r := range orderDateRanges {
+		value := filters[r.key]
+		if value == "" {
+			continue
+		}
+		bound, err := models.ParseDateBound(value, r.op == "$lte")
+		if err != nil {
+			return nil, err
+		}
+		existing, ok := filter[r.field].(bson.M)
+		if !ok {
+			existing = bson.M{}
+			filter[r.field] = existing
+		}
+		existing[r.op] = bound
+	}
+	return filter, nil
+}
+
 // GetOrdersByFilter
 func (r *OrderRepository) GetOrdersByFilter(ctx context.Context, filters map[string]string, limit, offset int) ([]models.Order, error) {
 
@@ -74,16 +150,9 @@ func (r *OrderRepository) GetOrdersByFilter(ctx context.Context, filters map[str
 	if offset < 0 {
 		offset = 0
 	}
-	filter := bson.M{}
-
-	if tenantID, exists := filters["tenant_id"]; exists && tenantID != "" {
-		filter["tenant_id"] = tenantID
-	}
-	if sellerID, exists := filters["seller_id"]; exists && sellerID != "" {
-		filter["seller_id"] = sellerID
-	}
-	if status, exists := filters["status"]; exists && status != "" {
-		filter["status"] = status
+	filter, err := buildOrderFilter(filters)
+	if err != nil {
+		return nil, err
 	}
 
 	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(offset)).SetSort(bson.D{{Key: "created_at", Value: -1}})
@@ -103,12 +172,54 @@ func (r *OrderRepository) GetOrdersByFilter(ctx context.Context, filters map[str
 	return orders, nil
 }
 
+// StreamOrdersByFilter calls fn with every order matching filters, newest first, reading
+// them from a cursor one at a time so large results are never held in memory.
+// It stops at the first error fn returns.
+func (r *OrderRepository) StreamOrdersByFilter(ctx context.Context, filters map[string]string, fn func(*models.Order) error) error {
+	filter, err := buildOrderFilter(filters)
+	if err != nil {
+		return err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetBatchSize(500)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		fmt.Println("ERROR: Failed to query orders for export:", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var order models.Order
+		if err := cursor.Decode(&order); err != nil {
+			return fmt.Errorf("failed to decode order: %w", err)
+		}
+		if err := fn(&order); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// CountOrdersByFilter returns how many orders match filters across all pages
+func (r *OrderRepository) CountOrdersByFilter(ctx context.Context, filters map[string]string) (int64, error) {
+	filter, err := buildOrderFilter(filters)
+	if err != nil {
+		return 0, err
+	}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		fmt.Println("ERROR: Failed to count filtered orders in MongoDB:", err)
+		return 0, err
+	}
+	return total, nil
+}
+
 // GetOrderByID
 func (r *OrderRepository) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
 	fmt.Printf("Looking for order with ID: %s\n", orderID)
 	filter := bson.M{"order_id": orderID}
-	var result bson.M
-	err := r.collection.FindOne(ctx, filter).Decode(&result)
+	order := &models.Order{}
+	err := r.collection.FindOne(ctx, filter).Decode(order)
 	if err != nil {
 		if err.Error() == "mongo: no documents in result" {
 			fmt.Printf("Order not found with ID: %s\n", orderID)
@@ -117,34 +228,27 @@ func (r *OrderRepository) GetOrderByID(ctx context.Context, orderID string) (*mo
 		fmt.Printf("ERROR: Failed to query order from MongoDB - OrderID: %s: %v\n", orderID, err)
 		return nil, fmt.Errorf("failed to query order: %w", err)
 	}
-	order := &models.Order{
-		ID:       result["order_id"].(string),
-		SKU:      result["sku"].(string),
-		Location: result["location"].(string),
-		TenantID: result["tenant_id"].(string),
-		SellerID: result["seller_id"].(string),
-		Status:   models.OrderStatus(result["status"].(string)),
-	}
-	if createdAt, ok := result["created_at"].(primitive.DateTime); ok {
-		order.CreatedAt = createdAt.Time()
-	} else if createdAt, ok := result["created_at"].(time.Time); ok {
-		order.CreatedAt = createdAt
-	} else {
-		order.CreatedAt = time.Now()
-	}
-	if updatedAt, ok := result["updated_at"].(primitive.DateTime); ok {
-		order.UpdatedAt = updatedAt.Time()
-	} else if updatedAt, ok := result["updated_at"].(time.Time); ok {
-		order.UpdatedAt = updatedAt
-	} else {
-		order.UpdatedAt = time.Now()
-	}
 
 	fmt.Printf("Found order - OrderID: %s, Status: %s, SKU: %s, Location: %s\n",
 		order.ID, order.Status, order.SKU, order.Location)
 	return order, nil
 }
 
+// GetOrderByExternalID finds a tenant's order by the client's external reference
+func (r *OrderRepository) GetOrderByExternalID(ctx context.Context, tenantID, externalOrderID string) (*models.Order, error) {
+	filter := bson.M{"tenant_id": tenantID, "external_order_id": externalOrderID}
+	var order models.Order
+	err := r.collection.FindOne(ctx, filter).Decode(&order)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("order not found with external ID: %s", externalOrderID)
+		}
+		fmt.Printf("ERROR: Failed to query order by external ID - Tenant: %s, ExternalOrderID: %s: %v\n", tenantID, externalOrderID, err)
+		return nil, fmt.Errorf("failed to query order: %w", err)
+	}
+	return &order, nil
+}
+
 // UpdateOrderStatus
 func (r *OrderRepository) UpdateOrderStatus(ctx context.Context, orderID string, newStatus models.OrderStatus) error {
 	fmt.Printf("Updating order status - OrderID: %s, NewStatus: %s\n", orderID, newStatus)
@@ -174,8 +278,161 @@ func (r *OrderRepository) UpdateOrderStatus(ctx context.Context, orderID string,
 	return nil
 }
 
+// UpdateOrderFulfilment stores a split order's sub-shipments with the status derived from them
+func (r *OrderRepository) UpdateOrderFulfilment(ctx context.Context, orderID string, newStatus models.OrderStatus, shipments []models.SubShipment) error {
+	fmt.Printf("Updating order fulfilment - OrderID: %s, NewStatus: %s, SubShipments: %d\n", orderID, newStatus, len(shipments))
+	filter := bson.M{"order_id": orderID}
+	update := bson.M{
+		"$set": bson.M{
+			"status":        newStatus,
+			"sub_shipments": shipments,
+			"updated_at":    time.Now(),
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to update order fulfilment - OrderID: %s: %v\n", orderID, err)
+		return fmt.Errorf("failed to update order fulfilment: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("order not found with ID: %s", orderID)
+	}
+	return nil
+}
+
+// MarkOrderWaiting keeps an order waiting for stock in status, recording why the
+// attempt failed. The first wait also stamps waiting_since.
+func (r *OrderRepository) MarkOrderWaiting(ctx context.Context, orderID string, status models.OrderStatus, reason string) error {
+	fmt.Printf("Order waiting for stock - OrderID: %s, Status: %s, Reason: %s\n", orderID, status, reason)
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{"status": status, "wait_reason": reason, "updated_at": now},
+		"$min": bson.M{"waiting_since": now},
+		"$inc": bson.M{"retry_attempts": 1},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"order_id": orderID}, update)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to mark order waiting - OrderID: %s: %v\n", orderID, err)
+		return fmt.Errorf("failed to mark order waiting: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("order not found with ID: %s", orderID)
+	}
+	return nil
+}
+
+// GetWaitingOrders returns on_hold and backordered orders that already failed a
+// finalization attempt, express orders first and then oldest first, so scarce stock
+// goes to high-priority orders. Empty tenantID or sku match every tenant or SKU.
+func (r *OrderRepository) GetWaitingOrders(ctx context.Context, tenantID, sku string, limit int) ([]models.Order, error) {
+	filter := bson.M{
+		"status":        bson.M{"$in": []models.OrderStatus{models.OrderStatusOnHold, models.OrderStatusBackordered}},
+		"waiting_since": bson.M{"$exists": true},
+	}
+	if tenantID != "" {
+		filter["tenant_id"] = tenantID
+	}
+	if sku != "" {
+		filter["sku"] = sku
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$addFields", Value: bson.M{"priority_rank": bson.M{
+			"$cond": bson.A{bson.M{"$eq": bson.A{"$priority", models.PriorityExpress}}, 0, 1},
+		}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "priority_rank", Value: 1}, {Key: "created_at", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to query waiting orders: %v\n", err)
+		return nil, fmt.Errorf("failed to query waiting orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("failed to decode waiting orders: %w", err)
+	}
+	return orders, nil
+}
+
+// GetOrdersBySLA returns open orders matching filters whose SLA is at risk or breached,
+// or both when state is empty, soonest deadline first, with their total count
+func (r *OrderRepository) GetOrdersBySLA(ctx context.Context, filters map[string]string, state string, now time.Time, limit, offset int) ([]models.Order, int64, error) {
+	filter, err := buildOrderFilter(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	open := []models.OrderStatus{models.OrderStatusOnHold, models.OrderStatusBackordered, models.OrderStatusNewOrder}
+	if status, ok := filter["status"]; ok {
+		filter["$and"] = bson.A{bson.M{"status": status}, bson.M{"status": bson.M{"$in": open}}}
+		delete(filter, "status")
+	} else {
+		filter["status"] = bson.M{"$in": open}
+	}
+	switch state {
+	case models.SLABreached:
+		filter["sla_deadline"] = bson.M{"$lt": now}
+	case models.SLAAtRisk:
+		filter["sla_at_risk_at"] = bson.M{"$lte": now}
+		filter["sla_deadline"] = bson.M{"$gte": now}
+	default:
+		filter["sla_at_risk_at"] = bson.M{"$lte": now}
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		fmt.Println("ERROR: Failed to count SLA orders:", err)
+		return nil, 0, err
+	}
+	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(offset)).SetSort(bson.D{{Key: "sla_deadline", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		fmt.Println("ERROR: Failed to query SLA orders:", err)
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+// ClaimSLABreaches marks open orders past their deadline as breached and returns them.
+// Each order is claimed once, so its breach is reported once.
+func (r *OrderRepository) ClaimSLABreaches(ctx context.Context, now time.Time, limit int) ([]models.Order, error) {
+	filter := bson.M{
+		"status":          bson.M{"$in": []models.OrderStatus{models.OrderStatusOnHold, models.OrderStatusBackordered, models.OrderStatusNewOrder}},
+		"sla_deadline":    bson.M{"$lt": now},
+		"sla_breached_at": bson.M{"$exists": false},
+	}
+	var breached []models.Order
+	for len(breached) < limit {
+		var order models.Order
+		err := r.collection.FindOneAndUpdate(ctx, filter,
+			bson.M{"$set": bson.M{"sla_breached_at": now}},
+			options.FindOneAndUpdate().SetSort(bson.D{{Key: "sla_deadline", Value: 1}}).SetReturnDocument(options.After),
+		).Decode(&order)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			fmt.Println("ERROR: Failed to claim SLA breach:", err)
+			return breached, err
+		}
+		breached = append(breached, order)
+	}
+	return breached, nil
+}
+
 // SaveWebhookEvent to database
 func SaveWebhookEvent(ctx context.Context, event interface{}) error {
+	if GetGlobalDatabase() == nil {
+		return errors.New("database not connected")
+	}
 	collection := GetGlobalDatabase().GetCollection("webhook_events")
 	_, err := collection.InsertOne(ctx, event)
 	if err != nil {
@@ -183,3 +440,80 @@ func SaveWebhookEvent(ctx context.Context, event interface{}) error {
 	}
 	return nil
 }
+
+// ErrReturnExceedsOrder is returned when a return would take more of a SKU than the
+// order has left to return
+var ErrReturnExceedsOrder = errors.New("return exceeds what is left of the order")
+
+// ReserveOrderReturn links a return request to its order and counts its lines in the
+// order's returned_quantities, in one update that only matches while every line still
+// fits the order's returnable quantities, so concurrent requests cannot together return
+// more than was shipped. returned seeds the count of an order whose returns predate it.
+func (r *OrderRepository) ReserveOrderReturn(ctx context.Context, order *models.Order, returnID string, lines []models.ReturnLine, returned map[string]int) error {
+	seed := bson.M{}
+	for sku, quantity := range returned {
+		seed[models.ReturnCounterKey(sku)] = quantity
+	}
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"order_id": order.ID, "returned_quantities": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"returned_quantities": seed}})
+	if err != nil {
+		return fmt.Errorf("failed to count order returns: %w", err)
+	}
+
+	returnable := models.ReturnableQuantities(order)
+	filter := bson.M{"order_id": order.ID}
+	increments := bson.M{}
+	for _, line := range lines {
+		field := "returned_quantities." + models.ReturnCounterKey(line.SKU)
+		filter[field] = bson.M{"$not": bson.M{"$gt": returnable[line.SKU] - line.Quantity}}
+		increments[field] = line.Quantity
+	}
+	update := bson.M{
+		"$inc":      increments,
+		"$addToSet": bson.M{"return_ids": returnID},
+		"$set":      bson.M{"updated_at": time.Now()},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to link return - OrderID: %s, ReturnID: %s: %v\n", order.ID, returnID, err)
+		return fmt.Errorf("failed to link return to order: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: order %s", ErrReturnExceedsOrder, order.ID)
+	}
+	return nil
+}
+
+// ReleaseOrderReturn takes a rejected or unsaved return's lines back out of the order's
+// returned_quantities
+func (r *OrderRepository) ReleaseOrderReturn(ctx context.Context, orderID string, lines []models.ReturnLine) error {
+	increments := bson.M{}
+	for _, line := range lines {
+		increments["returned_quantities."+models.ReturnCounterKey(line.SKU)] = -line.Quantity
+	}
+	// an order not counted yet has nothing to release; its count is seeded without the return
+	filter := bson.M{"order_id": orderID, "returned_quantities": bson.M{"$exists": true}}
+	update := bson.M{"$inc": increments, "$set": bson.M{"updated_at": time.Now()}}
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		fmt.Printf("ERROR: Failed to release return - OrderID: %s: %v\n", orderID, err)
+		return fmt.Errorf("failed to release order return: %w", err)
+	}
+	return nil
+}
+
+// AdvanceOrderStatus moves an order to newStatus only while it is still in from, and
+// reports whether it moved. Carrier updates use it so a stale poll cannot move an order back.
+func (r *OrderRepository) AdvanceOrderStatus(ctx context.Context, orderID string, from, newStatus models.OrderStatus) (bool, error) {
+	filter := bson.M{"order_id": orderID, "status": from}
+	update := bson.M{"$set": bson.M{"status": newStatus, "updated_at": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to advance order status - OrderID: %s: %v\n", orderID, err)
+		return false, fmt.Errorf("failed to advance order status: %w", err)
+	}
+	if result.ModifiedCount > 0 {
+		fmt.Printf("Order status advanced - OrderID: %s, %s -> %s\n", orderID, from, newStatus)
+	}
+	return result.ModifiedCount > 0, nil
+}
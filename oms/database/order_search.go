@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"oms/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// searchFacets groups matches by these fields; day buckets created_at as YYYY-MM-DD
+var searchFacets = map[string]interface{}{
+	"status":   "$status",
+	"tenant":   "$tenant_id",
+	"location": "$location",
+	"day":      bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$created_at"}},
+}
+
+type searchHit struct {
+	models.Order `bson:",inline"`
+	Score        float64 `bson:"score"`
+}
+
+type searchPage struct {
+	Results []searchHit `bson:"results"`
+	Total   []struct {
+		Count int64 `bson:"count"`
+	} `bson:"total"`
+	Status   []models.FacetCount `bson:"status"`
+	Tenant   []models.FacetCount `bson:"tenant"`
+	Location []models.FacetCount `bson:"location"`
+	Day      []models.FacetCount `bson:"day"`
+}
+
+// SearchOrders matches query against the order text index, narrowed by ListOrders filters.
+// Whole words go through $text and are ranked by text score; when that finds nothing the
+// query is retried as a case-insensitive fragment of any searchable field.
+func (r *OrderRepository) SearchOrders(ctx context.Context, query string, filters map[string]string, limit, offset int) (*models.OrderSearchResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	match, err := buildOrderFilter(filters)
+	if err != nil {
+		return nil, err
+	}
+	match["$text"] = bson.M{"$search": query}
+
+	page, err := r.searchPage(ctx, match, true, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	mode := models.SearchModeText
+
+	if len(page.Total) == 0 {
+		delete(match, "$text")
+		pattern := regexp.QuoteMeta(query)
+		var or bson.A
+		for _, field := range models.SearchableFields {
+			or = append(or, bson.M{field: bson.M{"$regex": pattern, "$options": "i"}})
+		}
+		match["$or"] = or
+
+		page, err = r.searchPage(ctx, match, false, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		mode = models.SearchModeFragment
+	}
+
+	result := &models.OrderSearchResult{
+		Mode: mode,
+		Hits: make([]models.OrderHit, 0, len(page.Results)),
+		Facets: map[string][]models.FacetCount{
+			"status":   page.Status,
+			"tenant":   page.Tenant,
+			"location": page.Location,
+			"day":      page.Day,
+		},
+	}
+	if len(page.Total) > 0 {
+		result.Total = page.Total[0].Count
+	}
+
+	terms := models.SearchTerms(query)
+	for _, hit := range page.Results {
+		result.Hits = append(result.Hits, models.OrderHit{
+			Order:      hit.Order,
+			Score:      hit.Score,
+			Highlights: models.HighlightOrder(hit.Order, terms),
+		})
+	}
+
+	fmt.Printf("Order search (%s) matched %d orders\n", mode, result.Total)
+	return result, nil
+}
+
+// searchPage runs one $facet aggregation returning a page of matches and the facet counts
+func (r *OrderRepository) searchPage(ctx context.Context, match bson.M, scored bool, limit, offset int) (*searchPage, error) {
+	sort := bson.D{{Key: "created_at", Value: -1}}
+	score := interface{}(bson.M{"$literal": 0})
+	if scored {
+		sort = bson.D{{Key: "score", Value: -1}, {Key: "created_at", Value: -1}}
+		score = bson.M{"$meta": "textScore"}
+	}
+
+	facets := bson.M{
+		"results": bson.A{
+			bson.M{"$sort": sort},
+			bson.M{"$skip": offset},
+			bson.M{"$limit": limit},
+		},
+		"total": bson.A{bson.M{"$count": "count"}},
+	}
+	for name, key := range searchFacets {
+		facets[name] = bson.A{
+			bson.M{"$group": bson.M{"_id": key, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$addFields", Value: bson.M{"score": score}}},
+		{{Key: "$facet", Value: facets}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		fmt.Println("ERROR: Failed to search orders in MongoDB:", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pages []searchPage
+	if err := cursor.All(ctx, &pages); err != nil {
+		fmt.Println("ERROR: Failed to decode order search results:", err)
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return &searchPage{}, nil
+	}
+	return &pages[0], nil
+}
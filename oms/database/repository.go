@@ -1,10 +1,19 @@
 package database
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 
+// ErrDuplicateOrder is returned when a tenant already has an order with the same external reference
+var ErrDuplicateOrder = errors.New("duplicate external order reference")
+
 type OrderRepository struct {
 	db         *Database
 	collection *mongo.Collection
@@ -21,3 +30,39 @@ func NewOrderRepository(db *Database) *OrderRepository {
 		collection: collection,
 	}
 }
+
+// orderIndexes back the ListOrders filters and sort and the search text index
+var orderIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "order_id", Value: 1}}, Options: options.Index().SetName("order_id_unique").SetUnique(true)},
+	{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("tenant_created_at")},
+	{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("tenant_status_created_at")},
+	{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "updated_at", Value: -1}}, Options: options.Index().SetName("tenant_updated_at")},
+	{Keys: bson.D{{Key: "sku", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("sku_created_at")},
+	{Keys: bson.D{{Key: "location", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("location_created_at")},
+	{Keys: bson.D{{Key: "created_at", Value: -1}}, Options: options.Index().SetName("created_at")},
+	{Keys: bson.D{{Key: "status", Value: 1}, {Key: "tenant_id", Value: 1}, {Key: "sku", Value: 1}, {Key: "created_at", Value: 1}}, Options: options.Index().SetName("status_tenant_sku_created_at")},
+	{Keys: bson.D{{Key: "status", Value: 1}, {Key: "sla_deadline", Value: 1}}, Options: options.Index().SetName("status_sla_deadline")},
+	{
+		Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "external_order_id", Value: 1}},
+		Options: options.Index().SetName("tenant_external_order_id_unique").SetUnique(true).
+			SetPartialFilterExpression(bson.M{"external_order_id": bson.M{"$type": "string"}}),
+	},
+	{
+		Keys: bson.D{
+			{Key: "order_id", Value: "text"}, {Key: "sku", Value: "text"}, {Key: "seller_id", Value: "text"},
+			{Key: "location", Value: "text"}, {Key: "tenant_id", Value: "text"},
+		},
+		Options: options.Index().SetName("order_search_text").
+			SetWeights(bson.D{{Key: "order_id", Value: 10}, {Key: "sku", Value: 5}, {Key: "seller_id", Value: 2}, {Key: "location", Value: 2}}),
+	},
+}
+
+// EnsureIndexes creates the orders collection indexes; existing ones are left as they are
+func (r *OrderRepository) EnsureIndexes(ctx context.Context) error {
+	names, err := r.collection.Indexes().CreateMany(ctx, orderIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create order indexes: %w", err)
+	}
+	fmt.Println("Order indexes ready:", names)
+	return nil
+}
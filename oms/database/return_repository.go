@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"oms/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrReturnNotFound is returned when no return request has the given ID
+	ErrReturnNotFound = errors.New("return not found")
+	// ErrReturnChanged is returned when a return moved on before an update was saved
+	ErrReturnChanged = errors.New("return status changed concurrently")
+)
+
+// ReturnRepository stores return requests (RMAs)
+type ReturnRepository struct {
+	collection *mongo.Collection
+}
+
+func NewReturnRepository(db *Database) *ReturnRepository {
+	return &ReturnRepository{collection: db.GetCollection("returns")}
+}
+
+// SaveReturn inserts a new return request
+func (r *ReturnRepository) SaveReturn(ctx context.Context, ret *models.ReturnRequest) error {
+	if _, err := r.collection.InsertOne(ctx, ret); err != nil {
+		fmt.Printf("ERROR: Failed to save return - ReturnID: %s: %v\n", ret.ID, err)
+		return fmt.Errorf("failed to save return: %w", err)
+	}
+	fmt.Printf("Return saved - ReturnID: %s, OrderID: %s\n", ret.ID, ret.OrderID)
+	return nil
+}
+
+// GetReturnByID finds a return request by its ID
+func (r *ReturnRepository) GetReturnByID(ctx context.Context, returnID string) (*models.ReturnRequest, error) {
+	ret := &models.ReturnRequest{}
+	err := r.collection.FindOne(ctx, bson.M{"return_id": returnID}).Decode(ret)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrReturnNotFound, returnID)
+		}
+		fmt.Printf("ERROR: Failed to query return - ReturnID: %s: %v\n", returnID, err)
+		return nil, fmt.Errorf("failed to query return: %w", err)
+	}
+	return ret, nil
+}
+
+// GetReturnsByOrder lists an order's return requests, oldest first
+func (r *ReturnRepository) GetReturnsByOrder(ctx context.Context, orderID string) ([]models.ReturnRequest, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"order_id": orderID}, opts)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to query returns - OrderID: %s: %v\n", orderID, err)
+		return nil, fmt.Errorf("failed to query returns: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	returns := []models.ReturnRequest{}
+	if err := cursor.All(ctx, &returns); err != nil {
+		return nil, fmt.Errorf("failed to decode returns: %w", err)
+	}
+	return returns, nil
+}
+
+// UpdateReturn replaces ret only while it is still in status from, so two
+// concurrent transitions of the same return cannot both succeed
+func (r *ReturnRepository) UpdateReturn(ctx context.Context, ret *models.ReturnRequest, from models.ReturnStatus) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"return_id": ret.ID, "status": from}, ret)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to update return - ReturnID: %s: %v\n", ret.ID, err)
+		return fmt.Errorf("failed to update return: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s is no longer %s", ErrReturnChanged, ret.ID, from)
+	}
+	fmt.Printf("Return updated - ReturnID: %s, Status: %s -> %s\n", ret.ID, from, ret.Status)
+	return nil
+}
+
+// EnsureIndexes makes return_id unique and backs the per-order listing
+func (r *ReturnRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "return_id", Value: 1}}, Options: options.Index().SetName("return_id_unique").SetUnique(true)},
+		{Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "created_at", Value: 1}}, Options: options.Index().SetName("order_created_at")},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create return indexes: %w", err)
+	}
+	return nil
+}
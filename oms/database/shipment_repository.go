@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"oms/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrShipmentNotFound is returned when no shipment has the given ID
+	ErrShipmentNotFound = errors.New("shipment not found")
+	// ErrParcelBooked is returned when another active shipment already carries the parcel
+	ErrParcelBooked = errors.New("parcel already has an active shipment")
+)
+
+// ShipmentRepository stores carrier shipments
+type ShipmentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewShipmentRepository(db *Database) *ShipmentRepository {
+	return &ShipmentRepository{collection: db.GetCollection("shipments")}
+}
+
+// SaveShipment inserts a new shipment. It returns ErrParcelBooked when an active shipment
+// of the same parcel was saved first.
+func (r *ShipmentRepository) SaveShipment(ctx context.Context, shipment *models.Shipment) error {
+	if _, err := r.collection.InsertOne(ctx, shipment); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("%w: %s", ErrParcelBooked, shipment.ActiveParcel)
+		}
+		fmt.Printf("ERROR: Failed to save shipment - ShipmentID: %s: %v\n", shipment.ID, err)
+		return fmt.Errorf("failed to save shipment: %w", err)
+	}
+	fmt.Printf("Shipment saved - ShipmentID: %s, OrderID: %s, Tracking: %s\n", shipment.ID, shipment.OrderID, shipment.TrackingNumber)
+	return nil
+}
+
+// GetShipmentByID finds a shipment by its ID
+func (r *ShipmentRepository) GetShipmentByID(ctx context.Context, shipmentID string) (*models.Shipment, error) {
+	shipment := &models.Shipment{}
+	err := r.collection.FindOne(ctx, bson.M{"shipment_id": shipmentID}).Decode(shipment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrShipmentNotFound, shipmentID)
+		}
+		fmt.Printf("ERROR: Failed to query shipment - ShipmentID: %s: %v\n", shipmentID, err)
+		return nil, fmt.Errorf("failed to query shipment: %w", err)
+	}
+	return shipment, nil
+}
+
+// GetShipmentsByOrder lists an order's shipments, oldest first
+func (r *ShipmentRepository) GetShipmentsByOrder(ctx context.Context, orderID string) ([]models.Shipment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	return r.find(ctx, bson.M{"order_id": orderID}, opts)
+}
+
+// GetActiveShipments returns up to limit shipments the carrier may still update,
+// least recently polled first
+func (r *ShipmentRepository) GetActiveShipments(ctx context.Context, limit int) ([]models.Shipment, error) {
+	filter := bson.M{"status": bson.M{"$ne": models.ShipmentDelivered}}
+	opts := options.Find().SetSort(bson.D{{Key: "last_polled_at", Value: 1}}).SetLimit(int64(limit))
+	return r.find(ctx, filter, opts)
+}
+
+// UpdateShipment replaces the stored shipment with shipment
+func (r *ShipmentRepository) UpdateShipment(ctx context.Context, shipment *models.Shipment) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"shipment_id": shipment.ID}, shipment)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to update shipment - ShipmentID: %s: %v\n", shipment.ID, err)
+		return fmt.Errorf("failed to update shipment: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrShipmentNotFound, shipment.ID)
+	}
+	return nil
+}
+
+func (r *ShipmentRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.Shipment, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to query shipments: %v\n", err)
+		return nil, fmt.Errorf("failed to query shipments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	shipments := []models.Shipment{}
+	if err := cursor.All(ctx, &shipments); err != nil {
+		return nil, fmt.Errorf("failed to decode shipments: %w", err)
+	}
+	return shipments, nil
+}
+
+// EnsureIndexes makes shipment_id and active parcels unique and backs the order listing
+// and the status poll
+func (r *ShipmentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "shipment_id", Value: 1}}, Options: options.Index().SetName("shipment_id_unique").SetUnique(true)},
+		{Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "created_at", Value: 1}}, Options: options.Index().SetName("order_created_at")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "last_polled_at", Value: 1}}, Options: options.Index().SetName("status_last_polled_at")},
+		{Keys: bson.D{{Key: "carrier", Value: 1}, {Key: "tracking_number", Value: 1}}, Options: options.Index().SetName("carrier_tracking_number")},
+		{
+			Keys: bson.D{{Key: "active_parcel", Value: 1}},
+			Options: options.Index().SetName("active_parcel_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"active_parcel": bson.M{"$exists": true}}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create shipment indexes: %w", err)
+	}
+	return nil
+}
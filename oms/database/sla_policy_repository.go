@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"oms/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SLAPolicyRepository stores one SLA policy per tenant
+type SLAPolicyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSLAPolicyRepository(db *Database) *SLAPolicyRepository {
+	return &SLAPolicyRepository{collection: db.GetCollection("sla_policies")}
+}
+
+// GetPolicy returns the tenant's policy, or the default policy when none is configured
+func (r *SLAPolicyRepository) GetPolicy(ctx context.Context, tenantID string) (models.SLAPolicy, error) {
+	var policy models.SLAPolicy
+	err := r.collection.FindOne(ctx, bson.M{"tenant_id": tenantID}).Decode(&policy)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.DefaultSLAPolicy(tenantID), nil
+		}
+		fmt.Printf("ERROR: Failed to query SLA policy - Tenant: %s: %v\n", tenantID, err)
+		return policy, fmt.Errorf("failed to query SLA policy: %w", err)
+	}
+	return policy, nil
+}
+
+// SavePolicy replaces the tenant's policy
+func (r *SLAPolicyRepository) SavePolicy(ctx context.Context, policy *models.SLAPolicy) error {
+	policy.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"tenant_id": policy.TenantID}, policy, options.Replace().SetUpsert(true))
+	if err != nil {
+		fmt.Printf("ERROR: Failed to save SLA policy - Tenant: %s: %v\n", policy.TenantID, err)
+		return fmt.Errorf("failed to save SLA policy: %w", err)
+	}
+	fmt.Printf("SLA policy saved - Tenant: %s, Timezone: %s\n", policy.TenantID, policy.Timezone)
+	return nil
+}
+
+// EnsureIndexes makes tenant_id unique so each tenant has at most one policy
+func (r *SLAPolicyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+		Options: options.Index().SetName("tenant_id_unique").SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create SLA policy indexes: %w", err)
+	}
+	return nil
+}
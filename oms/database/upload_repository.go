@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"oms/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrUploadNotFound is returned when no upload was queued under the given request ID
+var ErrUploadNotFound = errors.New("upload not found")
+
+// UploadResultRepository stores the outcome of queued order uploads
+type UploadResultRepository struct {
+	collection *mongo.Collection
+}
+
+func NewUploadResultRepository(db *Database) *UploadResultRepository {
+	return &UploadResultRepository{collection: db.GetCollection("upload_results")}
+}
+
+// SaveUploadResult stores result, replacing an earlier state of the same upload
+func (r *UploadResultRepository) SaveUploadResult(ctx context.Context, result *models.UploadResult) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"request_id": result.RequestID}, result, options.Replace().SetUpsert(true))
+	if err != nil {
+		fmt.Printf("ERROR: Failed to save upload result - RequestID: %s: %v\n", result.RequestID, err)
+		return fmt.Errorf("failed to save upload result: %w", err)
+	}
+	return nil
+}
+
+// QueueUploadResult stores result unless the upload already has one; the upload may be
+// picked up before the request that queued it records it
+func (r *UploadResultRepository) QueueUploadResult(ctx context.Context, result *models.UploadResult) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"request_id": result.RequestID}, bson.M{"$setOnInsert": result}, options.Update().SetUpsert(true))
+	if err != nil {
+		fmt.Printf("ERROR: Failed to queue upload result - RequestID: %s: %v\n", result.RequestID, err)
+		return fmt.Errorf("failed to queue upload result: %w", err)
+	}
+	return nil
+}
+
+// GetUploadResult finds the result of the upload queued as requestID
+func (r *UploadResultRepository) GetUploadResult(ctx context.Context, requestID string) (*models.UploadResult, error) {
+	result := &models.UploadResult{}
+	err := r.collection.FindOne(ctx, bson.M{"request_id": requestID}).Decode(result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrUploadNotFound, requestID)
+		}
+		fmt.Printf("ERROR: Failed to query upload result - RequestID: %s: %v\n", requestID, err)
+		return nil, fmt.Errorf("failed to query upload result: %w", err)
+	}
+	return result, nil
+}
+
+// EnsureIndexes makes request_id unique
+func (r *UploadResultRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "request_id", Value: 1}},
+		Options: options.Index().SetName("request_id_unique").SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create upload result indexes: %w", err)
+	}
+	return nil
+}
@@ -1,17 +1,85 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"oms/models"
+
 	"github.com/gin-gonic/gin"
 	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/log"
 )
 
+// maxLoggedBody caps how much of a request or response body is logged
+const maxLoggedBody = 64 << 10
+
+// LoggingMiddleware logs each request line; bodies are logged by BodyLoggingMiddleware
+// so that personal data can be masked first.
 func LoggingMiddleware() gin.HandlerFunc {
 	return http.RequestLogMiddleware(http.LoggingMiddlewareOptions{
 		Format:      "json",
 		Level:       "info",
-		LogRequest:  true,
-		LogResponse: true,
-		LogHeader:   false, 
+		LogRequest:  false,
+		LogResponse: false,
+		LogHeader:   false,
 	})
 }
 
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	if w.body.Len() < maxLoggedBody {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// BodyLoggingMiddleware logs JSON request and response bodies with customer names,
+// phones, emails and street addresses masked.
+func BodyLoggingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requestBody []byte
+		if isJSON(c.ContentType()) && c.Request.Body != nil {
+			requestBody, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
+		}
+
+		writer := &bodyLogWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		c.Next()
+
+		if len(requestBody) > 0 {
+			log.Infof("request %s %s body: %s", c.Request.Method, c.Request.URL.Path, maskBody(requestBody))
+		}
+		if isJSON(writer.Header().Get("Content-Type")) && writer.body.Len() > 0 {
+			log.Infof("response %s %s %d body: %s", c.Request.Method, c.Request.URL.Path, writer.Status(), maskBody(writer.body.Bytes()))
+		}
+	}
+}
+
+func isJSON(contentType string) bool {
+	return strings.Contains(contentType, "application/json")
+}
+
+// maskBody masks personal data in a JSON body; bodies that do not parse are not logged
+func maskBody(body []byte) string {
+	if len(body) > maxLoggedBody {
+		return "[body too large to log]"
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return "[unparsable body omitted]"
+	}
+	masked, err := json.Marshal(models.MaskPII(decoded))
+	if err != nil {
+		return "[unparsable body omitted]"
+	}
+	return string(masked)
+}
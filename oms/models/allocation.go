@@ -0,0 +1,203 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AllocationStrategy is how a tenant's orders without a location are assigned a hub
+type AllocationStrategy string
+
+const (
+	AllocationPriority  AllocationStrategy = "priority"
+	AllocationNearest   AllocationStrategy = "nearest"
+	AllocationMostStock AllocationStrategy = "most_stock"
+)
+
+func (s AllocationStrategy) IsValid() bool {
+	switch s {
+	case AllocationPriority, AllocationNearest, AllocationMostStock:
+		return true
+	default:
+		return false
+	}
+}
+
+// AllocationRule is a tenant's allocation strategy. HubPriority lists hub locations,
+// most preferred first, and is only used by the priority strategy.
+type AllocationRule struct {
+	TenantID    string             `json:"tenant_id" bson:"tenant_id"`
+	Strategy    AllocationStrategy `json:"strategy" bson:"strategy"`
+	HubPriority []string           `json:"hub_priority,omitempty" bson:"hub_priority,omitempty"`
+	// AllowSplit lets an order no single hub can fulfil be split across hubs
+	AllowSplit bool      `json:"allow_split" bson:"allow_split"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// DefaultAllocationRule applies to tenants that have not configured one
+func DefaultAllocationRule(tenantID string) AllocationRule {
+	return AllocationRule{TenantID: tenantID, Strategy: AllocationMostStock}
+}
+
+// Validate checks the strategy is known and a priority rule names at least one hub
+func (r *AllocationRule) Validate() error {
+	if !r.Strategy.IsValid() {
+		return fmt.Errorf("invalid allocation strategy: %s", r.Strategy)
+	}
+	if r.Strategy == AllocationPriority && len(r.HubPriority) == 0 {
+		return errors.New("hub_priority is required for the priority strategy")
+	}
+	seen := make(map[string]bool)
+	for _, hub := range r.HubPriority {
+		if strings.TrimSpace(hub) == "" {
+			return errors.New("hub_priority contains an empty hub location")
+		}
+		if seen[hub] {
+			return fmt.Errorf("hub_priority lists %s more than once", hub)
+		}
+		seen[hub] = true
+	}
+	return nil
+}
+
+// HubCandidate is a hub considered for an order with its stock of the order's SKU.
+// Hub is the hub's location, which is how IMS keys stock and orders name their hub.
+type HubCandidate struct {
+	Hub        string   `json:"hub" bson:"hub"`
+	Name       string   `json:"name,omitempty" bson:"name,omitempty"`
+	Stock      int      `json:"stock" bson:"stock"`
+	Latitude   *float64 `json:"latitude,omitempty" bson:"latitude,omitempty"`
+	Longitude  *float64 `json:"longitude,omitempty" bson:"longitude,omitempty"`
+	DistanceKm *float64 `json:"distance_km,omitempty" bson:"distance_km,omitempty"`
+}
+
+// Allocation records which hub was chosen for an order and why; Hub is its location
+type Allocation struct {
+	Strategy    AllocationStrategy `json:"strategy" bson:"strategy"`
+	Hub         string             `json:"hub" bson:"hub"`
+	Reasons     []string           `json:"reasons" bson:"reasons"`
+	Candidates  []HubCandidate     `json:"candidates" bson:"candidates"`
+	AllocatedAt time.Time          `json:"allocated_at" bson:"allocated_at"`
+}
+
+// ErrNoHubs is returned when there is no hub to allocate from
+var ErrNoHubs = errors.New("no hubs available for allocation")
+
+// Allocate picks a hub for quantity units among candidates following rule.
+// Only hubs holding the full quantity are eligible; when none does, every hub
+// is considered so the order can wait for stock at the best one.
+// Strategies that cannot decide fall back to most stock.
+func Allocate(rule AllocationRule, candidates []HubCandidate, quantity int, destination *Address) (*Allocation, error) {
+	if len(candidates) == 0 {
+		return nil, ErrNoHubs
+	}
+	sorted := withDistances(candidates, destination)
+	allocation := &Allocation{Strategy: rule.Strategy, Candidates: sorted, AllocatedAt: time.Now()}
+
+	var eligible []HubCandidate
+	for _, c := range allocation.Candidates {
+		if c.Stock >= quantity {
+			eligible = append(eligible, c)
+		}
+	}
+	if len(eligible) == 0 {
+		allocation.reason("no hub holds %d units, considering all %d hubs", quantity, len(sorted))
+		eligible = allocation.Candidates
+	} else {
+		allocation.reason("%d of %d hubs hold %d units", len(eligible), len(sorted), quantity)
+	}
+
+	switch rule.Strategy {
+	case AllocationPriority:
+		if hub, rank := byPriority(eligible, rule.HubPriority); hub != "" {
+			allocation.Hub = hub
+			allocation.reason("%s is priority %d of %d", hub, rank, len(rule.HubPriority))
+			return allocation, nil
+		}
+		allocation.reason("no priority hub is eligible, falling back to most stock")
+	case AllocationNearest:
+		if nearest := byDistance(eligible); nearest != nil {
+			allocation.Hub = nearest.Hub
+			allocation.reason("%s is nearest at %.1f km", nearest.Hub, *nearest.DistanceKm)
+			return allocation, nil
+		}
+		allocation.reason("distances unknown, falling back to most stock")
+	}
+
+	most := byStock(eligible)
+	allocation.Hub = most.Hub
+	allocation.reason("%s has the most stock (%d)", most.Hub, most.Stock)
+	return allocation, nil
+}
+
+// withDistances copies candidates sorted by hub name, with their distance to
+// destination when both have coordinates
+func withDistances(candidates []HubCandidate, destination *Address) []HubCandidate {
+	sorted := append([]HubCandidate(nil), candidates...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Hub < sorted[j].Hub })
+	if destination == nil || destination.Latitude == nil || destination.Longitude == nil {
+		return sorted
+	}
+	for i := range sorted {
+		c := &sorted[i]
+		if c.Latitude != nil && c.Longitude != nil {
+			d := math.Round(DistanceKm(*destination.Latitude, *destination.Longitude, *c.Latitude, *c.Longitude)*10) / 10
+			c.DistanceKm = &d
+		}
+	}
+	return sorted
+}
+
+func (a *Allocation) reason(format string, args ...interface{}) {
+	a.Reasons = append(a.Reasons, fmt.Sprintf(format, args...))
+}
+
+func byPriority(candidates []HubCandidate, priority []string) (string, int) {
+	for i, hub := range priority {
+		for _, c := range candidates {
+			if c.Hub == hub {
+				return hub, i + 1
+			}
+		}
+	}
+	return "", 0
+}
+
+func byDistance(candidates []HubCandidate) *HubCandidate {
+	var nearest *HubCandidate
+	for i := range candidates {
+		c := &candidates[i]
+		if c.DistanceKm != nil && (nearest == nil || *c.DistanceKm < *nearest.DistanceKm) {
+			nearest = c
+		}
+	}
+	return nearest
+}
+
+// byStock returns the candidate with the most stock; ties go to the first by name
+func byStock(candidates []HubCandidate) HubCandidate {
+	most := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.Stock > most.Stock {
+			most = c
+		}
+	}
+	return most
+}
+
+// earthRadiusKm is the mean radius used for great-circle distances
+const earthRadiusKm = 6371.0
+
+// DistanceKm is the haversine great-circle distance between two points in degrees
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
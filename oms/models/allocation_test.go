@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func coord(v float64) *float64 { return &v }
+
+func TestAllocationRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    AllocationRule
+		wantErr bool
+	}{
+		{"most stock", AllocationRule{Strategy: AllocationMostStock}, false},
+		{"nearest", AllocationRule{Strategy: AllocationNearest}, false},
+		{"priority", AllocationRule{Strategy: AllocationPriority, HubPriority: []string{"hub-a", "hub-b"}}, false},
+		{"unknown strategy", AllocationRule{Strategy: "random"}, true},
+		{"priority without hubs", AllocationRule{Strategy: AllocationPriority}, true},
+		{"duplicate hub", AllocationRule{Strategy: AllocationPriority, HubPriority: []string{"hub-a", "hub-a"}}, true},
+		{"blank hub", AllocationRule{Strategy: AllocationPriority, HubPriority: []string{" "}}, true},
+	}
+	for _, tt := range tests {
+		err := tt.rule.Validate()
+		assert.Equal(t, tt.wantErr, err != nil, tt.name)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	// Mumbai, Delhi and Bengaluru hubs; the destination is in Pune
+	candidates := []HubCandidate{
+		{Hub: "delhi", Stock: 50, Latitude: coord(28.6139), Longitude: coord(77.2090)},
+		{Hub: "mumbai", Stock: 5, Latitude: coord(19.0760), Longitude: coord(72.8777)},
+		{Hub: "bengaluru", Stock: 2, Latitude: coord(12.9716), Longitude: coord(77.5946)},
+	}
+	pune := &Address{Latitude: coord(18.5204), Longitude: coord(73.8567)}
+
+	tests := []struct {
+		name        string
+		rule        AllocationRule
+		quantity    int
+		destination *Address
+		wantHub     string
+	}{
+		{"most stock", AllocationRule{Strategy: AllocationMostStock}, 1, nil, "delhi"},
+		{"nearest", AllocationRule{Strategy: AllocationNearest}, 1, pune, "mumbai"},
+		{"nearest skips hubs short of stock", AllocationRule{Strategy: AllocationNearest}, 10, pune, "delhi"},
+		{"nearest without coordinates", AllocationRule{Strategy: AllocationNearest}, 1, nil, "delhi"},
+		{"priority", AllocationRule{Strategy: AllocationPriority, HubPriority: []string{"bengaluru", "mumbai"}}, 1, nil, "bengaluru"},
+		{"priority skips hubs short of stock", AllocationRule{Strategy: AllocationPriority, HubPriority: []string{"bengaluru", "mumbai"}}, 3, nil, "mumbai"},
+		{"priority falls back", AllocationRule{Strategy: AllocationPriority, HubPriority: []string{"chennai"}}, 1, nil, "delhi"},
+		{"no hub holds the quantity", AllocationRule{Strategy: AllocationNearest}, 100, pune, "mumbai"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocation, err := Allocate(tt.rule, candidates, tt.quantity, tt.destination)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantHub, allocation.Hub)
+			assert.Equal(t, tt.rule.Strategy, allocation.Strategy)
+			assert.NotEmpty(t, allocation.Reasons)
+			assert.Len(t, allocation.Candidates, 3)
+		})
+	}
+}
+
+func TestAllocateRecordsDistances(t *testing.T) {
+	candidates := []HubCandidate{{Hub: "mumbai", Stock: 1, Latitude: coord(19.0760), Longitude: coord(72.8777)}, {Hub: "remote", Stock: 1}}
+	allocation, err := Allocate(AllocationRule{Strategy: AllocationNearest}, candidates, 1, &Address{Latitude: coord(18.5204), Longitude: coord(73.8567)})
+	assert.NoError(t, err)
+	assert.Equal(t, "mumbai", allocation.Hub)
+	assert.InDelta(t, 120, *allocation.Candidates[0].DistanceKm, 5)
+	assert.Nil(t, allocation.Candidates[1].DistanceKm)
+	assert.Nil(t, candidates[0].DistanceKm)
+}
+
+func TestAllocateWithoutHubs(t *testing.T) {
+	_, err := Allocate(DefaultAllocationRule("tenant-1"), nil, 1, nil)
+	assert.ErrorIs(t, err, ErrNoHubs)
+}
+
+func TestDistanceKm(t *testing.T) {
+	assert.InDelta(t, 0, DistanceKm(10, 10, 10, 10), 1e-9)
+	assert.InDelta(t, 1153, DistanceKm(19.0760, 72.8777, 28.6139, 77.2090), 10)
+}
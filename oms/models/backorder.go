@@ -0,0 +1,46 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// BackorderPolicy is how long a tenant's orders may wait for stock before they are cancelled.
+// A wait of zero hours never cancels.
+type BackorderPolicy struct {
+	TenantID string `json:"tenant_id" bson:"tenant_id"`
+	// Backorder keeps orders without stock as backordered instead of cancelling them
+	Backorder          bool      `json:"backorder" bson:"backorder"`
+	OnHoldWaitHours    int       `json:"on_hold_wait_hours" bson:"on_hold_wait_hours"`
+	BackorderWaitHours int       `json:"backorder_wait_hours" bson:"backorder_wait_hours"`
+	UpdatedAt          time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// DefaultBackorderPolicy applies to tenants that have not configured one
+func DefaultBackorderPolicy(tenantID string) BackorderPolicy {
+	return BackorderPolicy{TenantID: tenantID, Backorder: true, OnHoldWaitHours: 24, BackorderWaitHours: 168}
+}
+
+// Validate checks the waits are not negative
+func (p *BackorderPolicy) Validate() error {
+	if p.OnHoldWaitHours < 0 || p.BackorderWaitHours < 0 {
+		return errors.New("wait hours cannot be negative")
+	}
+	return nil
+}
+
+// Expired reports whether order has waited longer than the policy allows for its status
+func (p *BackorderPolicy) Expired(order *Order, now time.Time) bool {
+	hours := p.OnHoldWaitHours
+	if order.Status == OrderStatusBackordered {
+		hours = p.BackorderWaitHours
+	}
+	if hours == 0 {
+		return false
+	}
+	since := order.CreatedAt
+	if order.WaitingSince != nil {
+		since = *order.WaitingSince
+	}
+	return now.Sub(since) > time.Duration(hours)*time.Hour
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackorderPolicyValidate(t *testing.T) {
+	policy := DefaultBackorderPolicy("tenant-1")
+	assert.NoError(t, policy.Validate())
+
+	policy.OnHoldWaitHours = -1
+	assert.Error(t, policy.Validate())
+}
+
+func TestBackorderPolicyExpired(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	waitingSince := now.Add(-30 * time.Hour)
+	policy := BackorderPolicy{OnHoldWaitHours: 24, BackorderWaitHours: 48}
+
+	tests := []struct {
+		name  string
+		order Order
+		want  bool
+	}{
+		{"on hold past its wait", Order{Status: OrderStatusOnHold, WaitingSince: &waitingSince}, true},
+		{"backordered within its wait", Order{Status: OrderStatusBackordered, WaitingSince: &waitingSince}, false},
+		{"falls back to created_at", Order{Status: OrderStatusOnHold, CreatedAt: now.Add(-time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, policy.Expired(&tt.order, now), tt.name)
+	}
+
+	forever := BackorderPolicy{}
+	assert.False(t, forever.Expired(&Order{Status: OrderStatusBackordered, WaitingSince: &waitingSince}, now))
+}
+
+func TestOrderStatusIsWaiting(t *testing.T) {
+	assert.True(t, OrderStatusOnHold.IsWaiting())
+	assert.True(t, OrderStatusBackordered.IsWaiting())
+	assert.False(t, OrderStatusNewOrder.IsWaiting())
+	assert.False(t, OrderStatusCancelled.IsWaiting())
+}
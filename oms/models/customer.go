@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+var (
+	phonePattern      = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+	countryPattern    = regexp.MustCompile(`^[A-Z]{2}$`)
+	postalCodePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 -]{1,8}[A-Za-z0-9]$`)
+	phoneSeparators   = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+)
+
+// Customer is the recipient of an order
+type Customer struct {
+	Name  string `json:"name" bson:"name"`
+	Phone string `json:"phone,omitempty" bson:"phone,omitempty"`
+	Email string `json:"email,omitempty" bson:"email,omitempty"`
+}
+
+// Address is a postal address; Country is an ISO 3166-1 alpha-2 code.
+// Latitude and Longitude are optional and used to find the nearest hub.
+type Address struct {
+	Line1      string `json:"line1" bson:"line1"`
+	Line2      string `json:"line2,omitempty" bson:"line2,omitempty"`
+	City       string `json:"city" bson:"city"`
+	State      string `json:"state,omitempty" bson:"state,omitempty"`
+	PostalCode string `json:"postal_code,omitempty" bson:"postal_code,omitempty"`
+	Country    string `json:"country" bson:"country"`
+
+	Latitude  *float64 `json:"latitude,omitempty" bson:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty" bson:"longitude,omitempty"`
+}
+
+// NormalizePhone strips common separators so "+1 (555) 010-2030" becomes "+15550102030"
+func NormalizePhone(phone string) string {
+	return phoneSeparators.Replace(strings.TrimSpace(phone))
+}
+
+// Validate checks the customer has a name and well-formed phone and email when given.
+// Errors never echo the values, so they are safe to log.
+func (c *Customer) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("customer name is required")
+	}
+	if c.Phone != "" && !phonePattern.MatchString(NormalizePhone(c.Phone)) {
+		return errors.New("customer phone must be 7 to 15 digits with an optional leading +")
+	}
+	if c.Email != "" {
+		addr, err := mail.ParseAddress(c.Email)
+		if err != nil || addr.Address != strings.TrimSpace(c.Email) {
+			return errors.New("customer email is invalid")
+		}
+	}
+	return nil
+}
+
+// Validate checks the address has a street line, a city and a two-letter country code.
+// kind names the address in errors, e.g. "shipping".
+func (a *Address) Validate(kind string) error {
+	if strings.TrimSpace(a.Line1) == "" {
+		return errors.New(kind + " address line1 is required")
+	}
+	if strings.TrimSpace(a.City) == "" {
+		return errors.New(kind + " address city is required")
+	}
+	if !countryPattern.MatchString(strings.ToUpper(strings.TrimSpace(a.Country))) {
+		return errors.New(kind + " address country must be a two-letter ISO code")
+	}
+	if a.PostalCode != "" && !postalCodePattern.MatchString(strings.TrimSpace(a.PostalCode)) {
+		return errors.New(kind + " address postal_code is invalid")
+	}
+	if (a.Latitude == nil) != (a.Longitude == nil) {
+		return errors.New(kind + " address latitude and longitude must be given together")
+	}
+	if a.Latitude != nil && (*a.Latitude < -90 || *a.Latitude > 90 || *a.Longitude < -180 || *a.Longitude > 180) {
+		return errors.New(kind + " address coordinates are out of range")
+	}
+	return nil
+}
+
+// IsEmpty reports whether no address field was given
+func (a *Address) IsEmpty() bool {
+	return a == nil || *a == Address{}
+}
+
+// ValidateContact validates whichever of customer and addresses an order carries
+func (o *Order) ValidateContact() error {
+	if o.Customer != nil {
+		if err := o.Customer.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.ShippingAddress != nil {
+		if err := o.ShippingAddress.Validate("shipping"); err != nil {
+			return err
+		}
+	}
+	if o.BillingAddress != nil {
+		if err := o.BillingAddress.Validate("billing"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
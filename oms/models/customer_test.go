@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		customer Customer
+		wantErr  bool
+	}{
+		{"valid", Customer{Name: "Asha Rao", Phone: "+91 98450-12345", Email: "asha@example.com"}, false},
+		{"name only", Customer{Name: "Asha Rao"}, false},
+		{"missing name", Customer{Phone: "+919845012345"}, true},
+		{"short phone", Customer{Name: "Asha", Phone: "12345"}, true},
+		{"letters in phone", Customer{Name: "Asha", Phone: "+91 98450 ABCDE"}, true},
+		{"bad email", Customer{Name: "Asha", Email: "asha@"}, true},
+		{"display-name email", Customer{Name: "Asha", Email: "Asha <asha@example.com>"}, true},
+	}
+	for _, tt := range tests {
+		err := tt.customer.Validate()
+		assert.Equal(t, tt.wantErr, err != nil, tt.name)
+	}
+}
+
+func TestAddressValidate(t *testing.T) {
+	valid := Address{Line1: "12 MG Road", City: "Bengaluru", PostalCode: "560001", Country: "IN"}
+	assert.NoError(t, valid.Validate("shipping"))
+
+	missingCity := valid
+	missingCity.City = ""
+	assert.EqualError(t, missingCity.Validate("shipping"), "shipping address city is required")
+
+	badCountry := valid
+	badCountry.Country = "India"
+	assert.Error(t, badCountry.Validate("billing"))
+
+	badPostal := valid
+	badPostal.PostalCode = "56#001"
+	assert.Error(t, badPostal.Validate("billing"))
+
+	lat, lon, far := 12.97, 77.59, 120.0
+	located := valid
+	located.Latitude, located.Longitude = &lat, &lon
+	assert.NoError(t, located.Validate("shipping"))
+
+	latOnly := valid
+	latOnly.Latitude = &lat
+	assert.EqualError(t, latOnly.Validate("shipping"), "shipping address latitude and longitude must be given together")
+
+	outOfRange := located
+	outOfRange.Latitude = &far
+	assert.Error(t, outOfRange.Validate("shipping"))
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportFormat is a file format orders can be exported to
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+	ExportXLSX   ExportFormat = "xlsx"
+)
+
+// ParseExportFormat reads a format parameter; blank means CSV and jsonl is NDJSON
+func ParseExportFormat(value string) (ExportFormat, error) {
+	switch f := ExportFormat(strings.ToLower(strings.TrimSpace(value))); f {
+	case "":
+		return ExportCSV, nil
+	case "jsonl":
+		return ExportNDJSON, nil
+	case ExportCSV, ExportNDJSON, ExportXLSX:
+		return f, nil
+	default:
+		return "", fmt.Errorf("format must be csv, ndjson or xlsx")
+	}
+}
+
+// ContentType is the MIME type of an export in this format
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	case ExportXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv"
+	}
+}
+
+// ExportJobStatus is where an asynchronous export is
+type ExportJobStatus string
+
+const (
+	ExportPending   ExportJobStatus = "pending"
+	ExportRunning   ExportJobStatus = "running"
+	ExportCompleted ExportJobStatus = "completed"
+	ExportFailed    ExportJobStatus = "failed"
+)
+
+// ExportJob is an order export too large to stream, written to S3 in the background
+type ExportJob struct {
+	ID          string            `json:"id" bson:"job_id"`
+	Format      ExportFormat      `json:"format" bson:"format"`
+	Filters     map[string]string `json:"filters" bson:"filters"`
+	Status      ExportJobStatus   `json:"status" bson:"status"`
+	Total       int64             `json:"total" bson:"total"`
+	Rows        int64             `json:"rows" bson:"rows"`
+	S3Path      string            `json:"s3_path,omitempty" bson:"s3_path,omitempty"`
+	Error       string            `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt   time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" bson:"updated_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// NewExportJob queues an export of the total orders matching filters
+func NewExportJob(format ExportFormat, filters map[string]string, total int64) *ExportJob {
+	now := time.Now()
+	return &ExportJob{
+		ID:        generateID("EXP"),
+		Format:    format,
+		Filters:   filters,
+		Status:    ExportPending,
+		Total:     total,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Filename is the name of the job's export file
+func (j *ExportJob) Filename() string {
+	return fmt.Sprintf("orders-%s.%s", j.ID, j.Format)
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExportFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ExportFormat
+		wantErr bool
+	}{
+		{"", ExportCSV, false},
+		{"CSV", ExportCSV, false},
+		{"ndjson", ExportNDJSON, false},
+		{"jsonl", ExportNDJSON, false},
+		{" xlsx ", ExportXLSX, false},
+		{"pdf", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseExportFormat(tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+		assert.Equal(t, tt.wantErr, err != nil, tt.value)
+	}
+}
+
+func TestNewExportJob(t *testing.T) {
+	job := NewExportJob(ExportNDJSON, map[string]string{"status": "new_order"}, 42)
+	assert.Equal(t, ExportPending, job.Status)
+	assert.Equal(t, int64(42), job.Total)
+	assert.Equal(t, "orders-"+job.ID+".ndjson", job.Filename())
+}
@@ -13,6 +13,14 @@ const (
 	OrderStatusOnHold OrderStatus = "on_hold"
 	OrderStatusNewOrder OrderStatus = "new_order"
     OrderStatusCancelled OrderStatus = "cancelled"
+	// OrderStatusPartiallyFulfilled is derived for split orders with some sub-shipments cancelled
+	OrderStatusPartiallyFulfilled OrderStatus = "partially_fulfilled"
+	// OrderStatusBackordered waits for stock that no hub has yet
+	OrderStatusBackordered OrderStatus = "backordered"
+	// OrderStatusShipped orders were handed to a carrier and are on their way
+	OrderStatusShipped OrderStatus = "shipped"
+	// OrderStatusDelivered orders reached the customer and can be returned
+	OrderStatusDelivered OrderStatus = "delivered"
 )
 
 
@@ -20,27 +28,79 @@ const (
 
 func (s OrderStatus) IsValid() bool {
 	switch s {
-	case OrderStatusOnHold, OrderStatusNewOrder, OrderStatusCancelled:
+	case OrderStatusOnHold, OrderStatusNewOrder, OrderStatusCancelled, OrderStatusPartiallyFulfilled, OrderStatusBackordered, OrderStatusShipped, OrderStatusDelivered:
 		return true
 	default:
 		return false
 	}
 }
 
+// IsWaiting reports whether an order in this status is still waiting to be fulfilled
+func (s OrderStatus) IsWaiting() bool {
+	return s == OrderStatusOnHold || s == OrderStatusBackordered
+}
+
 
 
 
 
 
 type Order struct {
-	ID        string      `json:"id"`
-	SKU       string      `json:"sku"`
-	Location  string      `json:"location"`
-	TenantID  string      `json:"tenant_id"`
-	SellerID  string      `json:"seller_id"`
-	Status    OrderStatus `json:"status"`
-	CreatedAt time.Time   `json:"created_at"`
-	UpdatedAt time.Time   `json:"updated_at"`
+	ID        string      `json:"id" bson:"order_id"`
+	SKU       string      `json:"sku" bson:"sku"`
+	Location  string      `json:"location" bson:"location"`
+	TenantID  string      `json:"tenant_id" bson:"tenant_id"`
+	SellerID  string      `json:"seller_id" bson:"seller_id"`
+	Status    OrderStatus `json:"status" bson:"status"`
+	CreatedAt time.Time   `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at" bson:"updated_at"`
+
+	// ExternalOrderID is the client's own reference, unique per tenant when set
+	ExternalOrderID string `json:"external_order_id,omitempty" bson:"external_order_id,omitempty"`
+
+	Customer        *Customer `json:"customer,omitempty" bson:"customer,omitempty"`
+	ShippingAddress *Address  `json:"shipping_address,omitempty" bson:"shipping_address,omitempty"`
+	BillingAddress  *Address  `json:"billing_address,omitempty" bson:"billing_address,omitempty"`
+
+	// Quantity of SKU ordered; orders stored before it existed read as zero and mean one
+	Quantity int          `json:"quantity" bson:"quantity"`
+	Currency string       `json:"currency,omitempty" bson:"currency,omitempty"`
+	Lines    []OrderLine  `json:"lines,omitempty" bson:"lines,omitempty"`
+	Totals   *OrderTotals `json:"totals,omitempty" bson:"totals,omitempty"`
+
+	// Allocation is set when the hub was chosen by OMS rather than given with the order
+	Allocation *Allocation `json:"allocation,omitempty" bson:"allocation,omitempty"`
+
+	// SubShipments split the order across hubs when no single hub could fulfil it;
+	// Status is then derived from them
+	SubShipments []SubShipment `json:"sub_shipments,omitempty" bson:"sub_shipments,omitempty"`
+
+	// WaitingSince is when the order first had to wait for stock; RetryAttempts counts
+	// the attempts since, and WaitReason says why the last one failed
+	WaitingSince  *time.Time `json:"waiting_since,omitempty" bson:"waiting_since,omitempty"`
+	RetryAttempts int        `json:"retry_attempts,omitempty" bson:"retry_attempts,omitempty"`
+	WaitReason    string     `json:"wait_reason,omitempty" bson:"wait_reason,omitempty"`
+
+	// Priority and the SLA times computed from the tenant's cut-offs at creation;
+	// SLABreachedAt is set once the breach has been reported
+	Priority      OrderPriority `json:"priority" bson:"priority"`
+	SLADeadline   *time.Time    `json:"sla_deadline,omitempty" bson:"sla_deadline,omitempty"`
+	SLAAtRiskAt   *time.Time    `json:"sla_at_risk_at,omitempty" bson:"sla_at_risk_at,omitempty"`
+	SLABreachedAt *time.Time    `json:"sla_breached_at,omitempty" bson:"sla_breached_at,omitempty"`
+
+	// ReturnIDs links the order to its return requests
+	ReturnIDs []string `json:"return_ids,omitempty" bson:"return_ids,omitempty"`
+	// ReturnedQuantities counts the units of each SKU under returns that were not
+	// rejected, keyed by ReturnCounterKey
+	ReturnedQuantities map[string]int `json:"-" bson:"returned_quantities,omitempty"`
+}
+
+// OrderedQuantity is the quantity to reserve for the order
+func (o *Order) OrderedQuantity() int {
+	if o.Quantity <= 0 {
+		return 1
+	}
+	return o.Quantity
 }
 
 
@@ -49,6 +109,11 @@ type Order struct {
 
 // generateOrderID 
 func generateOrderID() string {
+	return generateID("ORD")
+}
+
+// generateID is prefix, the time in nanoseconds and six random characters
+func generateID(prefix string) string {
 
 	timestamp := time.Now().UnixNano()
 	const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
@@ -57,7 +122,7 @@ func generateOrderID() string {
 		randomStr[i] = letters[rand.Intn(len(letters))]
 	}
 
-	return fmt.Sprintf("ORD-%d-%s", timestamp, string(randomStr))
+	return fmt.Sprintf("%s-%d-%s", prefix, timestamp, string(randomStr))
 }
 
 
@@ -73,6 +138,8 @@ func NewOrder(sku, location, tenantID, sellerID string) *Order {
 		TenantID:  tenantID,
 		SellerID:  sellerID,
 		Status:    OrderStatusOnHold,
+		Quantity:  1,
+		Priority:  PriorityStandard,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -91,4 +158,21 @@ func (o *Order) IsValid() bool {
 	return o.ID != "" && o.SKU != "" && o.Location != "" && o.TenantID != "" && o.SellerID != ""
 }
 
+// DateLayout is the YYYY-MM-DD form accepted by order date filters
+const DateLayout = "2006-01-02"
 
+// ParseDateBound parses a date filter given as YYYY-MM-DD or RFC3339.
+// A bare date used as an upper bound covers the whole day.
+func ParseDateBound(value string, upper bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %s", value)
+	}
+	if upper {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDateBound(t *testing.T) {
+	from, err := ParseDateBound("2024-06-01", false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), from)
+
+	to, err := ParseDateBound("2024-06-01", true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 1, 23, 59, 59, 999999999, time.UTC), to)
+
+	exact, err := ParseDateBound("2024-06-01T10:30:00Z", true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC), exact)
+
+	_, err = ParseDateBound("01/06/2024", false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// piiMaskers mask JSON fields holding personal data, keyed by field name
+var piiMaskers = map[string]func(string) string{
+	"phone":          MaskPhone,
+	"customer_phone": MaskPhone,
+	"email":          MaskEmail,
+	"customer_email": MaskEmail,
+	"customer_name":  MaskText,
+	"line1":          MaskText,
+	"line2":          MaskText,
+	"postal_code":    MaskText,
+
+	"shipping_latitude":  MaskText,
+	"shipping_longitude": MaskText,
+}
+
+// MaskText keeps the first character of each word and hides the rest
+func MaskText(value string) string {
+	words := strings.Fields(value)
+	for i, w := range words {
+		r := []rune(w)
+		words[i] = string(r[0]) + strings.Repeat("*", len(r)-1)
+	}
+	return strings.Join(words, " ")
+}
+
+// MaskPhone hides every digit but the last four
+func MaskPhone(value string) string {
+	r := []rune(value)
+	digits := 0
+	for i := len(r) - 1; i >= 0; i-- {
+		if r[i] < '0' || r[i] > '9' {
+			continue
+		}
+		digits++
+		if digits > 4 {
+			r[i] = '*'
+		}
+	}
+	return string(r)
+}
+
+// MaskEmail keeps the first character of the local part and the domain
+func MaskEmail(value string) string {
+	at := strings.LastIndex(value, "@")
+	if at <= 0 {
+		return MaskText(value)
+	}
+	return value[:1] + strings.Repeat("*", at-1) + value[at:]
+}
+
+// MaskPII masks personal data in decoded JSON in place and returns it. Customer names are
+// masked under a "customer" object and coordinates dropped from addresses; other fields
+// are recognised by name anywhere, including inside strings holding JSON documents such
+// as stored webhook payloads.
+func MaskPII(v interface{}) interface{} {
+	return maskPII(v, "")
+}
+
+func maskPII(v interface{}, parent string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			if (key == "latitude" || key == "longitude") && strings.HasSuffix(parent, "_address") {
+				delete(t, key)
+				continue
+			}
+			if s, ok := value.(string); ok {
+				if mask, ok := piiMaskers[key]; ok {
+					t[key] = mask(s)
+					continue
+				} else if key == "name" && parent == "customer" {
+					t[key] = MaskText(s)
+					continue
+				}
+			}
+			t[key] = maskPII(value, key)
+		}
+	case []interface{}:
+		for i, value := range t {
+			t[i] = maskPII(value, parent)
+		}
+	case string:
+		return maskEmbeddedJSON(t, parent)
+	}
+	return v
+}
+
+// maskEmbeddedJSON masks s when it holds a JSON object or array, and returns it unchanged
+// otherwise
+func maskEmbeddedJSON(s, parent string) string {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return s
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		return s
+	}
+	masked, err := json.Marshal(maskPII(decoded, parent))
+	if err != nil {
+		return s
+	}
+	return string(masked)
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskers(t *testing.T) {
+	assert.Equal(t, "A*** R**", MaskText("Asha Rao"))
+	assert.Equal(t, "+** *****-*2345", MaskPhone("+91 98450-12345"))
+	assert.Equal(t, "a***@example.com", MaskEmail("asha@example.com"))
+	assert.Equal(t, "", MaskEmail(""))
+}
+
+func TestMaskPII(t *testing.T) {
+	body := []byte(`{"order":{"id":"ORD-1","sku":"SKU-1",
+		"customer":{"name":"Asha Rao","phone":"+919845012345","email":"asha@example.com"},
+		"shipping_address":{"line1":"12 MG Road","city":"Bengaluru","country":"IN","latitude":12.97,"longitude":77.59}},
+		"orders":[{"customer_name":"Ravi K"}]}`)
+
+	var decoded interface{}
+	assert.NoError(t, json.Unmarshal(body, &decoded))
+	masked, _ := json.Marshal(MaskPII(decoded))
+
+	assert.JSONEq(t, `{"order":{"id":"ORD-1","sku":"SKU-1",
+		"customer":{"name":"A*** R**","phone":"+********2345","email":"a***@example.com"},
+		"shipping_address":{"line1":"1* M* R***","city":"Bengaluru","country":"IN"}},
+		"orders":[{"customer_name":"R*** K"}]}`, string(masked))
+}
+
+func TestMaskPIIInsideJSONStrings(t *testing.T) {
+	body := []byte(`{"events":[{"event_type":"order.created","payload":"{\"order_id\":\"ORD-1\",\"customer\":{\"name\":\"Asha Rao\",\"email\":\"asha@example.com\"}}"}],
+		"note":"{not json"}`)
+
+	var decoded interface{}
+	assert.NoError(t, json.Unmarshal(body, &decoded))
+	masked := MaskPII(decoded).(map[string]interface{})
+
+	event := masked["events"].([]interface{})[0].(map[string]interface{})
+	assert.JSONEq(t, `{"order_id":"ORD-1","customer":{"name":"A*** R**","email":"a***@example.com"}}`, event["payload"].(string))
+	assert.Equal(t, "order.created", event["event_type"])
+	assert.Equal(t, "{not json", masked["note"])
+}
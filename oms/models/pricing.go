@@ -0,0 +1,153 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// moneyPlaces is the precision computed amounts are rounded to
+const moneyPlaces = 2
+
+var (
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+	hundred         = decimal.NewFromInt(100)
+)
+
+// Amount is an exact decimal value. It is a JSON string and a BSON Decimal128,
+// so prices never pass through floating point.
+type Amount struct {
+	decimal.Decimal
+}
+
+// NewAmount parses s as an exact decimal; an empty string is zero
+func NewAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Amount{}, nil
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return Amount{}, fmt.Errorf("%q is not a decimal number", s)
+	}
+	return Amount{d}, nil
+}
+
+func (a Amount) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	d, err := primitive.ParseDecimal128(a.String())
+	if err != nil {
+		return 0, nil, err
+	}
+	return bson.MarshalValue(d)
+}
+
+func (a *Amount) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	var s string
+	switch t {
+	case bsontype.Decimal128:
+		s = raw.Decimal128().String()
+	case bsontype.String:
+		s = raw.StringValue()
+	case bsontype.Int32, bsontype.Int64:
+		a.Decimal = decimal.NewFromInt(raw.AsInt64())
+		return nil
+	case bsontype.Double:
+		a.Decimal = decimal.NewFromFloat(raw.Double())
+		return nil
+	case bsontype.Null:
+		a.Decimal = decimal.Zero
+		return nil
+	default:
+		return fmt.Errorf("cannot decode %s into an amount", t)
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return err
+	}
+	a.Decimal = d
+	return nil
+}
+
+// OrderLine is one priced SKU of an order. Discount is an amount off the line and
+// TaxRate a percentage applied after the discount.
+type OrderLine struct {
+	SKU       string `json:"sku" bson:"sku"`
+	Quantity  int    `json:"quantity" bson:"quantity"`
+	UnitPrice Amount `json:"unit_price" bson:"unit_price"`
+	Discount  Amount `json:"discount" bson:"discount"`
+	TaxRate   Amount `json:"tax_rate" bson:"tax_rate"`
+	Subtotal  Amount `json:"subtotal" bson:"subtotal"`
+	Tax       Amount `json:"tax" bson:"tax"`
+	Total     Amount `json:"total" bson:"total"`
+}
+
+// OrderTotals sums the order's lines
+type OrderTotals struct {
+	Subtotal Amount `json:"subtotal" bson:"subtotal"`
+	Discount Amount `json:"discount" bson:"discount"`
+	Tax      Amount `json:"tax" bson:"tax"`
+	Total    Amount `json:"total" bson:"total"`
+}
+
+// Validate checks the line's inputs before totals are computed
+func (l *OrderLine) Validate() error {
+	if l.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+	if l.UnitPrice.IsNegative() {
+		return errors.New("unit_price cannot be negative")
+	}
+	if l.Discount.IsNegative() {
+		return errors.New("discount cannot be negative")
+	}
+	if l.Discount.GreaterThan(l.UnitPrice.Mul(decimal.NewFromInt(int64(l.Quantity)))) {
+		return errors.New("discount cannot exceed the line subtotal")
+	}
+	if l.TaxRate.IsNegative() || l.TaxRate.GreaterThan(hundred) {
+		return errors.New("tax_rate must be a percentage between 0 and 100")
+	}
+	return nil
+}
+
+// Compute fills the line's subtotal, tax and total
+func (l *OrderLine) Compute() {
+	subtotal := l.UnitPrice.Mul(decimal.NewFromInt(int64(l.Quantity)))
+	taxable := subtotal.Sub(l.Discount.Decimal)
+	tax := taxable.Mul(l.TaxRate.Decimal).Div(hundred).Round(moneyPlaces)
+
+	l.Subtotal = Amount{subtotal.Round(moneyPlaces)}
+	l.Tax = Amount{tax}
+	l.Total = Amount{taxable.Round(moneyPlaces).Add(tax)}
+}
+
+// PriceLines validates currency and lines, computes each line and sets the order totals
+func (o *Order) PriceLines(currency string, lines []OrderLine) error {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !currencyPattern.MatchString(currency) {
+		return errors.New("currency must be a three-letter ISO code")
+	}
+
+	totals := &OrderTotals{}
+	for i := range lines {
+		if err := lines[i].Validate(); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		lines[i].Compute()
+		totals.Subtotal.Decimal = totals.Subtotal.Add(lines[i].Subtotal.Decimal)
+		totals.Discount.Decimal = totals.Discount.Add(lines[i].Discount.Decimal)
+		totals.Tax.Decimal = totals.Tax.Add(lines[i].Tax.Decimal)
+		totals.Total.Decimal = totals.Total.Add(lines[i].Total.Decimal)
+	}
+
+	o.Currency = currency
+	o.Lines = lines
+	o.Totals = totals
+	return nil
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func amount(t *testing.T, s string) Amount {
+	a, err := NewAmount(s)
+	assert.NoError(t, err)
+	return a
+}
+
+func TestPriceLines(t *testing.T) {
+	var order Order
+	lines := []OrderLine{
+		{SKU: "SKU-1", Quantity: 3, UnitPrice: amount(t, "19.99"), Discount: amount(t, "5"), TaxRate: amount(t, "18")},
+		{SKU: "SKU-2", Quantity: 1, UnitPrice: amount(t, "0.10"), TaxRate: amount(t, "12.5")},
+	}
+	assert.NoError(t, order.PriceLines("inr", lines))
+
+	assert.Equal(t, "INR", order.Currency)
+	// 3 x 19.99 = 59.97, less 5 = 54.97, tax 18% = 9.8946 -> 9.89
+	assert.Equal(t, "59.97", order.Lines[0].Subtotal.StringFixed(2))
+	assert.Equal(t, "9.89", order.Lines[0].Tax.StringFixed(2))
+	assert.Equal(t, "64.86", order.Lines[0].Total.StringFixed(2))
+	// 0.10 + 12.5% = 0.0125 -> 0.01
+	assert.Equal(t, "0.11", order.Lines[1].Total.StringFixed(2))
+
+	assert.Equal(t, "60.07", order.Totals.Subtotal.StringFixed(2))
+	assert.Equal(t, "5.00", order.Totals.Discount.StringFixed(2))
+	assert.Equal(t, "9.90", order.Totals.Tax.StringFixed(2))
+	assert.Equal(t, "64.97", order.Totals.Total.StringFixed(2))
+}
+
+func TestPriceLinesRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		line     OrderLine
+	}{
+		{"bad currency", "RUPEES", OrderLine{Quantity: 1, UnitPrice: amount(t, "1")}},
+		{"zero quantity", "USD", OrderLine{Quantity: 0, UnitPrice: amount(t, "1")}},
+		{"negative price", "USD", OrderLine{Quantity: 1, UnitPrice: amount(t, "-1")}},
+		{"discount over subtotal", "USD", OrderLine{Quantity: 2, UnitPrice: amount(t, "1"), Discount: amount(t, "2.01")}},
+		{"tax over 100", "USD", OrderLine{Quantity: 1, UnitPrice: amount(t, "1"), TaxRate: amount(t, "101")}},
+	}
+	for _, tt := range tests {
+		var order Order
+		assert.Error(t, order.PriceLines(tt.currency, []OrderLine{tt.line}), tt.name)
+	}
+}
+
+func TestAmountEncoding(t *testing.T) {
+	in := OrderTotals{Total: amount(t, "1234.56")}
+
+	data, err := json.Marshal(in)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"total":"1234.56"`)
+
+	raw, err := bson.Marshal(in)
+	assert.NoError(t, err)
+	var out OrderTotals
+	assert.NoError(t, bson.Unmarshal(raw, &out))
+	assert.True(t, out.Total.Equal(in.Total.Decimal))
+
+	_, err = NewAmount("12,50")
+	assert.Error(t, err)
+}
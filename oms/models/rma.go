@@ -0,0 +1,213 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReturnStatus is where a return request is in the RMA workflow:
+// requested, then approved or rejected, and finally received at a hub
+type ReturnStatus string
+
+const (
+	ReturnRequested ReturnStatus = "requested"
+	ReturnApproved  ReturnStatus = "approved"
+	ReturnRejected  ReturnStatus = "rejected"
+	ReturnReceived  ReturnStatus = "received"
+)
+
+// returnTransitions lists the statuses each status may move to
+var returnTransitions = map[ReturnStatus][]ReturnStatus{
+	ReturnRequested: {ReturnApproved, ReturnRejected},
+	ReturnApproved:  {ReturnReceived, ReturnRejected},
+}
+
+// CanBecome reports whether a return in status s may move to next
+func (s ReturnStatus) CanBecome(next ReturnStatus) bool {
+	for _, allowed := range returnTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Disposition says whether received stock goes back on sale or into quarantine in IMS
+type Disposition string
+
+const (
+	DispositionSellable   Disposition = "sellable"
+	DispositionQuarantine Disposition = "quarantine"
+)
+
+func (d Disposition) IsValid() bool {
+	return d == DispositionSellable || d == DispositionQuarantine
+}
+
+// ReturnLine is a quantity of one SKU being returned. Disposition is set on receipt.
+type ReturnLine struct {
+	SKU         string      `json:"sku" bson:"sku"`
+	Quantity    int         `json:"quantity" bson:"quantity"`
+	Reason      string      `json:"reason" bson:"reason"`
+	Disposition Disposition `json:"disposition,omitempty" bson:"disposition,omitempty"`
+}
+
+// ReturnRequest is a customer return (RMA) against a delivered order.
+// Location is the hub the goods are received and restocked at.
+type ReturnRequest struct {
+	ID           string       `json:"id" bson:"return_id"`
+	OrderID      string       `json:"order_id" bson:"order_id"`
+	TenantID     string       `json:"tenant_id" bson:"tenant_id"`
+	SellerID     string       `json:"seller_id" bson:"seller_id"`
+	Location     string       `json:"location" bson:"location"`
+	Status       ReturnStatus `json:"status" bson:"status"`
+	Lines        []ReturnLine `json:"lines" bson:"lines"`
+	RejectReason string       `json:"reject_reason,omitempty" bson:"reject_reason,omitempty"`
+	CreatedAt    time.Time    `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at" bson:"updated_at"`
+	ApprovedAt   *time.Time   `json:"approved_at,omitempty" bson:"approved_at,omitempty"`
+	ReceivedAt   *time.Time   `json:"received_at,omitempty" bson:"received_at,omitempty"`
+}
+
+// Errors returned while requesting and progressing returns
+var (
+	ErrOrderNotReturnable = errors.New("only delivered orders can be returned")
+	ErrReturnTransition   = errors.New("return cannot move to that status")
+)
+
+// NewReturnRequest opens a return for lines of order. previous are the order's
+// earlier returns; together they may not return more than was ordered.
+func NewReturnRequest(order *Order, lines []ReturnLine, previous []ReturnRequest) (*ReturnRequest, error) {
+	if order.Status != OrderStatusDelivered {
+		return nil, fmt.Errorf("%w: order is %s", ErrOrderNotReturnable, order.Status)
+	}
+	if err := ValidateReturnLines(order, lines, previous); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &ReturnRequest{
+		ID:        generateID("RMA"),
+		OrderID:   order.ID,
+		TenantID:  order.TenantID,
+		SellerID:  order.SellerID,
+		Location:  order.Location,
+		Status:    ReturnRequested,
+		Lines:     lines,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// ReturnableQuantities is how many units of each SKU order shipped: its priced
+// lines when it has them, otherwise its single SKU
+func ReturnableQuantities(order *Order) map[string]int {
+	quantities := make(map[string]int)
+	for _, line := range order.Lines {
+		quantities[line.SKU] += line.Quantity
+	}
+	if len(quantities) == 0 {
+		quantities[order.SKU] = order.OrderedQuantity()
+	}
+	return quantities
+}
+
+// ReturnedQuantities is how many units of each SKU the returns that were not rejected take
+func ReturnedQuantities(returns []ReturnRequest) map[string]int {
+	quantities := make(map[string]int)
+	for _, ret := range returns {
+		if ret.Status == ReturnRejected {
+			continue
+		}
+		for _, line := range ret.Lines {
+			quantities[line.SKU] += line.Quantity
+		}
+	}
+	return quantities
+}
+
+var returnCounterEscaper = strings.NewReplacer("%", "%25", ".", "%2E", "$", "%24")
+
+// ReturnCounterKey is the Order.ReturnedQuantities key of sku, which Mongo field paths
+// can address even when the SKU holds dots or dollar signs
+func ReturnCounterKey(sku string) string {
+	return returnCounterEscaper.Replace(sku)
+}
+
+// ValidateReturnLines checks each line names a SKU of the order once, with a positive
+// quantity and a reason, and that no SKU is returned beyond what is left after the
+// order's earlier returns that were not rejected
+func ValidateReturnLines(order *Order, lines []ReturnLine, previous []ReturnRequest) error {
+	if len(lines) == 0 {
+		return errors.New("at least one return line is required")
+	}
+	remaining := ReturnableQuantities(order)
+	for sku, quantity := range ReturnedQuantities(previous) {
+		remaining[sku] -= quantity
+	}
+
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		if _, ok := remaining[line.SKU]; !ok {
+			return fmt.Errorf("line %d: sku %q is not part of order %s", i+1, line.SKU, order.ID)
+		}
+		if seen[line.SKU] {
+			return fmt.Errorf("line %d: sku %s is listed more than once", i+1, line.SKU)
+		}
+		seen[line.SKU] = true
+		if line.Quantity <= 0 {
+			return fmt.Errorf("line %d: quantity must be greater than zero", i+1)
+		}
+		if strings.TrimSpace(line.Reason) == "" {
+			return fmt.Errorf("line %d: reason is required", i+1)
+		}
+		if line.Quantity > remaining[line.SKU] {
+			return fmt.Errorf("line %d: only %d of sku %s can still be returned", i+1, remaining[line.SKU], line.SKU)
+		}
+	}
+	return nil
+}
+
+// Transition moves the return to next, stamping the matching time
+func (r *ReturnRequest) Transition(next ReturnStatus, now time.Time) error {
+	if !r.Status.CanBecome(next) {
+		return fmt.Errorf("%w: %s to %s", ErrReturnTransition, r.Status, next)
+	}
+	r.Status = next
+	r.UpdatedAt = now
+	switch next {
+	case ReturnApproved:
+		r.ApprovedAt = &now
+	case ReturnReceived:
+		r.ReceivedAt = &now
+	}
+	return nil
+}
+
+// AssignDispositions sets each line's disposition from bySKU, falling back to fallback
+func (r *ReturnRequest) AssignDispositions(fallback Disposition, bySKU map[string]Disposition) error {
+	for sku, d := range bySKU {
+		if !d.IsValid() {
+			return fmt.Errorf("invalid disposition %q for sku %s", d, sku)
+		}
+		found := false
+		for _, line := range r.Lines {
+			found = found || line.SKU == sku
+		}
+		if !found {
+			return fmt.Errorf("sku %s is not part of return %s", sku, r.ID)
+		}
+	}
+	for i := range r.Lines {
+		d, ok := bySKU[r.Lines[i].SKU]
+		if !ok {
+			d = fallback
+		}
+		if !d.IsValid() {
+			return fmt.Errorf("disposition must be sellable or quarantine")
+		}
+		r.Lines[i].Disposition = d
+	}
+	return nil
+}
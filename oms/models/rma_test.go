@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReturnRequest(t *testing.T) {
+	order := NewOrder("SKU-1", "hub-a", "tenant-1", "seller-1")
+	order.Quantity = 3
+
+	_, err := NewReturnRequest(order, []ReturnLine{{SKU: "SKU-1", Quantity: 1, Reason: "damaged"}}, nil)
+	assert.True(t, errors.Is(err, ErrOrderNotReturnable))
+
+	order.Status = OrderStatusDelivered
+	ret, err := NewReturnRequest(order, []ReturnLine{{SKU: "SKU-1", Quantity: 2, Reason: "damaged"}}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ret.ID, "RMA-"))
+	assert.Equal(t, ReturnRequested, ret.Status)
+	assert.Equal(t, order.ID, ret.OrderID)
+	assert.Equal(t, "hub-a", ret.Location)
+}
+
+func TestValidateReturnLines(t *testing.T) {
+	order := &Order{ID: "ORD-1", SKU: "SKU-1", Lines: []OrderLine{{SKU: "SKU-1", Quantity: 2}, {SKU: "SKU-2", Quantity: 1}}}
+	previous := []ReturnRequest{
+		{Status: ReturnReceived, Lines: []ReturnLine{{SKU: "SKU-1", Quantity: 1}}},
+		{Status: ReturnRejected, Lines: []ReturnLine{{SKU: "SKU-2", Quantity: 1}}},
+	}
+
+	tests := []struct {
+		name    string
+		lines   []ReturnLine
+		wantErr string
+	}{
+		{"within remaining", []ReturnLine{{SKU: "SKU-1", Quantity: 1, Reason: "wrong size"}, {SKU: "SKU-2", Quantity: 1, Reason: "damaged"}}, ""},
+		{"no lines", nil, "at least one"},
+		{"unknown sku", []ReturnLine{{SKU: "SKU-9", Quantity: 1, Reason: "damaged"}}, "not part of order"},
+		{"duplicate sku", []ReturnLine{{SKU: "SKU-2", Quantity: 1, Reason: "a"}, {SKU: "SKU-2", Quantity: 1, Reason: "b"}}, "more than once"},
+		{"zero quantity", []ReturnLine{{SKU: "SKU-1", Quantity: 0, Reason: "damaged"}}, "greater than zero"},
+		{"missing reason", []ReturnLine{{SKU: "SKU-1", Quantity: 1, Reason: " "}}, "reason is required"},
+		{"already returned", []ReturnLine{{SKU: "SKU-1", Quantity: 2, Reason: "damaged"}}, "only 1 of sku SKU-1"},
+	}
+	for _, tt := range tests {
+		err := ValidateReturnLines(order, tt.lines, previous)
+		if tt.wantErr == "" {
+			assert.NoError(t, err, tt.name)
+		} else if assert.Error(t, err, tt.name) {
+			assert.Contains(t, err.Error(), tt.wantErr, tt.name)
+		}
+	}
+}
+
+func TestReturnableQuantitiesWithoutLines(t *testing.T) {
+	assert.Equal(t, map[string]int{"SKU-1": 1}, ReturnableQuantities(&Order{SKU: "SKU-1"}))
+}
+
+func TestReturnedQuantities(t *testing.T) {
+	previous := []ReturnRequest{
+		{Status: ReturnReceived, Lines: []ReturnLine{{SKU: "SKU-1", Quantity: 1}}},
+		{Status: ReturnRequested, Lines: []ReturnLine{{SKU: "SKU-1", Quantity: 2}, {SKU: "SKU-2", Quantity: 1}}},
+		{Status: ReturnRejected, Lines: []ReturnLine{{SKU: "SKU-2", Quantity: 4}}},
+	}
+	assert.Equal(t, map[string]int{"SKU-1": 3, "SKU-2": 1}, ReturnedQuantities(previous))
+}
+
+func TestReturnCounterKey(t *testing.T) {
+	assert.Equal(t, "SKU-1", ReturnCounterKey("SKU-1"))
+	assert.Equal(t, "A%2EB%24C%25D", ReturnCounterKey("A.B$C%D"))
+}
+
+func TestReturnTransition(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	ret := &ReturnRequest{Status: ReturnRequested}
+
+	assert.True(t, errors.Is(ret.Transition(ReturnReceived, now), ErrReturnTransition))
+	assert.NoError(t, ret.Transition(ReturnApproved, now))
+	assert.Equal(t, &now, ret.ApprovedAt)
+	assert.NoError(t, ret.Transition(ReturnReceived, now))
+	assert.Equal(t, &now, ret.ReceivedAt)
+	assert.True(t, errors.Is(ret.Transition(ReturnRejected, now), ErrReturnTransition))
+}
+
+func TestAssignDispositions(t *testing.T) {
+	ret := &ReturnRequest{ID: "RMA-1", Lines: []ReturnLine{{SKU: "SKU-1"}, {SKU: "SKU-2"}}}
+
+	assert.NoError(t, ret.AssignDispositions(DispositionSellable, map[string]Disposition{"SKU-2": DispositionQuarantine}))
+	assert.Equal(t, DispositionSellable, ret.Lines[0].Disposition)
+	assert.Equal(t, DispositionQuarantine, ret.Lines[1].Disposition)
+
+	assert.Error(t, ret.AssignDispositions(DispositionSellable, map[string]Disposition{"SKU-9": DispositionSellable}))
+	assert.Error(t, ret.AssignDispositions(DispositionSellable, map[string]Disposition{"SKU-1": "scrap"}))
+	assert.Error(t, ret.AssignDispositions("", nil))
+}
@@ -0,0 +1,101 @@
+package models
+
+import "strings"
+
+// SearchMode reports how a search query was matched
+type SearchMode string
+
+const (
+	SearchModeText     SearchMode = "text"
+	SearchModeFragment SearchMode = "fragment"
+)
+
+// SearchableFields are the order fields covered by search and highlighting
+var SearchableFields = []string{"order_id", "sku", "seller_id", "location", "tenant_id"}
+
+// FacetCount is one bucket of a search facet
+type FacetCount struct {
+	Value string `json:"value" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// OrderHit is a matched order with its relevance and highlighted fields
+type OrderHit struct {
+	Order      Order             `json:"order"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// OrderSearchResult is one page of hits plus facet counts over every match
+type OrderSearchResult struct {
+	Mode   SearchMode              `json:"mode"`
+	Hits   []OrderHit              `json:"hits"`
+	Total  int64                   `json:"total"`
+	Facets map[string][]FacetCount `json:"facets"`
+}
+
+// SearchTerms splits a query into lower-cased terms for highlighting
+func SearchTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// Highlight wraps every case-insensitive occurrence of terms in value with <em> tags.
+// It reports false when nothing matched.
+func Highlight(value string, terms []string) (string, bool) {
+	lower := strings.ToLower(value)
+	if len(lower) != len(value) {
+		lower = value
+	}
+
+	marked := make([]bool, len(value))
+	found := false
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		for from := 0; ; {
+			i := strings.Index(lower[from:], term)
+			if i < 0 {
+				break
+			}
+			for j := from + i; j < from+i+len(term); j++ {
+				marked[j] = true
+			}
+			found = true
+			from += i + len(term)
+		}
+	}
+	if !found {
+		return value, false
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString("<em>")
+		}
+		b.WriteByte(value[i])
+		if marked[i] && (i == len(value)-1 || !marked[i+1]) {
+			b.WriteString("</em>")
+		}
+	}
+	return b.String(), true
+}
+
+// HighlightOrder returns the highlighted searchable fields of order that match terms
+func HighlightOrder(order Order, terms []string) map[string]string {
+	values := map[string]string{
+		"order_id":  order.ID,
+		"sku":       order.SKU,
+		"seller_id": order.SellerID,
+		"location":  order.Location,
+		"tenant_id": order.TenantID,
+	}
+	highlights := make(map[string]string)
+	for _, field := range SearchableFields {
+		if h, ok := Highlight(values[field], terms); ok {
+			highlights[field] = h
+		}
+	}
+	return highlights
+}
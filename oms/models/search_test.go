@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		value string
+		terms []string
+		want  string
+		found bool
+	}{
+		{"ORD-123-ABC", []string{"abc"}, "ORD-123-<em>ABC</em>", true},
+		{"ORD-123-ABC", []string{"123", "-abc"}, "ORD-<em>123-ABC</em>", true},
+		{"sku-1-sku", []string{"sku"}, "<em>sku</em>-1-<em>sku</em>", true},
+		{"HUB001", []string{"xyz"}, "HUB001", false},
+	}
+	for _, tt := range tests {
+		got, found := Highlight(tt.value, tt.terms)
+		assert.Equal(t, tt.want, got, tt.value)
+		assert.Equal(t, tt.found, found, tt.value)
+	}
+}
+
+func TestHighlightOrder(t *testing.T) {
+	order := Order{ID: "ORD-1-HUB", SKU: "sku-9", Location: "HUB001", TenantID: "t1", SellerID: "s1"}
+	highlights := HighlightOrder(order, SearchTerms("Hub"))
+	assert.Equal(t, map[string]string{
+		"order_id": "ORD-1-<em>HUB</em>",
+		"location": "<em>HUB</em>001",
+	}, highlights)
+}
@@ -0,0 +1,251 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ShipmentStatus is a shipment's carrier status
+type ShipmentStatus string
+
+const (
+	ShipmentLabelCreated   ShipmentStatus = "label_created"
+	ShipmentInTransit      ShipmentStatus = "in_transit"
+	ShipmentOutForDelivery ShipmentStatus = "out_for_delivery"
+	ShipmentDelivered      ShipmentStatus = "delivered"
+	// ShipmentException is a carrier problem (failed delivery, damage, lost parcel)
+	ShipmentException ShipmentStatus = "exception"
+)
+
+func (s ShipmentStatus) IsValid() bool {
+	switch s {
+	case ShipmentLabelCreated, ShipmentInTransit, ShipmentOutForDelivery, ShipmentDelivered, ShipmentException:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsFinal reports whether the carrier will not update a shipment in this status again
+func (s ShipmentStatus) IsFinal() bool {
+	return s == ShipmentDelivered
+}
+
+// RateQuote is a carrier's price for one service level
+type RateQuote struct {
+	Carrier       string `json:"carrier" bson:"carrier"`
+	Service       string `json:"service" bson:"service"`
+	Amount        Amount `json:"amount" bson:"amount"`
+	Currency      string `json:"currency" bson:"currency"`
+	EstimatedDays int    `json:"estimated_days" bson:"estimated_days"`
+}
+
+// TrackingEvent is one step reported by the carrier
+type TrackingEvent struct {
+	Status      ShipmentStatus `json:"status" bson:"status"`
+	Description string         `json:"description" bson:"description"`
+	Location    string         `json:"location,omitempty" bson:"location,omitempty"`
+	At          time.Time      `json:"at" bson:"at"`
+}
+
+// Shipment is an order's parcel booked with a carrier, from the parcel's hub to the
+// order's shipping address
+type Shipment struct {
+	ID             string          `json:"id" bson:"shipment_id"`
+	OrderID        string          `json:"order_id" bson:"order_id"`
+	SubShipmentID  string          `json:"sub_shipment_id,omitempty" bson:"sub_shipment_id,omitempty"`
+	TenantID       string          `json:"tenant_id" bson:"tenant_id"`
+	SellerID       string          `json:"seller_id" bson:"seller_id"`
+	Carrier        string          `json:"carrier" bson:"carrier"`
+	Rate           RateQuote       `json:"rate" bson:"rate"`
+	TrackingNumber string          `json:"tracking_number" bson:"tracking_number"`
+	LabelURL       string          `json:"label_url" bson:"label_url"`
+	Origin         string          `json:"origin" bson:"origin"`
+	Destination    *Address        `json:"destination,omitempty" bson:"destination,omitempty"`
+	Status         ShipmentStatus  `json:"status" bson:"status"`
+	Events         []TrackingEvent `json:"events" bson:"events"`
+	CreatedAt      time.Time       `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" bson:"updated_at"`
+	LastPolledAt   time.Time       `json:"last_polled_at" bson:"last_polled_at"`
+	ShippedAt      *time.Time      `json:"shipped_at,omitempty" bson:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+	// ActiveParcel holds the parcel's key until the shipment hits an exception; it is
+	// unique, so a parcel is never booked twice at once
+	ActiveParcel string `json:"-" bson:"active_parcel,omitempty"`
+}
+
+// Parcel is what one shipment carries: the whole order from its hub, or one sub-shipment
+// of a split order from the sub-shipment's hub
+type Parcel struct {
+	SubShipmentID string
+	Origin        string
+	Quantity      int
+}
+
+// Key identifies the parcel: the sub-shipment's ID, or the order's for a whole order
+func (p Parcel) Key(orderID string) string {
+	if p.SubShipmentID != "" {
+		return p.SubShipmentID
+	}
+	return orderID
+}
+
+// ParcelKey is the key of the parcel the shipment carries
+func (s *Shipment) ParcelKey() string {
+	return Parcel{SubShipmentID: s.SubShipmentID}.Key(s.OrderID)
+}
+
+// Parcels lists the order's parcels whose stock was reserved: the order itself, or each
+// sub-shipment of a split order that reached new_order
+func (o *Order) Parcels() []Parcel {
+	if len(o.SubShipments) == 0 {
+		return []Parcel{{Origin: o.Location, Quantity: o.OrderedQuantity()}}
+	}
+	var parcels []Parcel
+	for _, s := range o.SubShipments {
+		if s.Status == OrderStatusNewOrder {
+			parcels = append(parcels, Parcel{SubShipmentID: s.ID, Origin: s.Location, Quantity: s.Quantity})
+		}
+	}
+	return parcels
+}
+
+// ErrOrderNotShippable is returned when a shipment is booked for an order that is not ready to ship
+var ErrOrderNotShippable = errors.New("order is not ready to ship")
+
+// CheckShippable returns ErrOrderNotShippable unless the order's stock was reserved
+// (new_order, or partially_fulfilled for the part that was)
+func (o *Order) CheckShippable() error {
+	if o.Status != OrderStatusNewOrder && o.Status != OrderStatusPartiallyFulfilled {
+		return fmt.Errorf("%w: order is %s", ErrOrderNotShippable, o.Status)
+	}
+	return nil
+}
+
+// ParcelStatus is how far all of the order's parcels got: the least advanced status among
+// their active shipments, and false while some parcel has no active shipment yet.
+// shipments are the order's shipments, oldest first.
+func (o *Order) ParcelStatus(shipments []Shipment) (ShipmentStatus, bool) {
+	active := make(map[string]ShipmentStatus)
+	for _, s := range shipments {
+		if s.Status != ShipmentException {
+			active[s.ParcelKey()] = s.Status
+		}
+	}
+	parcels := o.Parcels()
+	if len(parcels) == 0 {
+		return "", false
+	}
+	var least ShipmentStatus
+	for i, p := range parcels {
+		status, ok := active[p.Key(o.ID)]
+		if !ok {
+			return "", false
+		}
+		if i == 0 || shipmentStep(status) < shipmentStep(least) {
+			least = status
+		}
+	}
+	return least, true
+}
+
+// NewShipment books a shipment for one of order's parcels with the carrier's chosen rate and label
+func NewShipment(order *Order, parcel Parcel, rate RateQuote, trackingNumber, labelURL string) (*Shipment, error) {
+	if err := order.CheckShippable(); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &Shipment{
+		ID:             generateID("SHP"),
+		OrderID:        order.ID,
+		SubShipmentID:  parcel.SubShipmentID,
+		TenantID:       order.TenantID,
+		SellerID:       order.SellerID,
+		Carrier:        rate.Carrier,
+		Rate:           rate,
+		TrackingNumber: trackingNumber,
+		LabelURL:       labelURL,
+		Origin:         parcel.Origin,
+		Destination:    order.ShippingAddress,
+		Status:         ShipmentLabelCreated,
+		Events:         []TrackingEvent{{Status: ShipmentLabelCreated, Description: "Label created", Location: parcel.Origin, At: now}},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		LastPolledAt:   now,
+		ActiveParcel:   parcel.Key(order.ID),
+	}, nil
+}
+
+// CanBecome reports whether a shipment in status s may move to next. Shipments only move
+// forward along the delivery; an exception can happen at any step before delivery and
+// the shipment picks up again from there.
+func (s ShipmentStatus) CanBecome(next ShipmentStatus) bool {
+	switch {
+	case s == next || s.IsFinal() || !next.IsValid():
+		return false
+	case next == ShipmentException:
+		return true
+	case s == ShipmentException:
+		return next != ShipmentLabelCreated
+	default:
+		return shipmentStep(next) > shipmentStep(s)
+	}
+}
+
+// ApplyTracking records the carrier's current status and the events not seen yet,
+// and reports whether the status changed
+func (s *Shipment) ApplyTracking(status ShipmentStatus, events []TrackingEvent, now time.Time) bool {
+	s.LastPolledAt = now
+	for _, e := range events {
+		if len(s.Events) == 0 || e.At.After(s.Events[len(s.Events)-1].At) {
+			s.Events = append(s.Events, e)
+		}
+	}
+	if !s.Status.CanBecome(status) {
+		return false
+	}
+	s.Status = status
+	s.UpdatedAt = now
+	if status == ShipmentException {
+		s.ActiveParcel = ""
+	}
+	if s.ShippedAt == nil && shipmentStep(status) > shipmentStep(ShipmentLabelCreated) {
+		s.ShippedAt = &now
+	}
+	if status == ShipmentDelivered {
+		s.DeliveredAt = &now
+	}
+	return true
+}
+
+// shipmentStep orders statuses along the delivery
+func shipmentStep(s ShipmentStatus) int {
+	switch s {
+	case ShipmentInTransit:
+		return 1
+	case ShipmentOutForDelivery:
+		return 2
+	case ShipmentDelivered:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// OrderStatusAfter is the status an order in current moves to when its shipment reaches
+// status, and false when the order does not change. Orders only move forward:
+// new_order (or partially_fulfilled) to shipped to delivered.
+func OrderStatusAfter(current OrderStatus, status ShipmentStatus) (OrderStatus, bool) {
+	switch status {
+	case ShipmentInTransit, ShipmentOutForDelivery:
+		if current == OrderStatusNewOrder || current == OrderStatusPartiallyFulfilled {
+			return OrderStatusShipped, true
+		}
+	case ShipmentDelivered:
+		if current == OrderStatusNewOrder || current == OrderStatusPartiallyFulfilled || current == OrderStatusShipped {
+			return OrderStatusDelivered, true
+		}
+	}
+	return current, false
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewShipmentRequiresReservedStock(t *testing.T) {
+	order := NewOrder("SKU-1", "hub-a", "tenant-1", "seller-1")
+	_, err := NewShipment(order, order.Parcels()[0], RateQuote{Carrier: "fake"}, "T-1", "")
+	assert.True(t, errors.Is(err, ErrOrderNotShippable))
+
+	order.Status = OrderStatusNewOrder
+	shipment, err := NewShipment(order, order.Parcels()[0], RateQuote{Carrier: "fake", Service: "standard"}, "T-1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, ShipmentLabelCreated, shipment.Status)
+	assert.Equal(t, "fake", shipment.Carrier)
+	assert.Equal(t, "hub-a", shipment.Origin)
+	assert.Equal(t, order.ID, shipment.ActiveParcel)
+	assert.Len(t, shipment.Events, 1)
+}
+
+func TestOrderParcels(t *testing.T) {
+	order := NewOrder("SKU-1", "hub-a", "tenant-1", "seller-1")
+	order.SubShipments = []SubShipment{
+		{ID: order.ID + "-S1", Location: "hub-b", Quantity: 2, Status: OrderStatusNewOrder},
+		{ID: order.ID + "-S2", Location: "hub-c", Quantity: 1, Status: OrderStatusOnHold},
+	}
+	order.Status = OrderStatusPartiallyFulfilled
+
+	parcels := order.Parcels()
+	assert.Equal(t, []Parcel{{SubShipmentID: order.ID + "-S1", Origin: "hub-b", Quantity: 2}}, parcels)
+
+	shipment, err := NewShipment(order, parcels[0], RateQuote{Carrier: "fake"}, "T-1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "hub-b", shipment.Origin)
+	assert.Equal(t, order.ID+"-S1", shipment.ParcelKey())
+	assert.Equal(t, order.ID+"-S1", shipment.ActiveParcel)
+
+	assert.True(t, shipment.ApplyTracking(ShipmentException, nil, time.Now()))
+	assert.Empty(t, shipment.ActiveParcel)
+}
+
+func TestShipmentStatusCanBecome(t *testing.T) {
+	tests := []struct {
+		from, to ShipmentStatus
+		want     bool
+	}{
+		{ShipmentLabelCreated, ShipmentInTransit, true},
+		{ShipmentLabelCreated, ShipmentDelivered, true},
+		{ShipmentOutForDelivery, ShipmentInTransit, false},
+		{ShipmentOutForDelivery, ShipmentException, true},
+		{ShipmentException, ShipmentOutForDelivery, true},
+		{ShipmentException, ShipmentLabelCreated, false},
+		{ShipmentDelivered, ShipmentException, false},
+		{ShipmentInTransit, ShipmentInTransit, false},
+		{ShipmentInTransit, "lost", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.from.CanBecome(tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
+
+func TestShipmentApplyTracking(t *testing.T) {
+	labelled := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	now := labelled.Add(3 * time.Hour)
+	shipment := &Shipment{Status: ShipmentLabelCreated, Events: []TrackingEvent{{Status: ShipmentLabelCreated, At: labelled}}}
+	events := []TrackingEvent{
+		{Status: ShipmentLabelCreated, At: labelled},
+		{Status: ShipmentInTransit, At: labelled.Add(time.Hour)},
+		{Status: ShipmentDelivered, At: labelled.Add(2 * time.Hour)},
+	}
+
+	assert.True(t, shipment.ApplyTracking(ShipmentDelivered, events, now))
+	assert.Equal(t, ShipmentDelivered, shipment.Status)
+	assert.Len(t, shipment.Events, 3)
+	assert.Equal(t, &now, shipment.ShippedAt)
+	assert.Equal(t, &now, shipment.DeliveredAt)
+
+	assert.False(t, shipment.ApplyTracking(ShipmentInTransit, events, now.Add(time.Hour)))
+	assert.Equal(t, ShipmentDelivered, shipment.Status)
+	assert.Len(t, shipment.Events, 3)
+}
+
+func TestOrderStatusAfter(t *testing.T) {
+	tests := []struct {
+		current OrderStatus
+		status  ShipmentStatus
+		want    OrderStatus
+		changed bool
+	}{
+		{OrderStatusNewOrder, ShipmentLabelCreated, OrderStatusNewOrder, false},
+		{OrderStatusNewOrder, ShipmentInTransit, OrderStatusShipped, true},
+		{OrderStatusPartiallyFulfilled, ShipmentOutForDelivery, OrderStatusShipped, true},
+		{OrderStatusShipped, ShipmentOutForDelivery, OrderStatusShipped, false},
+		{OrderStatusShipped, ShipmentDelivered, OrderStatusDelivered, true},
+		{OrderStatusNewOrder, ShipmentDelivered, OrderStatusDelivered, true},
+		{OrderStatusShipped, ShipmentException, OrderStatusShipped, false},
+		{OrderStatusCancelled, ShipmentDelivered, OrderStatusCancelled, false},
+	}
+	for _, tt := range tests {
+		got, changed := OrderStatusAfter(tt.current, tt.status)
+		assert.Equal(t, tt.want, got, "%s on %s", tt.current, tt.status)
+		assert.Equal(t, tt.changed, changed, "%s on %s", tt.current, tt.status)
+	}
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OrderPriority decides how soon an order must be dispatched and who gets scarce stock first
+type OrderPriority string
+
+const (
+	PriorityStandard OrderPriority = "standard"
+	PriorityExpress  OrderPriority = "express"
+)
+
+func (p OrderPriority) IsValid() bool {
+	return p == PriorityStandard || p == PriorityExpress
+}
+
+// ParsePriority reads a priority column; blank means standard
+func ParsePriority(value string) (OrderPriority, error) {
+	p := OrderPriority(strings.ToLower(strings.TrimSpace(value)))
+	if p == "" {
+		return PriorityStandard, nil
+	}
+	if !p.IsValid() {
+		return "", fmt.Errorf("priority must be standard or express")
+	}
+	return p, nil
+}
+
+// IsOpen reports whether an order in this status still has to be dispatched
+func (s OrderStatus) IsOpen() bool {
+	return s == OrderStatusOnHold || s == OrderStatusBackordered || s == OrderStatusNewOrder
+}
+
+// SLA states of an open order
+const (
+	SLAOnTrack  = "on_track"
+	SLAAtRisk   = "at_risk"
+	SLABreached = "breached"
+)
+
+// SLAPolicy holds a tenant's dispatch promise per priority. An order placed by the
+// cut-off (HH:MM in Timezone) counts from that day, a later one from the next day,
+// and must be dispatched by the end of the day the given number of days later.
+type SLAPolicy struct {
+	TenantID       string    `json:"tenant_id" bson:"tenant_id"`
+	Timezone       string    `json:"timezone" bson:"timezone"`
+	StandardCutoff string    `json:"standard_cutoff" bson:"standard_cutoff"`
+	StandardDays   int       `json:"standard_days" bson:"standard_days"`
+	ExpressCutoff  string    `json:"express_cutoff" bson:"express_cutoff"`
+	ExpressDays    int       `json:"express_days" bson:"express_days"`
+	AtRiskHours    int       `json:"at_risk_hours" bson:"at_risk_hours"`
+	UpdatedAt      time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// DefaultSLAPolicy applies to tenants that have not configured one
+func DefaultSLAPolicy(tenantID string) SLAPolicy {
+	return SLAPolicy{
+		TenantID:       tenantID,
+		Timezone:       "UTC",
+		StandardCutoff: "14:00",
+		StandardDays:   2,
+		ExpressCutoff:  "16:00",
+		ExpressDays:    0,
+		AtRiskHours:    4,
+	}
+}
+
+// Validate checks the timezone, cut-offs and day counts
+func (p *SLAPolicy) Validate() error {
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %s", p.Timezone)
+	}
+	for _, cutoff := range []string{p.StandardCutoff, p.ExpressCutoff} {
+		if _, err := time.Parse("15:04", cutoff); err != nil {
+			return fmt.Errorf("invalid cut-off %q, use HH:MM", cutoff)
+		}
+	}
+	if p.StandardDays < 0 || p.ExpressDays < 0 || p.AtRiskHours < 0 {
+		return errors.New("days and at_risk_hours cannot be negative")
+	}
+	return nil
+}
+
+// Deadline is when an order of priority placed at placedAt must be dispatched
+func (p *SLAPolicy) Deadline(priority OrderPriority, placedAt time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timezone: %s", p.Timezone)
+	}
+	cutoff, days := p.StandardCutoff, p.StandardDays
+	if priority == PriorityExpress {
+		cutoff, days = p.ExpressCutoff, p.ExpressDays
+	}
+	at, err := time.Parse("15:04", cutoff)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cut-off %q, use HH:MM", cutoff)
+	}
+
+	local := placedAt.In(loc)
+	y, m, d := local.Date()
+	if local.After(time.Date(y, m, d, at.Hour(), at.Minute(), 0, 0, loc)) {
+		days++
+	}
+	endOfDay := time.Date(y, m, d+days+1, 0, 0, 0, 0, loc).Add(-time.Second)
+	return endOfDay.UTC(), nil
+}
+
+// AtRiskFrom is when an order with the given deadline starts being at risk
+func (p *SLAPolicy) AtRiskFrom(deadline time.Time) time.Time {
+	return deadline.Add(-time.Duration(p.AtRiskHours) * time.Hour)
+}
+
+// ApplySLA sets the order's deadline and at-risk time from policy
+func (o *Order) ApplySLA(policy SLAPolicy) error {
+	deadline, err := policy.Deadline(o.Priority, o.CreatedAt)
+	if err != nil {
+		return err
+	}
+	atRisk := policy.AtRiskFrom(deadline)
+	o.SLADeadline, o.SLAAtRiskAt = &deadline, &atRisk
+	return nil
+}
+
+// SLAState is the order's SLA state at now; orders without a deadline or no
+// longer open are on track
+func (o *Order) SLAState(now time.Time) string {
+	if o.SLADeadline == nil || !o.Status.IsOpen() {
+		return SLAOnTrack
+	}
+	if now.After(*o.SLADeadline) {
+		return SLABreached
+	}
+	if o.SLAAtRiskAt != nil && !now.Before(*o.SLAAtRiskAt) {
+		return SLAAtRisk
+	}
+	return SLAOnTrack
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePriority(t *testing.T) {
+	p, err := ParsePriority("")
+	assert.NoError(t, err)
+	assert.Equal(t, PriorityStandard, p)
+
+	p, err = ParsePriority(" Express ")
+	assert.NoError(t, err)
+	assert.Equal(t, PriorityExpress, p)
+
+	_, err = ParsePriority("overnight")
+	assert.Error(t, err)
+}
+
+func TestSLAPolicyValidate(t *testing.T) {
+	policy := DefaultSLAPolicy("tenant-1")
+	assert.NoError(t, policy.Validate())
+
+	badZone := policy
+	badZone.Timezone = "Mars/Olympus"
+	assert.Error(t, badZone.Validate())
+
+	badCutoff := policy
+	badCutoff.ExpressCutoff = "4pm"
+	assert.Error(t, badCutoff.Validate())
+
+	negative := policy
+	negative.StandardDays = -1
+	assert.Error(t, negative.Validate())
+}
+
+func TestSLAPolicyDeadline(t *testing.T) {
+	policy := SLAPolicy{Timezone: "Asia/Kolkata", StandardCutoff: "14:00", StandardDays: 2, ExpressCutoff: "16:00", ExpressDays: 0}
+	ist := time.FixedZone("IST", 5*3600+1800)
+
+	tests := []struct {
+		name     string
+		priority OrderPriority
+		placedAt time.Time
+		want     time.Time
+	}{
+		{"standard before cut-off", PriorityStandard, time.Date(2024, 7, 1, 13, 59, 0, 0, ist), time.Date(2024, 7, 3, 23, 59, 59, 0, ist)},
+		{"standard after cut-off", PriorityStandard, time.Date(2024, 7, 1, 14, 1, 0, 0, ist), time.Date(2024, 7, 4, 23, 59, 59, 0, ist)},
+		{"express same day", PriorityExpress, time.Date(2024, 7, 1, 15, 0, 0, 0, ist), time.Date(2024, 7, 1, 23, 59, 59, 0, ist)},
+		{"express after cut-off", PriorityExpress, time.Date(2024, 7, 1, 17, 0, 0, 0, ist), time.Date(2024, 7, 2, 23, 59, 59, 0, ist)},
+		{"month end", PriorityStandard, time.Date(2024, 7, 31, 9, 0, 0, 0, ist), time.Date(2024, 8, 2, 23, 59, 59, 0, ist)},
+	}
+	for _, tt := range tests {
+		got, err := policy.Deadline(tt.priority, tt.placedAt.UTC())
+		assert.NoError(t, err, tt.name)
+		assert.True(t, tt.want.Equal(got), "%s: got %s", tt.name, got)
+	}
+}
+
+func TestOrderSLAState(t *testing.T) {
+	policy := DefaultSLAPolicy("tenant-1")
+	order := Order{Status: OrderStatusOnHold, Priority: PriorityExpress, CreatedAt: time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)}
+	assert.NoError(t, order.ApplySLA(policy))
+	assert.Equal(t, time.Date(2024, 7, 1, 23, 59, 59, 0, time.UTC), *order.SLADeadline)
+
+	assert.Equal(t, SLAOnTrack, order.SLAState(time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, SLAAtRisk, order.SLAState(time.Date(2024, 7, 1, 20, 0, 0, 0, time.UTC)))
+	assert.Equal(t, SLABreached, order.SLAState(time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)))
+
+	order.Status = OrderStatusCancelled
+	assert.Equal(t, SLAOnTrack, order.SLAState(time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SubShipment is the part of a split order fulfilled from one hub.
+// Each one reduces stock in IMS on its own and carries its own status.
+type SubShipment struct {
+	ID        string      `json:"id" bson:"id"`
+	Location  string      `json:"location" bson:"location"`
+	Quantity  int         `json:"quantity" bson:"quantity"`
+	Status    OrderStatus `json:"status" bson:"status"`
+	Reason    string      `json:"reason,omitempty" bson:"reason,omitempty"`
+	UpdatedAt time.Time   `json:"updated_at" bson:"updated_at"`
+}
+
+// ErrInsufficientStock is returned when all hubs together hold less than the order needs
+var ErrInsufficientStock = errors.New("not enough stock across hubs")
+
+// PlanSplit divides quantity units of an order across candidate hubs, taking as much as
+// possible from each hub in the order the rule prefers them. Every sub-shipment starts on hold.
+func PlanSplit(orderID string, rule AllocationRule, candidates []HubCandidate, quantity int, destination *Address) ([]SubShipment, error) {
+	ranked := rankForSplit(rule, withDistances(candidates, destination))
+
+	var shipments []SubShipment
+	remaining := quantity
+	now := time.Now()
+	for _, c := range ranked {
+		if remaining == 0 {
+			break
+		}
+		if c.Stock <= 0 {
+			continue
+		}
+		take := c.Stock
+		if take > remaining {
+			take = remaining
+		}
+		shipments = append(shipments, SubShipment{
+			ID:        fmt.Sprintf("%s-S%d", orderID, len(shipments)+1),
+			Location:  c.Hub,
+			Quantity:  take,
+			Status:    OrderStatusOnHold,
+			UpdatedAt: now,
+		})
+		remaining -= take
+	}
+	if remaining > 0 {
+		return nil, fmt.Errorf("%w: short by %d of %d", ErrInsufficientStock, remaining, quantity)
+	}
+	return shipments, nil
+}
+
+// rankForSplit orders candidates by the rule's preference: listed priority hubs first,
+// or nearest first, then by most stock. candidates must already be sorted by name.
+func rankForSplit(rule AllocationRule, candidates []HubCandidate) []HubCandidate {
+	rank := make(map[string]int)
+	for i, hub := range rule.HubPriority {
+		rank[hub] = i + 1
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch rule.Strategy {
+		case AllocationPriority:
+			ra, rb := rank[a.Hub], rank[b.Hub]
+			if ra != rb {
+				return ra != 0 && (rb == 0 || ra < rb)
+			}
+		case AllocationNearest:
+			if (a.DistanceKm == nil) != (b.DistanceKm == nil) {
+				return a.DistanceKm != nil
+			}
+			if a.DistanceKm != nil && *a.DistanceKm != *b.DistanceKm {
+				return *a.DistanceKm < *b.DistanceKm
+			}
+		}
+		return a.Stock > b.Stock
+	})
+	return candidates
+}
+
+// DeriveStatus is a split order's status given its sub-shipments: the shared status
+// when they all agree, on hold while any is still waiting, and partially fulfilled
+// when some were fulfilled and the rest cancelled.
+func DeriveStatus(shipments []SubShipment) OrderStatus {
+	if len(shipments) == 0 {
+		return OrderStatusOnHold
+	}
+	status := shipments[0].Status
+	for _, s := range shipments[1:] {
+		if s.Status != status {
+			status = ""
+			break
+		}
+	}
+	if status != "" {
+		return status
+	}
+	for _, s := range shipments {
+		if s.Status == OrderStatusOnHold {
+			return OrderStatusOnHold
+		}
+	}
+	return OrderStatusPartiallyFulfilled
+}
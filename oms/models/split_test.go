@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSplit(t *testing.T) {
+	candidates := []HubCandidate{
+		{Hub: "delhi", Stock: 4, Latitude: coord(28.6139), Longitude: coord(77.2090)},
+		{Hub: "mumbai", Stock: 3, Latitude: coord(19.0760), Longitude: coord(72.8777)},
+		{Hub: "bengaluru", Stock: 2, Latitude: coord(12.9716), Longitude: coord(77.5946)},
+		{Hub: "empty", Stock: 0},
+	}
+	pune := &Address{Latitude: coord(18.5204), Longitude: coord(73.8567)}
+
+	type part struct {
+		hub      string
+		quantity int
+	}
+	tests := []struct {
+		name        string
+		rule        AllocationRule
+		quantity    int
+		destination *Address
+		want        []part
+	}{
+		{"most stock", AllocationRule{Strategy: AllocationMostStock}, 6, nil, []part{{"delhi", 4}, {"mumbai", 2}}},
+		{"nearest", AllocationRule{Strategy: AllocationNearest}, 6, pune, []part{{"mumbai", 3}, {"bengaluru", 2}, {"delhi", 1}}},
+		{"priority then stock", AllocationRule{Strategy: AllocationPriority, HubPriority: []string{"bengaluru"}}, 5, nil, []part{{"bengaluru", 2}, {"delhi", 3}}},
+		{"all stock", AllocationRule{Strategy: AllocationMostStock}, 9, nil, []part{{"delhi", 4}, {"mumbai", 3}, {"bengaluru", 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shipments, err := PlanSplit("ORD-1", tt.rule, candidates, tt.quantity, tt.destination)
+			assert.NoError(t, err)
+			var got []part
+			for i, s := range shipments {
+				got = append(got, part{s.Location, s.Quantity})
+				assert.Equal(t, OrderStatusOnHold, s.Status)
+				assert.Equal(t, "ORD-1-S"+string(rune('1'+i)), s.ID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPlanSplitInsufficientStock(t *testing.T) {
+	_, err := PlanSplit("ORD-1", DefaultAllocationRule("t1"), []HubCandidate{{Hub: "a", Stock: 2}, {Hub: "b", Stock: 1}}, 4, nil)
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	assert.Contains(t, err.Error(), "short by 1 of 4")
+}
+
+func TestDeriveStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []OrderStatus
+		want     OrderStatus
+	}{
+		{"none", nil, OrderStatusOnHold},
+		{"all fulfilled", []OrderStatus{OrderStatusNewOrder, OrderStatusNewOrder}, OrderStatusNewOrder},
+		{"all cancelled", []OrderStatus{OrderStatusCancelled, OrderStatusCancelled}, OrderStatusCancelled},
+		{"one waiting", []OrderStatus{OrderStatusNewOrder, OrderStatusOnHold}, OrderStatusOnHold},
+		{"some cancelled", []OrderStatus{OrderStatusNewOrder, OrderStatusCancelled}, OrderStatusPartiallyFulfilled},
+	}
+	for _, tt := range tests {
+		var shipments []SubShipment
+		for _, s := range tt.statuses {
+			shipments = append(shipments, SubShipment{Status: s})
+		}
+		assert.Equal(t, tt.want, DeriveStatus(shipments), tt.name)
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// UploadStatus is where a queued order upload is
+type UploadStatus string
+
+const (
+	UploadQueued     UploadStatus = "queued"
+	UploadProcessing UploadStatus = "processing"
+	UploadCompleted  UploadStatus = "completed"
+	UploadFailed     UploadStatus = "failed"
+)
+
+// UploadDuplicate is an uploaded row whose external_order_id the tenant already used
+type UploadDuplicate struct {
+	Row             int    `json:"row" bson:"row"`
+	ExternalOrderID string `json:"external_order_id" bson:"external_order_id"`
+	OrderID         string `json:"order_id,omitempty" bson:"order_id,omitempty"`
+}
+
+// UploadRowError is an uploaded row that was not turned into an order
+type UploadRowError struct {
+	Row   int    `json:"row" bson:"row"`
+	Error string `json:"error" bson:"error"`
+}
+
+// UploadResult is what became of the rows of one uploaded file, keyed by the request ID
+// the upload was queued under
+type UploadResult struct {
+	RequestID   string            `json:"request_id" bson:"request_id"`
+	Path        string            `json:"path" bson:"path"`
+	Status      UploadStatus      `json:"status" bson:"status"`
+	TotalRows   int               `json:"total_rows" bson:"total_rows"`
+	OrderIDs    []string          `json:"order_ids" bson:"order_ids"`
+	Duplicates  []UploadDuplicate `json:"duplicates" bson:"duplicates"`
+	Invalid     []UploadRowError  `json:"invalid" bson:"invalid"`
+	Error       string            `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt   time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" bson:"updated_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// NewUploadResult starts the result of the upload of path queued as requestID
+func NewUploadResult(requestID, path string, status UploadStatus) *UploadResult {
+	now := time.Now()
+	return &UploadResult{
+		RequestID:  requestID,
+		Path:       path,
+		Status:     status,
+		OrderIDs:   []string{},
+		Duplicates: []UploadDuplicate{},
+		Invalid:    []UploadRowError{},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// Finish marks the upload completed, or failed with err when the file could not be read
+func (r *UploadResult) Finish(err error) {
+	now := time.Now()
+	r.Status = UploadCompleted
+	if err != nil {
+		r.Status = UploadFailed
+		r.Error = err.Error()
+	}
+	r.UpdatedAt = now
+	r.CompletedAt = &now
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"oms/controllers"
+	"oms/middleware"
+
+	"github.com/omniful/go_commons/http"
+)
+
+// RegisterAllocationRoutes
+func RegisterAllocationRoutes(server *http.Server, allocationController *controllers.AllocationController) {
+	rules := server.Group("/api/v1/allocation-rules")
+	rules.Use(middleware.AuthMiddleware())
+	{
+		rules.GET("/:tenantID", allocationController.GetAllocationRule)
+		rules.PUT("/:tenantID", allocationController.PutAllocationRule)
+	}
+}
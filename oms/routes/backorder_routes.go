@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"oms/controllers"
+	"oms/middleware"
+
+	"github.com/omniful/go_commons/http"
+)
+
+// RegisterBackorderRoutes
+func RegisterBackorderRoutes(server *http.Server, backorderController *controllers.BackorderController) {
+	policies := server.Group("/api/v1/backorder-policies")
+	policies.Use(middleware.AuthMiddleware())
+	{
+		policies.GET("/:tenantID", backorderController.GetBackorderPolicy)
+		policies.PUT("/:tenantID", backorderController.PutBackorderPolicy)
+	}
+}
@@ -11,15 +11,23 @@ import (
 // RegisterOrderRoutes
 func RegisterOrderRoutes(server *http.Server, orderController *controllers.OrderController) {
 	//middleware
-	server.Use(middleware.LoggingMiddleware())
+	server.Use(middleware.LoggingMiddleware(), middleware.BodyLoggingMiddleware())
 
 
 	// Order management routes
 	orders := server.Group("/api/v1/orders")
 	orders.Use(middleware.AuthMiddleware())
 	{
+		orders.POST("/", orderController.CreateOrder)
+		orders.POST("/batch", orderController.CreateOrdersBatch)
 		orders.POST("/upload", orderController.UploadCSV)
+		orders.GET("/uploads/:requestID", orderController.GetUploadResult)
 		orders.GET("/", orderController.ListOrders)
+		orders.GET("/search", orderController.SearchOrders)
+		orders.GET("/sla", orderController.GetSLAOrders)
+		orders.GET("/export", orderController.ExportOrders)
+		orders.GET("/exports/:jobID", orderController.GetExportJob)
+		orders.GET("/by-external/:ref", orderController.GetOrderByExternalID)
 		orders.GET("/:orderID", orderController.GetOrderByID)
 		orders.PUT("/:orderID/status", orderController.UpdateOrderStatus)
 	}
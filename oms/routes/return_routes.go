@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"oms/controllers"
+	"oms/middleware"
+
+	"github.com/omniful/go_commons/http"
+)
+
+// RegisterReturnRoutes
+func RegisterReturnRoutes(server *http.Server, returnController *controllers.ReturnController) {
+	orders := server.Group("/api/v1/orders")
+	orders.Use(middleware.AuthMiddleware())
+	{
+		orders.POST("/:orderID/returns", returnController.RequestReturn)
+		orders.GET("/:orderID/returns", returnController.GetOrderReturns)
+	}
+
+	returns := server.Group("/api/v1/returns")
+	returns.Use(middleware.AuthMiddleware())
+	{
+		returns.GET("/:returnID", returnController.GetReturn)
+		returns.POST("/:returnID/approve", returnController.ApproveReturn)
+		returns.POST("/:returnID/reject", returnController.RejectReturn)
+		returns.POST("/:returnID/receive", returnController.ReceiveReturn)
+	}
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"oms/controllers"
+	"oms/middleware"
+
+	"github.com/omniful/go_commons/http"
+)
+
+// RegisterShipmentRoutes
+func RegisterShipmentRoutes(server *http.Server, shipmentController *controllers.ShipmentController) {
+	orders := server.Group("/api/v1/orders")
+	orders.Use(middleware.AuthMiddleware())
+	{
+		orders.GET("/:orderID/shipping-rates", shipmentController.GetShippingRates)
+		orders.POST("/:orderID/shipments", shipmentController.CreateShipment)
+		orders.GET("/:orderID/shipments", shipmentController.GetOrderShipments)
+	}
+
+	shipments := server.Group("/api/v1/shipments")
+	shipments.Use(middleware.AuthMiddleware())
+	{
+		shipments.GET("/:shipmentID", shipmentController.GetShipment)
+		shipments.POST("/:shipmentID/refresh", shipmentController.RefreshShipment)
+	}
+
+	server.GET("/api/v1/carriers", middleware.AuthMiddleware(), shipmentController.ListCarriers)
+}
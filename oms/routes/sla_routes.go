@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"oms/controllers"
+	"oms/middleware"
+
+	"github.com/omniful/go_commons/http"
+)
+
+// RegisterSLARoutes
+func RegisterSLARoutes(server *http.Server, slaController *controllers.SLAController) {
+	policies := server.Group("/api/v1/sla-policies")
+	policies.Use(middleware.AuthMiddleware())
+	{
+		policies.GET("/:tenantID", slaController.GetSLAPolicy)
+		policies.PUT("/:tenantID", slaController.PutSLAPolicy)
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"oms/models"
+)
+
+// AllocationRuleStore looks up a tenant's allocation rule
+type AllocationRuleStore interface {
+	GetRule(ctx context.Context, tenantID string) (models.AllocationRule, error)
+}
+
+// HubAllocator chooses a hub for orders that arrive without a location,
+// using the tenant's rule and IMS stock of the order's SKU at each hub.
+type HubAllocator struct {
+	imsClient IMSClientInterface
+	rules     AllocationRuleStore
+}
+
+func NewHubAllocator(imsClient IMSClientInterface, rules AllocationRuleStore) *HubAllocator {
+	return &HubAllocator{imsClient: imsClient, rules: rules}
+}
+
+// Allocate picks a hub for order; the caller stores the result on the order
+func (a *HubAllocator) Allocate(ctx context.Context, order *models.Order) (*models.Allocation, error) {
+	rule, err := a.rules.GetRule(ctx, order.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := a.candidates(order)
+	if err != nil {
+		return nil, err
+	}
+	allocation, err := models.Allocate(rule, candidates, order.OrderedQuantity(), order.ShippingAddress)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Allocated order %s to hub %s (%s): %v\n", order.ID, allocation.Hub, allocation.Strategy, allocation.Reasons)
+	return allocation, nil
+}
+
+// PlanSplit divides order across hubs when the tenant's rule allows split fulfilment
+func (a *HubAllocator) PlanSplit(ctx context.Context, order *models.Order) ([]models.SubShipment, error) {
+	rule, err := a.rules.GetRule(ctx, order.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !rule.AllowSplit {
+		return nil, fmt.Errorf("split fulfilment is off for tenant %s", order.TenantID)
+	}
+	candidates, err := a.candidates(order)
+	if err != nil {
+		return nil, err
+	}
+	return models.PlanSplit(order.ID, rule, candidates, order.OrderedQuantity(), order.ShippingAddress)
+}
+
+// candidates lists the hubs visible to the order's seller with their stock of its SKU.
+// IMS keeps stock by hub location, so candidates are keyed by location too; the chosen
+// candidate becomes the order's location.
+func (a *HubAllocator) candidates(order *models.Order) ([]models.HubCandidate, error) {
+	hubs, err := a.imsClient.GetHubs(order.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch hubs: %w", err)
+	}
+	inventory, err := a.imsClient.GetSKUInventory(order.TenantID, order.SKU)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch inventory: %w", err)
+	}
+
+	stock := make(map[string]int)
+	for _, item := range inventory {
+		if item.SKU == order.SKU && visibleTo(item.TenantID, item.SellerID, order) {
+			stock[item.Location] += item.Quantity
+		}
+	}
+
+	var candidates []models.HubCandidate
+	seen := make(map[string]bool)
+	for _, hub := range hubs {
+		if !visibleTo(hub.TenantID, hub.SellerID, order) || seen[hub.Location] {
+			continue
+		}
+		seen[hub.Location] = true
+		candidates = append(candidates, models.HubCandidate{
+			Hub:       hub.Location,
+			Name:      hub.Name,
+			Stock:     stock[hub.Location],
+			Latitude:  hub.Latitude,
+			Longitude: hub.Longitude,
+		})
+	}
+	return candidates, nil
+}
+
+// visibleTo reports whether an IMS record is shared or owned by the order's tenant and seller
+func visibleTo(tenantID, sellerID string, order *models.Order) bool {
+	return (tenantID == "" && sellerID == "") || (tenantID == order.TenantID && sellerID == order.SellerID)
+}
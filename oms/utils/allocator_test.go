@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"oms/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hubIMS lists hubs and the SKU's stock rows as IMS returns them
+type hubIMS struct {
+	*fakeIMS
+	hubs      []Hub
+	inventory []Inventory
+}
+
+func (f *hubIMS) GetHubs(string) ([]Hub, error)                       { return f.hubs, nil }
+func (f *hubIMS) GetSKUInventory(string, string) ([]Inventory, error) { return f.inventory, nil }
+
+// fixedRule returns the same rule for every tenant
+type fixedRule models.AllocationRule
+
+func (r fixedRule) GetRule(context.Context, string) (models.AllocationRule, error) {
+	return models.AllocationRule(r), nil
+}
+
+func TestAllocateKeysHubsByLocation(t *testing.T) {
+	ims := &hubIMS{
+		fakeIMS: newFakeIMS(nil),
+		hubs: []Hub{
+			{Name: "Delhi Warehouse", Location: "DEL-1", TenantID: "tenant-1", SellerID: "seller-1"},
+			{Name: "Mumbai Warehouse", Location: "BOM-1", TenantID: "tenant-1", SellerID: "seller-1"},
+		},
+		inventory: []Inventory{
+			{SKU: "SKU-1", Location: "DEL-1", TenantID: "tenant-1", SellerID: "seller-1", Quantity: 2},
+			{SKU: "SKU-1", Location: "BOM-1", TenantID: "tenant-1", SellerID: "seller-1", Quantity: 9},
+		},
+	}
+	allocator := NewHubAllocator(ims, fixedRule(models.DefaultAllocationRule("tenant-1")))
+	order := models.NewOrder("SKU-1", "", "tenant-1", "seller-1")
+	order.Quantity = 5
+
+	allocation, err := allocator.Allocate(context.Background(), order)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "BOM-1", allocation.Hub)
+		assert.Equal(t, []models.HubCandidate{
+			{Hub: "BOM-1", Name: "Mumbai Warehouse", Stock: 9},
+			{Hub: "DEL-1", Name: "Delhi Warehouse", Stock: 2},
+		}, allocation.Candidates)
+	}
+}
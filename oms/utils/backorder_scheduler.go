@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// retryBatchSize caps how many waiting orders one retry pass looks at
+const retryBatchSize = 200
+
+// BackorderScheduler retries on_hold and backordered orders, on a timer and whenever
+// IMS reports a stock increase, and cancels those that waited longer than their
+// tenant's backorder policy allows.
+type BackorderScheduler struct {
+	orderRepo OrderRepositoryInterface
+	handler   *OrderFinalizationHandler
+	policies  BackorderPolicyStore
+	interval  time.Duration
+	mu        sync.Mutex
+}
+
+func NewBackorderScheduler(orderRepo OrderRepositoryInterface, handler *OrderFinalizationHandler, policies BackorderPolicyStore, interval time.Duration) *BackorderScheduler {
+	return &BackorderScheduler{orderRepo: orderRepo, handler: handler, policies: policies, interval: interval}
+}
+
+// Start polls every interval until ctx is done
+func (s *BackorderScheduler) Start(ctx context.Context) {
+	fmt.Printf("Backorder scheduler polling every %s\n", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RetryWaiting(ctx, "", "")
+		}
+	}
+}
+
+// RetryWaiting re-evaluates waiting orders, limited to one tenant and SKU when given.
+// Orders are retried one at a time, and the lock is only held for one order, so a stock
+// event is never stuck behind a whole timer pass. IMS applies one reduction per order ID,
+// so an order retried again, by another instance or after a failed status update, does
+// not have its stock taken twice.
+func (s *BackorderScheduler) RetryWaiting(ctx context.Context, tenantID, sku string) {
+	orders, err := s.orderRepo.GetWaitingOrders(ctx, tenantID, sku, retryBatchSize)
+	if err != nil {
+		fmt.Printf("Backorder retry failed: %v\n", err)
+		return
+	}
+	if len(orders) == 0 {
+		return
+	}
+	fmt.Printf("Retrying %d waiting orders (tenant: %q, sku: %q)\n", len(orders), tenantID, sku)
+
+	for i := range orders {
+		s.retry(ctx, orders[i].ID)
+	}
+}
+
+// retry re-reads one waiting order under the lock, since another pass may have settled it
+// meanwhile, and cancels it when it waited too long or tries to finalize it again
+func (s *BackorderScheduler) retry(ctx context.Context, orderID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.orderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		fmt.Printf("Skipping order %s: %v\n", orderID, err)
+		return
+	}
+	if !order.Status.IsWaiting() {
+		return
+	}
+	policy, err := s.policies.GetPolicy(ctx, order.TenantID)
+	if err != nil {
+		fmt.Printf("Skipping order %s: %v\n", order.ID, err)
+		return
+	}
+	if policy.Expired(order, time.Now()) {
+		if err := s.handler.Cancel(ctx, order, fmt.Sprintf("waited longer than the tenant's %s limit", order.Status)); err != nil {
+			fmt.Printf("Cancel of expired order %s failed: %v\n", order.ID, err)
+		}
+		return
+	}
+	if err := s.handler.Finalize(ctx, order); err != nil {
+		fmt.Printf("Retry of order %s failed: %v\n", order.ID, err)
+	}
+}
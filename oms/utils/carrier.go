@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"oms/models"
+)
+
+// ShipmentRequest describes the parcel a carrier is asked to quote or book
+type ShipmentRequest struct {
+	OrderID     string
+	TenantID    string
+	Origin      string
+	Destination *models.Address
+	Quantity    int
+}
+
+// Label is a booked shipment's label and tracking number
+type Label struct {
+	TrackingNumber string
+	LabelURL       string
+	Rate           models.RateQuote
+}
+
+// TrackingUpdate is a carrier's current status of a shipment and the events leading to it
+type TrackingUpdate struct {
+	Status models.ShipmentStatus
+	Events []models.TrackingEvent
+}
+
+// Carrier is a shipping provider. Implementations wrap one carrier's API.
+type Carrier interface {
+	// Name identifies the carrier in requests and on stored shipments
+	Name() string
+	// Quote lists the carrier's rates for the parcel, one per service level
+	Quote(ctx context.Context, req ShipmentRequest) ([]models.RateQuote, error)
+	// CreateLabel books the parcel with service and returns its label and tracking number
+	CreateLabel(ctx context.Context, req ShipmentRequest, service string) (*Label, error)
+	// Track polls the carrier for the shipment's current status
+	Track(ctx context.Context, trackingNumber string) (*TrackingUpdate, error)
+}
+
+// ErrUnknownCarrier is returned when no carrier is registered under a name
+var ErrUnknownCarrier = errors.New("unknown carrier")
+
+// CarrierRegistry holds the configured carriers by name
+type CarrierRegistry struct {
+	carriers map[string]Carrier
+}
+
+func NewCarrierRegistry(carriers ...Carrier) *CarrierRegistry {
+	r := &CarrierRegistry{carriers: make(map[string]Carrier)}
+	for _, c := range carriers {
+		r.carriers[c.Name()] = c
+	}
+	return r
+}
+
+// Get returns the carrier registered as name
+func (r *CarrierRegistry) Get(name string) (Carrier, error) {
+	c, ok := r.carriers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCarrier, name)
+	}
+	return c, nil
+}
+
+// Names lists the registered carriers alphabetically
+func (r *CarrierRegistry) Names() []string {
+	names := make([]string, 0, len(r.carriers))
+	for name := range r.carriers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -3,8 +3,11 @@ package utils
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"oms/models"
+
 	"github.com/omniful/go_commons/csv"
 )
 
@@ -17,6 +20,134 @@ type CSVRow struct {
 	TenantID  string `json:"tenant_id"`
 	SellerID  string `json:"seller_id"`
 	RowNumber int    `json:"row_number"`
+
+	ExternalOrderID string `json:"external_order_id"`
+	Priority        string `json:"priority"`
+
+	CustomerName  string `json:"customer_name"`
+	CustomerPhone string `json:"customer_phone"`
+	CustomerEmail string `json:"customer_email"`
+
+	ShippingLine1      string `json:"shipping_line1"`
+	ShippingLine2      string `json:"shipping_line2"`
+	ShippingCity       string `json:"shipping_city"`
+	ShippingState      string `json:"shipping_state"`
+	ShippingPostalCode string `json:"shipping_postal_code"`
+	ShippingCountry    string `json:"shipping_country"`
+	ShippingLatitude   string `json:"shipping_latitude"`
+	ShippingLongitude  string `json:"shipping_longitude"`
+
+	BillingLine1      string `json:"billing_line1"`
+	BillingLine2      string `json:"billing_line2"`
+	BillingCity       string `json:"billing_city"`
+	BillingState      string `json:"billing_state"`
+	BillingPostalCode string `json:"billing_postal_code"`
+	BillingCountry    string `json:"billing_country"`
+
+	// Pricing columns are kept as text so amounts stay exact until parsed as decimals
+	Quantity   string `json:"quantity"`
+	UnitPrice  string `json:"unit_price"`
+	Currency   string `json:"currency"`
+	Discount   string `json:"discount"`
+	TaxRate    string `json:"tax_rate"`
+	OrderTotal string `json:"order_total"`
+}
+
+// applyPricing sets the row's quantity and, when a unit_price is given, prices the order.
+// A supplied order_total must equal the computed total.
+func (r CSVRow) applyPricing(order *models.Order) error {
+	order.Quantity = 1
+	if q := strings.TrimSpace(r.Quantity); q != "" {
+		quantity, err := strconv.Atoi(q)
+		if err != nil || quantity <= 0 {
+			return fmt.Errorf("quantity must be a positive whole number")
+		}
+		order.Quantity = quantity
+	}
+
+	if strings.TrimSpace(r.UnitPrice) == "" {
+		if r.Currency != "" || r.Discount != "" || r.TaxRate != "" || r.OrderTotal != "" {
+			return fmt.Errorf("unit_price is required when pricing columns are given")
+		}
+		return nil
+	}
+
+	line := models.OrderLine{SKU: r.SKU, Quantity: order.Quantity}
+	var err error
+	if line.UnitPrice, err = models.NewAmount(r.UnitPrice); err != nil {
+		return fmt.Errorf("unit_price: %w", err)
+	}
+	if line.Discount, err = models.NewAmount(r.Discount); err != nil {
+		return fmt.Errorf("discount: %w", err)
+	}
+	if line.TaxRate, err = models.NewAmount(r.TaxRate); err != nil {
+		return fmt.Errorf("tax_rate: %w", err)
+	}
+	if err := order.PriceLines(r.Currency, []models.OrderLine{line}); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(r.OrderTotal) != "" {
+		declared, err := models.NewAmount(r.OrderTotal)
+		if err != nil {
+			return fmt.Errorf("order_total: %w", err)
+		}
+		if !declared.Equal(order.Totals.Total.Decimal) {
+			return fmt.Errorf("order_total %s does not match computed total %s", declared, order.Totals.Total)
+		}
+	}
+	return nil
+}
+
+// applyContact copies the row's optional customer and address columns onto order,
+// normalising phone numbers and country codes. Blank groups are left unset.
+func (r CSVRow) applyContact(order *models.Order) error {
+	if r.CustomerName != "" || r.CustomerPhone != "" || r.CustomerEmail != "" {
+		order.Customer = &models.Customer{
+			Name:  strings.TrimSpace(r.CustomerName),
+			Phone: models.NormalizePhone(r.CustomerPhone),
+			Email: strings.TrimSpace(r.CustomerEmail),
+		}
+	}
+	order.ShippingAddress = rowAddress(r.ShippingLine1, r.ShippingLine2, r.ShippingCity, r.ShippingState, r.ShippingPostalCode, r.ShippingCountry)
+	order.BillingAddress = rowAddress(r.BillingLine1, r.BillingLine2, r.BillingCity, r.BillingState, r.BillingPostalCode, r.BillingCountry)
+
+	lat, lon := strings.TrimSpace(r.ShippingLatitude), strings.TrimSpace(r.ShippingLongitude)
+	if lat == "" && lon == "" {
+		return nil
+	}
+	if order.ShippingAddress == nil {
+		return fmt.Errorf("shipping coordinates require a shipping address")
+	}
+	for _, c := range []struct {
+		value string
+		dest  **float64
+	}{{lat, &order.ShippingAddress.Latitude}, {lon, &order.ShippingAddress.Longitude}} {
+		if c.value == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(c.value, 64)
+		if err != nil {
+			return fmt.Errorf("shipping coordinates must be decimal degrees")
+		}
+		*c.dest = &v
+	}
+	return nil
+}
+
+func rowAddress(line1, line2, city, state, postalCode, country string) *models.Address {
+	address := &models.Address{
+		Line1:      strings.TrimSpace(line1),
+		Line2:      strings.TrimSpace(line2),
+		City:       strings.TrimSpace(city),
+		State:      strings.TrimSpace(state),
+		PostalCode: strings.TrimSpace(postalCode),
+		Country:    strings.ToUpper(strings.TrimSpace(country)),
+	}
+	if address.IsEmpty() {
+		return nil
+	}
+	return address
 }
 
 
@@ -32,6 +163,19 @@ type CSVParseResult struct {
 	Headers      []string `json:"headers"`
 	ErrorRows    []int    `json:"error_rows"`
 	ErrorMessage string   `json:"error_message,omitempty"`
+	// RowErrors says why each of the ErrorRows is invalid, keyed by row number
+	RowErrors map[int]string `json:"row_errors,omitempty"`
+}
+
+// rejectRow records row as invalid because of err
+func (r *CSVParseResult) rejectRow(row CSVRow, err error) {
+	r.InvalidData = append(r.InvalidData, row)
+	r.InvalidRows++
+	r.ErrorRows = append(r.ErrorRows, row.RowNumber)
+	if r.RowErrors == nil {
+		r.RowErrors = make(map[int]string)
+	}
+	r.RowErrors[row.RowNumber] = err.Error()
 }
 
 type CSVParser struct {
@@ -103,9 +247,7 @@ func (p *CSVParser) ParseCSVFromBytes(ctx context.Context, csvData []byte) (*CSV
 		for i, row := range batchData {
 			row.RowNumber = rowNumber + i + 1
 			if err := p.validateRow(row); err != nil {
-				result.InvalidData = append(result.InvalidData, row)
-				result.InvalidRows++
-				result.ErrorRows = append(result.ErrorRows, row.RowNumber)
+				result.rejectRow(row, err)
 			} else {
 				result.ValidData = append(result.ValidData, row)
 				result.ValidRows++
@@ -139,7 +281,7 @@ func (p *CSVParser) ParseCSVFromBytes(ctx context.Context, csvData []byte) (*CSV
 
 // validateheaders
 func (p *CSVParser) validateHeaders(headers []string) error {
-	required := []string{"sku", "location", "tenant_id", "seller_id"}
+	required := []string{"sku", "tenant_id", "seller_id"}
 	m := make(map[string]bool)
 	for _, h := range headers {
 		m[strings.ToLower(strings.TrimSpace(h))] = true
@@ -159,19 +301,31 @@ func (p *CSVParser) validateHeaders(headers []string) error {
 
 // validaterow
 func (p *CSVParser) validateRow(row CSVRow) error {
+	return validateRowFields(row)
+}
+
+// validateRowFields checks the fields every order row needs, whatever its source
+func validateRowFields(row CSVRow) error {
 	if strings.TrimSpace(row.SKU) == "" {
 		return fmt.Errorf("SKU is empty")
 	}
-	if strings.TrimSpace(row.Location) == "" {
-		return fmt.Errorf("location is empty")
-	}
 	if strings.TrimSpace(row.TenantID) == "" {
 		return fmt.Errorf("tenant_id is empty")
 	}
 	if strings.TrimSpace(row.SellerID) == "" {
 		return fmt.Errorf("seller_id is empty")
 	}
-	return nil
+	var order models.Order
+	if _, err := models.ParsePriority(row.Priority); err != nil {
+		return err
+	}
+	if err := row.applyContact(&order); err != nil {
+		return err
+	}
+	if err := order.ValidateContact(); err != nil {
+		return err
+	}
+	return row.applyPricing(&order)
 }
 
 
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"oms/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// FakeCarrierName is the name the local fake carrier registers under
+const FakeCarrierName = "fake"
+
+// fakeServices are the fake carrier's service levels: price multiplier and transit days
+var fakeServices = map[string]struct {
+	multiplier int64
+	days       int
+}{
+	"standard": {1, 3},
+	"express":  {2, 1},
+}
+
+// fakeProgress is the status a fake shipment reaches after each step
+var fakeProgress = []struct {
+	status      models.ShipmentStatus
+	description string
+}{
+	{models.ShipmentLabelCreated, "Label created"},
+	{models.ShipmentInTransit, "Picked up from hub"},
+	{models.ShipmentOutForDelivery, "Out for delivery"},
+	{models.ShipmentDelivered, "Delivered"},
+}
+
+// FakeCarrier is an offline carrier for development and tests. It prices parcels from a flat
+// fee plus a per-unit charge and moves each shipment one status further every step, from
+// label_created to delivered. The label time is encoded in the tracking number, so tracking
+// works without any stored state and survives restarts.
+type FakeCarrier struct {
+	step time.Duration
+	now  func() time.Time
+}
+
+func NewFakeCarrier(step time.Duration) *FakeCarrier {
+	return &FakeCarrier{step: step, now: time.Now}
+}
+
+func (f *FakeCarrier) Name() string {
+	return FakeCarrierName
+}
+
+// Quote prices the parcel at 5.00 plus 1.00 per unit, doubled for express
+func (f *FakeCarrier) Quote(ctx context.Context, req ShipmentRequest) ([]models.RateQuote, error) {
+	quantity := req.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+	base := decimal.NewFromInt(5).Add(decimal.NewFromInt(int64(quantity)))
+
+	quotes := make([]models.RateQuote, 0, len(fakeServices))
+	for _, service := range []string{"standard", "express"} {
+		s := fakeServices[service]
+		quotes = append(quotes, models.RateQuote{
+			Carrier:       FakeCarrierName,
+			Service:       service,
+			Amount:        models.Amount{Decimal: base.Mul(decimal.NewFromInt(s.multiplier)).Round(2)},
+			Currency:      "USD",
+			EstimatedDays: s.days,
+		})
+	}
+	return quotes, nil
+}
+
+// CreateLabel books the parcel; tracking numbers look like FAKE-<unix seconds>-<random>
+func (f *FakeCarrier) CreateLabel(ctx context.Context, req ShipmentRequest, service string) (*Label, error) {
+	quotes, err := f.Quote(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range quotes {
+		if q.Service != service {
+			continue
+		}
+		tracking := fmt.Sprintf("FAKE-%d-%04d", f.now().Unix(), rand.Intn(10000))
+		return &Label{
+			TrackingNumber: tracking,
+			LabelURL:       fmt.Sprintf("https://labels.fake-carrier.local/%s.pdf", tracking),
+			Rate:           q,
+		}, nil
+	}
+	return nil, fmt.Errorf("fake carrier has no %q service", service)
+}
+
+// Track reports one more status for every step elapsed since the label was created
+func (f *FakeCarrier) Track(ctx context.Context, trackingNumber string) (*TrackingUpdate, error) {
+	parts := strings.Split(trackingNumber, "-")
+	if len(parts) != 3 || parts[0] != "FAKE" {
+		return nil, fmt.Errorf("unknown fake tracking number: %s", trackingNumber)
+	}
+	seconds, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unknown fake tracking number: %s", trackingNumber)
+	}
+	labelled := time.Unix(seconds, 0).UTC()
+
+	reached := 0
+	if f.step > 0 {
+		reached = int(f.now().Sub(labelled) / f.step)
+	}
+	if reached >= len(fakeProgress) {
+		reached = len(fakeProgress) - 1
+	}
+
+	update := &TrackingUpdate{}
+	for i := 0; i <= reached; i++ {
+		p := fakeProgress[i]
+		update.Status = p.status
+		update.Events = append(update.Events, models.TrackingEvent{
+			Status:      p.status,
+			Description: p.description,
+			At:          labelled.Add(time.Duration(i) * f.step),
+		})
+	}
+	return update, nil
+}
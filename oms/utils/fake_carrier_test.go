@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"oms/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeCarrierQuote(t *testing.T) {
+	carrier := NewFakeCarrier(time.Minute)
+
+	quotes, err := carrier.Quote(context.Background(), ShipmentRequest{Quantity: 3})
+	assert.NoError(t, err)
+	if assert.Len(t, quotes, 2) {
+		assert.Equal(t, "standard", quotes[0].Service)
+		assert.Equal(t, "8", quotes[0].Amount.String())
+		assert.Equal(t, "express", quotes[1].Service)
+		assert.Equal(t, "16", quotes[1].Amount.String())
+	}
+}
+
+func TestFakeCarrierLabelAndTracking(t *testing.T) {
+	labelled := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	carrier := NewFakeCarrier(time.Hour)
+	carrier.now = func() time.Time { return labelled }
+	ctx := context.Background()
+
+	_, err := carrier.CreateLabel(ctx, ShipmentRequest{}, "overnight")
+	assert.Error(t, err)
+
+	label, err := carrier.CreateLabel(ctx, ShipmentRequest{Quantity: 1}, "express")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(label.TrackingNumber, "FAKE-"))
+	assert.Equal(t, "express", label.Rate.Service)
+
+	tests := []struct {
+		elapsed time.Duration
+		want    models.ShipmentStatus
+		events  int
+	}{
+		{30 * time.Minute, models.ShipmentLabelCreated, 1},
+		{time.Hour, models.ShipmentInTransit, 2},
+		{2*time.Hour + time.Minute, models.ShipmentOutForDelivery, 3},
+		{10 * time.Hour, models.ShipmentDelivered, 4},
+	}
+	for _, tt := range tests {
+		carrier.now = func() time.Time { return labelled.Add(tt.elapsed) }
+		update, err := carrier.Track(ctx, label.TrackingNumber)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, update.Status, tt.elapsed.String())
+		assert.Len(t, update.Events, tt.events, tt.elapsed.String())
+	}
+
+	_, err = carrier.Track(ctx, "UPS-123")
+	assert.Error(t, err)
+}
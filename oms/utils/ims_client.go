@@ -3,8 +3,11 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -28,6 +31,9 @@ type Hub struct {
 	Location string `json:"location"`
 	TenantID string `json:"tenant_id"`
 	SellerID string `json:"seller_id"`
+
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
 }
 
 type Inventory struct {
@@ -43,20 +49,39 @@ type Inventory struct {
 }
 
 type SKUResponse struct {
-	Data   []SKU  `json:"data"`
-	Source string `json:"source"`
+	Data       []SKU  `json:"data"`
+	Source     string `json:"source"`
+	NextCursor string `json:"next_cursor"`
+	Total      int64  `json:"total"`
 }
 
 type HubResponse struct {
-	Data   []Hub  `json:"data"`
-	Source string `json:"source"`
+	Data       []Hub  `json:"data"`
+	Source     string `json:"source"`
+	NextCursor string `json:"next_cursor"`
+	Total      int64  `json:"total"`
 }
 
 type InventoryResponse struct {
-	Data   []Inventory `json:"data"`
-	Source string      `json:"source"`
+	Data       []Inventory `json:"data"`
+	Source     string      `json:"source"`
+	NextCursor string      `json:"next_cursor"`
+	Total      int64       `json:"total"`
 }
 
+var (
+	// ErrInsufficientStock is returned when IMS has too little stock, or no row, for a reduction
+	ErrInsufficientStock = errors.New("insufficient inventory")
+	// ErrReductionConflict is returned when IMS already reduced the order at another hub or quantity
+	ErrReductionConflict = errors.New("order already reduced differently")
+)
+
+// TenantHeader scopes every IMS request to one tenant.
+const TenantHeader = "X-Tenant-ID"
+
+// imsPageLimit is the page size used when walking IMS list endpoints.
+const imsPageLimit = 500
+
 func NewIMSClient(baseURL string) *IMSClient {
 	return &IMSClient{
 		baseURL: baseURL,
@@ -66,130 +91,176 @@ func NewIMSClient(baseURL string) *IMSClient {
 	}
 }
 
-// getskus
-func (c *IMSClient) GetSKUs() ([]SKU, error) {
-	url := fmt.Sprintf("%s/sku/", c.baseURL)
-	fmt.Printf("Fetching SKUs from IMS: %s\n", url)
+// get issues a GET scoped to tenantID
+func (c *IMSClient) get(url, tenantID string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(TenantHeader, tenantID)
+	return c.httpClient.Do(req)
+}
 
-	resp, err := c.httpClient.Get(url)
+// getPage fetches one page of an IMS list endpoint, narrowed by filters, into out
+func (c *IMSClient) getPage(path, tenantID, cursor string, filters url.Values, out interface{}) error {
+	params := url.Values{}
+	for key, values := range filters {
+		params[key] = values
+	}
+	params.Set("limit", strconv.Itoa(imsPageLimit))
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	resp, err := c.get(fmt.Sprintf("%s%s?%s", c.baseURL, path, params.Encode()), tenantID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch SKUs: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("IMS API returned status %d", resp.StatusCode)
+		return fmt.Errorf("IMS API returned status %d", resp.StatusCode)
 	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
 
-	var skuResponse SKUResponse
-	if err := json.NewDecoder(resp.Body).Decode(&skuResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode SKU response: %w", err)
+// getskus
+func (c *IMSClient) GetSKUs(tenantID string) ([]SKU, error) {
+	fmt.Printf("Fetching SKUs from IMS: %s/sku/\n", c.baseURL)
+
+	var skus []SKU
+	cursor := ""
+	for {
+		var page SKUResponse
+		if err := c.getPage("/sku/", tenantID, cursor, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch SKUs: %w", err)
+		}
+		skus = append(skus, page.Data...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
 	}
 
-	fmt.Printf("Successfully fetched %d SKUs from IMS\n", len(skuResponse.Data))
-	return skuResponse.Data, nil
+	fmt.Printf("Successfully fetched %d SKUs from IMS\n", len(skus))
+	return skus, nil
 }
 
 // gethubs
-func (c *IMSClient) GetHubs() ([]Hub, error) {
-	url := fmt.Sprintf("%s/hub/", c.baseURL)
-	fmt.Printf("Fetching Hubs from IMS: %s\n", url)
-
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch hubs: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("IMS API returned status %d", resp.StatusCode)
-	}
-
-	var hubResponse HubResponse
-	if err := json.NewDecoder(resp.Body).Decode(&hubResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode hub response: %w", err)
+func (c *IMSClient) GetHubs(tenantID string) ([]Hub, error) {
+	fmt.Printf("Fetching Hubs from IMS: %s/hub/\n", c.baseURL)
+
+	var hubs []Hub
+	cursor := ""
+	for {
+		var page HubResponse
+		if err := c.getPage("/hub/", tenantID, cursor, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch hubs: %w", err)
+		}
+		hubs = append(hubs, page.Data...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
 	}
 
-	fmt.Printf("Successfully fetched %d hubs from IMS\n", len(hubResponse.Data))
-	return hubResponse.Data, nil
+	fmt.Printf("Successfully fetched %d hubs from IMS\n", len(hubs))
+	return hubs, nil
 }
 
 // getinventory
-func (c *IMSClient) GetInventory() ([]Inventory, error) {
-	url := fmt.Sprintf("%s/inventory/", c.baseURL)
-	fmt.Printf("Fetching Inventory from IMS: %s\n", url)
+func (c *IMSClient) GetInventory(tenantID string) ([]Inventory, error) {
+	return c.listInventory(tenantID, nil)
+}
 
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch inventory: %w", err)
-	}
-	defer resp.Body.Close()
+// GetSKUInventory fetches the tenant's inventory of one SKU across every hub
+func (c *IMSClient) GetSKUInventory(tenantID, sku string) ([]Inventory, error) {
+	return c.listInventory(tenantID, url.Values{"sku": {sku}})
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("IMS API returned status %d", resp.StatusCode)
-	}
+func (c *IMSClient) listInventory(tenantID string, filters url.Values) ([]Inventory, error) {
+	fmt.Printf("Fetching Inventory from IMS: %s/inventory/\n", c.baseURL)
 
-	var inventoryResponse InventoryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&inventoryResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode inventory response: %w", err)
+	var inventory []Inventory
+	cursor := ""
+	for {
+		var page InventoryResponse
+		if err := c.getPage("/inventory/", tenantID, cursor, filters, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch inventory: %w", err)
+		}
+		inventory = append(inventory, page.Data...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
 	}
 
-	fmt.Printf("Successfully fetched %d inventory items from IMS\n", len(inventoryResponse.Data))
-	return inventoryResponse.Data, nil
+	fmt.Printf("Successfully fetched %d inventory items from IMS\n", len(inventory))
+	return inventory, nil
 }
 
-// validatesku
+// validatesku pages through the SKUs IMS returns for skuCode and stops at the first visible one
 func (c *IMSClient) ValidateSKU(skuCode, tenantID, sellerID string) (bool, error) {
-	skus, err := c.GetSKUs()
-	if err != nil {
-		return false, err
-	}
-
-	for _, sku := range skus {
-		if sku.Code == skuCode {
-			if (sku.TenantID == "" && sku.SellerID == "") ||
-				(sku.TenantID == tenantID && sku.SellerID == sellerID) {
+	filters := url.Values{"sku_code": {skuCode}}
+	cursor := ""
+	for {
+		var page SKUResponse
+		if err := c.getPage("/sku/", tenantID, cursor, filters, &page); err != nil {
+			return false, fmt.Errorf("failed to fetch SKUs: %w", err)
+		}
+		for _, sku := range page.Data {
+			if sku.Code == skuCode && visibleToSeller(sku.TenantID, sku.SellerID, tenantID, sellerID) {
 				return true, nil
 			}
 		}
+		if page.NextCursor == "" {
+			return false, nil
+		}
+		cursor = page.NextCursor
 	}
-
-	return false, nil
 }
 
-// validatehub
+// validatehub pages through the hubs whose name starts with hubName and stops at the
+// first visible exact match
 func (c *IMSClient) ValidateHub(hubName, tenantID, sellerID string) (bool, error) {
-	hubs, err := c.GetHubs()
-	if err != nil {
-		return false, err
-	}
-
-	for _, hub := range hubs {
-		if hub.Name == hubName {
-			if (hub.TenantID == "" && hub.SellerID == "") ||
-				(hub.TenantID == tenantID && hub.SellerID == sellerID) {
+	filters := url.Values{"name_prefix": {hubName}}
+	cursor := ""
+	for {
+		var page HubResponse
+		if err := c.getPage("/hub/", tenantID, cursor, filters, &page); err != nil {
+			return false, fmt.Errorf("failed to fetch hubs: %w", err)
+		}
+		for _, hub := range page.Data {
+			if hub.Name == hubName && visibleToSeller(hub.TenantID, hub.SellerID, tenantID, sellerID) {
 				return true, nil
 			}
 		}
+		if page.NextCursor == "" {
+			return false, nil
+		}
+		cursor = page.NextCursor
 	}
+}
 
-	return false, nil
+// visibleToSeller reports whether a shared row or one owned by the seller is being looked at
+func visibleToSeller(rowTenant, rowSeller, tenantID, sellerID string) bool {
+	return (rowTenant == "" && rowSeller == "") || (rowTenant == tenantID && rowSeller == sellerID)
 }
 
-// checkinventory
+// checkinventory asks IMS for the sku's row at location and stops at the first visible one
 func (c *IMSClient) CheckInventoryAvailability(sku, location, tenantID, sellerID string) (bool, int, error) {
 	fmt.Printf("Checking inventory availability - SKU: %s, Location: %s, Tenant: %s, Seller: %s\n",
 		sku, location, tenantID, sellerID)
 
-	inventory, err := c.GetInventory()
-	if err != nil {
-		return false, 0, fmt.Errorf("failed to fetch inventory: %w", err)
-	}
-
-	for _, item := range inventory {
-		if item.SKU == sku && item.Location == location {
-			if (item.TenantID == "" && item.SellerID == "") ||
-				(item.TenantID == tenantID && item.SellerID == sellerID) {
+	filters := url.Values{"sku": {sku}, "location": {location}}
+	cursor := ""
+	for {
+		var page InventoryResponse
+		if err := c.getPage("/inventory/", tenantID, cursor, filters, &page); err != nil {
+			return false, 0, fmt.Errorf("failed to fetch inventory: %w", err)
+		}
+		for _, item := range page.Data {
+			if item.SKU == sku && item.Location == location &&
+				visibleToSeller(item.TenantID, item.SellerID, tenantID, sellerID) {
 
 				isAvailable := item.Quantity > 0
 				fmt.Printf("Inventory check result - SKU: %s, Location: %s, Available: %t, Quantity: %d\n",
@@ -198,6 +269,10 @@ func (c *IMSClient) CheckInventoryAvailability(sku, location, tenantID, sellerID
 				return isAvailable, item.Quantity, nil
 			}
 		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
 	}
 
 	fmt.Printf("No inventory found - SKU: %s, Location: %s, Tenant: %s, Seller: %s\n",
@@ -206,8 +281,10 @@ func (c *IMSClient) CheckInventoryAvailability(sku, location, tenantID, sellerID
 	return false, 0, nil
 }
 
-// ReduceInventory calls the IMS API to atomically reduce inventory for a SKU/location
-func (c *IMSClient) ReduceInventory(sku, location, tenantID, sellerID string, quantity int) (bool, error) {
+// ReduceInventory calls the IMS API to atomically reduce inventory for a SKU/location.
+// orderID lets IMS assign serial numbers of serialized SKUs to the order, and IMS
+// reduces stock once per orderID, so repeating the call for an order is safe.
+func (c *IMSClient) ReduceInventory(sku, location, tenantID, sellerID string, quantity int, orderID string) (bool, error) {
 	url := fmt.Sprintf("%s/inventory/reduce", c.baseURL)
 	payload := map[string]interface{}{
 		"sku":       sku,
@@ -215,6 +292,7 @@ func (c *IMSClient) ReduceInventory(sku, location, tenantID, sellerID string, qu
 		"quantity":  quantity,
 		"tenant_id": tenantID,
 		"seller_id": sellerID,
+		"order_id":  orderID,
 	}
 	body, _ := json.Marshal(payload)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
@@ -222,6 +300,7 @@ func (c *IMSClient) ReduceInventory(sku, location, tenantID, sellerID string, qu
 		return false, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TenantHeader, tenantID)
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return false, err
@@ -230,5 +309,52 @@ func (c *IMSClient) ReduceInventory(sku, location, tenantID, sellerID string, qu
 	if resp.StatusCode == http.StatusOK {
 		return true, nil
 	}
+
+	var failure struct {
+		Error string `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&failure)
+	switch {
+	case resp.StatusCode == http.StatusNotFound,
+		resp.StatusCode == http.StatusBadRequest && failure.Error == "Insufficient inventory":
+		return false, fmt.Errorf("%w: %s", ErrInsufficientStock, failure.Error)
+	case resp.StatusCode == http.StatusConflict:
+		return false, fmt.Errorf("%w: %s", ErrReductionConflict, failure.Error)
+	}
 	return false, fmt.Errorf("IMS ReduceInventory failed with status %d", resp.StatusCode)
 }
+
+// ReturnRestock is one received return line sent to IMS
+type ReturnRestock struct {
+	ReturnID    string   `json:"return_id"`
+	OrderID     string   `json:"order_id"`
+	ShipmentIDs []string `json:"shipment_ids,omitempty"`
+	SKU         string   `json:"sku"`
+	Location    string   `json:"location"`
+	SellerID    string   `json:"seller_id"`
+	Quantity    int      `json:"quantity"`
+	Disposition string   `json:"disposition"`
+	Reason      string   `json:"reason,omitempty"`
+}
+
+// RestockReturn records a received return line in IMS, which restocks sellable lines
+// and quarantines the rest. IMS ignores a line it already received, so retries are safe.
+func (c *IMSClient) RestockReturn(tenantID string, line ReturnRestock) error {
+	url := fmt.Sprintf("%s/inventory/returns", c.baseURL)
+	body, _ := json.Marshal(line)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TenantHeader, tenantID)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("IMS RestockReturn failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -3,8 +3,10 @@ package utils
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"oms/models"
 	"oms/webhook"
@@ -18,16 +20,25 @@ type OrderRepositoryInterface interface {
 	GetOrdersByFilter(ctx context.Context, filters map[string]string, limit, offset int) ([]models.Order, error)
 	GetOrderByID(ctx context.Context, orderID string) (*models.Order, error)
 	UpdateOrderStatus(ctx context.Context, orderID string, newStatus models.OrderStatus) error
+	UpdateOrderFulfilment(ctx context.Context, orderID string, newStatus models.OrderStatus, shipments []models.SubShipment) error
+	MarkOrderWaiting(ctx context.Context, orderID string, status models.OrderStatus, reason string) error
+	GetWaitingOrders(ctx context.Context, tenantID, sku string, limit int) ([]models.Order, error)
+}
+
+// BackorderPolicyStore looks up a tenant's backorder policy
+type BackorderPolicyStore interface {
+	GetPolicy(ctx context.Context, tenantID string) (models.BackorderPolicy, error)
 }
 
 type IMSClientInterface interface {
-	GetSKUs() ([]SKU, error)
-	GetHubs() ([]Hub, error)
-	GetInventory() ([]Inventory, error)
+	GetSKUs(tenantID string) ([]SKU, error)
+	GetHubs(tenantID string) ([]Hub, error)
+	GetInventory(tenantID string) ([]Inventory, error)
+	GetSKUInventory(tenantID, sku string) ([]Inventory, error)
 	ValidateSKU(skuCode, tenantID, sellerID string) (bool, error)
 	ValidateHub(hubName, tenantID, sellerID string) (bool, error)
 	CheckInventoryAvailability(skuCode, location, tenantID, sellerID string) (bool, int, error)
-	ReduceInventory(skuCode, location, tenantID, sellerID string, quantity int) (bool, error)
+	ReduceInventory(skuCode, location, tenantID, sellerID string, quantity int, orderID string) (bool, error)
 }
 
 type OrderFinalizationConsumer struct {
@@ -41,11 +52,15 @@ type OrderFinalizationConsumer struct {
 type OrderFinalizationHandler struct {
 	orderRepo OrderRepositoryInterface
 	imsClient IMSClientInterface
+	allocator *HubAllocator
+	policies  BackorderPolicyStore
 }
 
+func NewOrderFinalizationHandler(orderRepo OrderRepositoryInterface, imsClient IMSClientInterface, allocator *HubAllocator, policies BackorderPolicyStore) *OrderFinalizationHandler {
+	return &OrderFinalizationHandler{orderRepo: orderRepo, imsClient: imsClient, allocator: allocator, policies: policies}
+}
 
-
-func NewOrderFinalizationConsumer(brokers []string, topic string, orderRepo OrderRepositoryInterface, imsClient IMSClientInterface) (*OrderFinalizationConsumer, error) {
+func NewOrderFinalizationConsumer(brokers []string, topic string, handler *OrderFinalizationHandler) (*OrderFinalizationConsumer, error) {
 	config := sarama.NewConfig()
 	config.Version = sarama.V2_8_1_0
 	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
@@ -56,7 +71,6 @@ func NewOrderFinalizationConsumer(brokers []string, topic string, orderRepo Orde
 		return nil, fmt.Errorf("new group error: %w", err)
 	}
 
-	handler := &OrderFinalizationHandler{orderRepo: orderRepo, imsClient: imsClient}
 	ctx, cancel := context.WithCancel(context.Background())
 
 	fmt.Printf("Consumer ready for topic: %s\n", topic)
@@ -193,53 +207,184 @@ func (h *OrderFinalizationHandler) processMessage(ctx context.Context, message *
 
 	fmt.Printf("Status: %s\n", order.Status)
 
-	if order.Status != "on_hold" {
+	if !order.Status.IsWaiting() {
 		fmt.Println("Invalid status")
 		return fmt.Errorf("invalid status: %s", order.Status)
 	}
 
-	available, _, err := h.imsClient.CheckInventoryAvailability(order.SKU, order.Location, order.TenantID, order.SellerID)
-	if err != nil {
-		fmt.Println("Inventory check failed")
-		_ = h.orderRepo.UpdateOrderStatus(ctx, order.ID, "cancelled")
-		fmt.Println("Order cancelled due to error")
-		return fmt.Errorf("inventory error: %w", err)
-	}
-
-	if available {
-		fmt.Println("Stock is available. Attempting to reduce inventory...")
-		reduced, reduceErr := h.imsClient.ReduceInventory(order.SKU, order.Location, order.TenantID, order.SellerID, 1)
-		fmt.Printf("ReduceInventory Result: Success = %v, Error = %v\n", reduced, reduceErr)
-		if reduceErr != nil || !reduced {
-			fmt.Println("Action: Inventory reduction failed. Keeping order ON HOLD.")
-			_ = h.orderRepo.UpdateOrderStatus(ctx, order.ID, "on_hold")
-	
-			return fmt.Errorf("inventory reduction failed: %w", reduceErr)
-		}
+	return h.Finalize(ctx, order)
+}
+
+// Finalize reserves stock for a waiting order. It moves the order to new_order when IMS
+// has the stock, splits it when the tenant allows that, and otherwise keeps it waiting
+// as backordered or cancels it, following the tenant's backorder policy. The reduction
+// is tried before anything else: IMS applies it once per order ID, so an order whose
+// stock was taken before its status update failed is finalized on retry.
+func (h *OrderFinalizationHandler) Finalize(ctx context.Context, order *models.Order) error {
+	// a split order only retries its sub-shipments still on hold
+	if len(order.SubShipments) > 0 {
+		return h.fulfilSplit(ctx, order, order.SubShipments)
+	}
+
+	quantity := order.OrderedQuantity()
+	fmt.Println("Attempting to reduce inventory...")
+	reduced, reduceErr := h.imsClient.ReduceInventory(order.SKU, order.Location, order.TenantID, order.SellerID, quantity, order.ID)
+	fmt.Printf("ReduceInventory Result: Success = %v, Error = %v\n", reduced, reduceErr)
+	if reduceErr == nil && reduced {
 		fmt.Println("Inventory reduced successfully.")
 		fmt.Println("Action: Updating order status to NEW_ORDER.")
 		if err := h.orderRepo.UpdateOrderStatus(ctx, order.ID, "new_order"); err != nil {
 			fmt.Printf("Status Update Error: %v\n", err)
-		
 			return fmt.Errorf("finalize error: %w", err)
 		}
 		order.Status = "new_order"
 		fmt.Println("Order finalized successfully.")
 		// Log webhook event for order finalized
 		_ = webhook.LogWebhookEvent(ctx, "order.finalized", order)
+		return nil
+	}
+	if !errors.Is(reduceErr, ErrInsufficientStock) {
+		fmt.Println("Action: Inventory reduction failed. Keeping order ON HOLD.")
+		_ = h.wait(ctx, order, models.OrderStatusOnHold, fmt.Sprintf("inventory reduction failed: %v", reduceErr))
+		return fmt.Errorf("inventory reduction failed: %w", reduceErr)
+	}
+
+	// only used to explain the wait; a failed check counts as nothing on hand
+	_, onHand, err := h.imsClient.CheckInventoryAvailability(order.SKU, order.Location, order.TenantID, order.SellerID)
+	if err != nil {
+		fmt.Println("Inventory check failed:", err)
+	}
+
+	if shipments := h.planSplit(ctx, order); shipments != nil {
+		fmt.Println("Stock is NOT available at one hub.")
+		fmt.Printf("Action: Splitting order into %d sub-shipments.\n", len(shipments))
+		// the plan is saved before any stock is reduced, so a retry after a failed update
+		// reduces the same sub-shipments again, which IMS applies once per sub-shipment ID
+		if err := h.orderRepo.UpdateOrderFulfilment(ctx, order.ID, order.Status, shipments); err != nil {
+			fmt.Printf("Split Plan Error: %v\n", err)
+			return fmt.Errorf("split error: %w", err)
+		}
+		order.SubShipments = shipments
+		return h.fulfilSplit(ctx, order, shipments)
+	} else if h.backorders(ctx, order) {
+		fmt.Println("Stock is NOT available.")
+		fmt.Println("Action: Backordering order until stock arrives.")
+		wasBackordered := order.Status == models.OrderStatusBackordered
+		if err := h.wait(ctx, order, models.OrderStatusBackordered, fmt.Sprintf("insufficient stock: %d of %d on hand", onHand, quantity)); err != nil {
+			return fmt.Errorf("backorder error: %w", err)
+		}
+		if !wasBackordered {
+			_ = webhook.LogWebhookEvent(ctx, "order.backordered", order)
+		}
 	} else {
 		fmt.Println("Stock is NOT available.")
 		fmt.Println("Action: Cancelling order due to insufficient stock.")
+		return h.Cancel(ctx, order, "insufficient stock")
+	}
+
+	return nil
+}
+
+// Cancel cancels a waiting order. A split order cancels only the sub-shipments
+// still on hold and derives its status from the rest.
+func (h *OrderFinalizationHandler) Cancel(ctx context.Context, order *models.Order, reason string) error {
+	if len(order.SubShipments) > 0 {
+		for i := range order.SubShipments {
+			if s := &order.SubShipments[i]; s.Status == models.OrderStatusOnHold {
+				s.Status, s.Reason, s.UpdatedAt = models.OrderStatusCancelled, reason, time.Now()
+			}
+		}
+		status := models.DeriveStatus(order.SubShipments)
+		if err := h.orderRepo.UpdateOrderFulfilment(ctx, order.ID, status, order.SubShipments); err != nil {
+			fmt.Printf("Cancel Error: %v\n", err)
+			return fmt.Errorf("cancel error: %w", err)
+		}
+		order.Status = status
+	} else {
 		if err := h.orderRepo.UpdateOrderStatus(ctx, order.ID, "cancelled"); err != nil {
 			fmt.Printf("Cancel Error: %v\n", err)
-			
 			return fmt.Errorf("cancel error: %w", err)
 		}
 		order.Status = "cancelled"
-		fmt.Println("Order cancelled.")
-		// Log webhook event for order cancelled
-		_ = webhook.LogWebhookEvent(ctx, "order.cancelled", order)
+	}
+	fmt.Printf("Order %s cancelled: %s\n", order.ID, reason)
+	// Log webhook event for order cancelled
+	_ = webhook.LogWebhookEvent(ctx, "order.cancelled", order)
+	return nil
+}
+
+// wait keeps order waiting for stock in status and records why
+func (h *OrderFinalizationHandler) wait(ctx context.Context, order *models.Order, status models.OrderStatus, reason string) error {
+	if err := h.orderRepo.MarkOrderWaiting(ctx, order.ID, status, reason); err != nil {
+		fmt.Printf("Wait Update Error: %v\n", err)
+		return err
+	}
+	order.Status = status
+	order.WaitReason = reason
+	return nil
+}
+
+// backorders reports whether the tenant's policy keeps orders without stock waiting
+func (h *OrderFinalizationHandler) backorders(ctx context.Context, order *models.Order) bool {
+	if h.policies == nil {
+		return false
+	}
+	policy, err := h.policies.GetPolicy(ctx, order.TenantID)
+	if err != nil {
+		fmt.Printf("Backorder policy unavailable for tenant %s: %v\n", order.TenantID, err)
+		return true
+	}
+	return policy.Backorder
+}
+
+// planSplit divides order across hubs when its tenant allows split fulfilment.
+// It returns nil when splitting is off or the hubs together lack the stock.
+func (h *OrderFinalizationHandler) planSplit(ctx context.Context, order *models.Order) []models.SubShipment {
+	if h.allocator == nil {
+		return nil
+	}
+	shipments, err := h.allocator.PlanSplit(ctx, order)
+	if err != nil {
+		fmt.Printf("Split not possible for order %s: %v\n", order.ID, err)
+		return nil
+	}
+	return shipments
+}
+
+// fulfilSplit reduces stock for each sub-shipment on hold independently. A sub-shipment
+// IMS refuses stays on hold; the order's status is derived from all of them.
+func (h *OrderFinalizationHandler) fulfilSplit(ctx context.Context, order *models.Order, shipments []models.SubShipment) error {
+	for i := range shipments {
+		s := &shipments[i]
+		if s.Status != models.OrderStatusOnHold {
+			continue
+		}
+		reduced, err := h.imsClient.ReduceInventory(order.SKU, s.Location, order.TenantID, order.SellerID, s.Quantity, s.ID)
+		fmt.Printf("ReduceInventory %s at %s: Success = %v, Error = %v\n", s.ID, s.Location, reduced, err)
+		if err != nil || !reduced {
+			s.Status = models.OrderStatusOnHold
+			s.Reason = fmt.Sprintf("inventory reduction failed: %v", err)
+		} else {
+			s.Status = models.OrderStatusNewOrder
+			s.Reason = ""
+		}
+		s.UpdatedAt = time.Now()
 	}
 
+	status := models.DeriveStatus(shipments)
+	if err := h.orderRepo.UpdateOrderFulfilment(ctx, order.ID, status, shipments); err != nil {
+		fmt.Printf("Split Update Error: %v\n", err)
+		return fmt.Errorf("split error: %w", err)
+	}
+	order.Status = status
+	order.SubShipments = shipments
+	if status.IsWaiting() {
+		_ = h.wait(ctx, order, status, "sub-shipments waiting for stock")
+	}
+	fmt.Printf("Order split, status %s.\n", status)
+	_ = webhook.LogWebhookEvent(ctx, "order.split", order)
+	if status == models.OrderStatusNewOrder {
+		_ = webhook.LogWebhookEvent(ctx, "order.finalized", order)
+	}
 	return nil
 }
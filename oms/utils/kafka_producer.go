@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"oms/models"
+
 	"github.com/omniful/go_commons/kafka"
 	"github.com/omniful/go_commons/log"
 	"github.com/omniful/go_commons/pubsub"
@@ -19,6 +21,24 @@ type OrderCreatedEvent struct {
 	SellerID  string `json:"seller_id"`
 	Status    string `json:"status"`
 	CreatedAt string `json:"created_at"`
+
+	ExternalOrderID string              `json:"external_order_id,omitempty"`
+	Quantity        int                 `json:"quantity"`
+	Currency        string              `json:"currency,omitempty"`
+	Totals          *models.OrderTotals `json:"totals,omitempty"`
+	Priority        string              `json:"priority,omitempty"`
+	SLADeadline     string              `json:"sla_deadline,omitempty"`
+}
+
+// OrderSLABreachedEvent is published once when an open order passes its SLA deadline
+type OrderSLABreachedEvent struct {
+	OrderID     string `json:"order_id"`
+	TenantID    string `json:"tenant_id"`
+	SellerID    string `json:"seller_id"`
+	Priority    string `json:"priority"`
+	Status      string `json:"status"`
+	SLADeadline string `json:"sla_deadline"`
+	BreachedAt  string `json:"breached_at"`
 }
 
 
@@ -76,6 +96,19 @@ func (k *KafkaProducer) PublishOrderCreated(ctx context.Context, event OrderCrea
 
 
 
+// Publish sends payload as JSON to topic keyed by key
+func (k *KafkaProducer) Publish(ctx context.Context, topic, key string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", topic, err)
+	}
+	if err := k.producer.Publish(ctx, &pubsub.Message{Topic: topic, Value: data, Key: key}); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", topic, err)
+	}
+	log.Infof("Published %s event: %s", topic, key)
+	return nil
+}
+
 // Close Kafka producer
 func (k *KafkaProducer) Close() {
 	if k.producer != nil {
@@ -2,38 +2,40 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"oms/database"
 	"oms/models"
-	"oms/webhook"
 )
 
+// UploadResultStore keeps what became of each queued upload
+type UploadResultStore interface {
+	SaveUploadResult(ctx context.Context, result *models.UploadResult) error
+}
+
 type DefaultMessageHandler struct {
-	s3Downloader  *S3DownloaderImpl
-	csvParser     *CSVParser
-	orderRepo     *database.OrderRepository
-	imsClient     *IMSClient
-	validator     *CSVRowValidator
-	kafkaProducer *KafkaProducer
+	s3Downloader *S3DownloaderImpl
+	csvParser    *CSVParser
+	creator      *OrderCreator
+	results      UploadResultStore
 }
 
-func NewDefaultMessageHandler(s3Endpoint, s3Region string, orderRepo *database.OrderRepository, imsClient *IMSClient, kafkaProducer *KafkaProducer, s3Uploader *S3UploaderImpl) (*DefaultMessageHandler, error) {
+func NewDefaultMessageHandler(s3Endpoint, s3Region string, creator *OrderCreator, results UploadResultStore, s3Uploader *S3UploaderImpl) (*DefaultMessageHandler, error) {
 	s3Downloader, err := NewS3Downloader(s3Endpoint, s3Region)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create S3 downloader: %w", err)
 	}
 
 	csvParser := NewCSVParser(50)
-	validator := NewCSVRowValidator(imsClient)
 
 	return &DefaultMessageHandler{
-		s3Downloader:  s3Downloader,
-		csvParser:     csvParser,
-		orderRepo:     orderRepo,
-		imsClient:     imsClient,
-		validator:     validator,
-		kafkaProducer: kafkaProducer,
+		s3Downloader: s3Downloader,
+		csvParser:    csvParser,
+		creator:      creator,
+		results:      results,
 	}, nil
 }
 
@@ -48,48 +50,77 @@ func (d *DefaultMessageHandler) ProcessMessage(ctx context.Context, message *Con
 		return fmt.Errorf("invalid message content")
 	}
 
-	fmt.Printf("Downloading and processing CSV - RequestID: %s, Path: %s\n", message.RequestID, message.Path)
+	result := models.NewUploadResult(message.RequestID, message.Path, models.UploadProcessing)
+	d.saveResult(ctx, result)
+	if err := d.importUpload(ctx, message, result); err != nil {
+		result.Finish(err)
+		d.saveResult(ctx, result)
+		return err
+	}
+	result.Finish(nil)
+	d.saveResult(ctx, result)
+
+	fmt.Printf("CSV orders - Created: %d, Duplicates: %d, Invalid: %d\n", len(result.OrderIDs), len(result.Duplicates), len(result.Invalid))
+	fmt.Printf("CSV processing completed - RequestID: %s\n", message.RequestID)
+	return nil
+}
 
-	csvData, err := d.s3Downloader.DownloadFile(ctx, message.Path)
+// importUpload downloads and parses the uploaded file and creates its orders, recording
+// the outcome of every row on result
+func (d *DefaultMessageHandler) importUpload(ctx context.Context, message *ConsumerMessage, result *models.UploadResult) error {
+	format, err := DetectUploadFormat(message.Path)
 	if err != nil {
-		return fmt.Errorf("failed to download CSV: %w", err)
+		return err
 	}
 
-	parseResult, err := d.csvParser.ParseCSVFromBytes(ctx, csvData)
+	fmt.Printf("Downloading and processing %s - RequestID: %s, Path: %s\n", format, message.RequestID, message.Path)
+
+	data, err := d.s3Downloader.DownloadFile(ctx, message.Path)
 	if err != nil {
-		return fmt.Errorf("failed to parse CSV: %w", err)
+		return fmt.Errorf("failed to download %s: %w", format, err)
 	}
 
-	for _, row := range parseResult.ValidData {
-		if !d.validator.ValidateCSVRow(ctx, row).IsValid {
-			continue
-		}
-
-		order := models.NewOrder(row.SKU, row.Location, row.TenantID, row.SellerID)
-		if !order.IsValid() {
-			continue
-		}
+	parseResult, err := d.csvParser.ParseUpload(ctx, data, format, message.Sheet)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", format, err)
+	}
+	importRows(ctx, d.creator, parseResult, result)
+	return nil
+}
 
-		if err := d.orderRepo.SaveOrder(ctx, order); err != nil {
-			continue
-		}
-		// Log webhook event for order creation
-		_ = webhook.LogWebhookEvent(ctx, "order.created", order)
-
-		if d.kafkaProducer != nil {
-			event := OrderCreatedEvent{
-				OrderID:   order.ID,
-				SKU:       order.SKU,
-				Location:  order.Location,
-				TenantID:  order.TenantID,
-				SellerID:  order.SellerID,
-				Status:    string(order.Status),
-				CreatedAt: order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+// importRows creates an order for each valid row and records on result the orders
+// created, the duplicates and, with the rows that failed parsing, the rejected rows
+func importRows(ctx context.Context, creator *OrderCreator, parseResult *CSVParseResult, result *models.UploadResult) {
+	result.TotalRows = parseResult.TotalRows
+	for _, row := range parseResult.InvalidData {
+		result.Invalid = append(result.Invalid, models.UploadRowError{Row: row.RowNumber, Error: parseResult.RowErrors[row.RowNumber]})
+	}
+	for _, row := range parseResult.ValidData {
+		order, err := creator.CreateOrder(ctx, row)
+		if err != nil {
+			fmt.Printf("Row %d skipped: %v\n", row.RowNumber, err)
+			if errors.Is(err, database.ErrDuplicateOrder) {
+				duplicate := models.UploadDuplicate{Row: row.RowNumber, ExternalOrderID: strings.TrimSpace(row.ExternalOrderID)}
+				if order != nil {
+					duplicate.OrderID = order.ID
+				}
+				result.Duplicates = append(result.Duplicates, duplicate)
+				continue
 			}
-			_ = d.kafkaProducer.PublishOrderCreated(ctx, event)
+			result.Invalid = append(result.Invalid, models.UploadRowError{Row: row.RowNumber, Error: err.Error()})
+			continue
 		}
+		result.OrderIDs = append(result.OrderIDs, order.ID)
 	}
+}
 
-	fmt.Printf("CSV processing completed - RequestID: %s\n", message.RequestID)
-	return nil
+// saveResult stores result; an upload whose result cannot be saved is still imported
+func (d *DefaultMessageHandler) saveResult(ctx context.Context, result *models.UploadResult) {
+	if d.results == nil {
+		return
+	}
+	result.UpdatedAt = time.Now()
+	if err := d.results.SaveUploadResult(ctx, result); err != nil {
+		fmt.Printf("ERROR: Upload result not saved - RequestID: %s: %v\n", result.RequestID, err)
+	}
 }
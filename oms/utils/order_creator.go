@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"oms/database"
+	"oms/models"
+	"oms/webhook"
+)
+
+// ErrOrderRejected marks a row that failed validation and was not stored
+var ErrOrderRejected = errors.New("order rejected")
+
+// OrderIntakeStore is the order storage OrderCreator looks duplicates up in and saves to
+type OrderIntakeStore interface {
+	GetOrderByExternalID(ctx context.Context, tenantID, externalOrderID string) (*models.Order, error)
+	SaveOrder(ctx context.Context, order *models.Order) error
+}
+
+// RowValidator checks a row's SKU and hub; CSVRowValidator checks them against IMS
+type RowValidator interface {
+	ValidateCSVRow(ctx context.Context, row CSVRow) ValidationResult
+}
+
+// OrderCreator turns a row from any intake (CSV upload or JSON API) into a stored order.
+// Every row is validated against IMS, saved, published on Kafka and logged as a webhook.
+// Rows without a location are allocated a hub by the tenant's allocation rule.
+type OrderCreator struct {
+	orderRepo     OrderIntakeStore
+	validator     RowValidator
+	allocator     *HubAllocator
+	slaPolicies   SLAPolicyStore
+	kafkaProducer *KafkaProducer
+}
+
+func NewOrderCreator(orderRepo OrderIntakeStore, imsClient *IMSClient, allocator *HubAllocator, slaPolicies SLAPolicyStore, kafkaProducer *KafkaProducer) *OrderCreator {
+	return &OrderCreator{
+		orderRepo:     orderRepo,
+		validator:     NewCSVRowValidator(imsClient),
+		allocator:     allocator,
+		slaPolicies:   slaPolicies,
+		kafkaProducer: kafkaProducer,
+	}
+}
+
+// CreateOrder validates and persists one row. Validation failures wrap ErrOrderRejected.
+// A row whose external_order_id the tenant already used returns the existing order
+// with an error wrapping database.ErrDuplicateOrder.
+func (o *OrderCreator) CreateOrder(ctx context.Context, row CSVRow) (*models.Order, error) {
+	if err := validateRowFields(row); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOrderRejected, err)
+	}
+	row.ExternalOrderID = strings.TrimSpace(row.ExternalOrderID)
+	row.Location = strings.TrimSpace(row.Location)
+	if row.ExternalOrderID != "" {
+		if existing, err := o.orderRepo.GetOrderByExternalID(ctx, row.TenantID, row.ExternalOrderID); err == nil {
+			return existing, fmt.Errorf("%w: %s", database.ErrDuplicateOrder, row.ExternalOrderID)
+		}
+	}
+	if result := o.validator.ValidateCSVRow(ctx, row); !result.IsValid {
+		return nil, fmt.Errorf("%w: %s", ErrOrderRejected, result.Reason)
+	}
+
+	order := models.NewOrder(row.SKU, row.Location, row.TenantID, row.SellerID)
+	order.ExternalOrderID = row.ExternalOrderID
+	priority, err := models.ParsePriority(row.Priority)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOrderRejected, err)
+	}
+	order.Priority = priority
+	if err := o.applySLA(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to compute SLA deadline: %w", err)
+	}
+	if err := row.applyContact(order); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOrderRejected, err)
+	}
+	if err := row.applyPricing(order); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOrderRejected, err)
+	}
+	if order.Location == "" {
+		allocation, err := o.allocator.Allocate(ctx, order)
+		if err != nil {
+			return nil, fmt.Errorf("%w: allocation failed: %v", ErrOrderRejected, err)
+		}
+		order.Location = allocation.Hub
+		order.Allocation = allocation
+	}
+	if !order.IsValid() {
+		return nil, fmt.Errorf("%w: incomplete order", ErrOrderRejected)
+	}
+
+	if err := o.orderRepo.SaveOrder(ctx, order); err != nil {
+		if errors.Is(err, database.ErrDuplicateOrder) {
+			existing, _ := o.orderRepo.GetOrderByExternalID(ctx, row.TenantID, row.ExternalOrderID)
+			return existing, err
+		}
+		return nil, fmt.Errorf("failed to save order: %w", err)
+	}
+	// Log webhook event for order creation
+	_ = webhook.LogWebhookEvent(ctx, "order.created", order)
+
+	if o.kafkaProducer != nil {
+		if err := o.kafkaProducer.PublishOrderCreated(ctx, NewOrderCreatedEvent(order)); err != nil {
+			fmt.Printf("Kafka publish failed for order %s: %v\n", order.ID, err)
+		}
+	}
+	return order, nil
+}
+
+// applySLA sets the order's deadline from its tenant's cut-offs
+func (o *OrderCreator) applySLA(ctx context.Context, order *models.Order) error {
+	if o.slaPolicies == nil {
+		return nil
+	}
+	policy, err := o.slaPolicies.GetPolicy(ctx, order.TenantID)
+	if err != nil {
+		return err
+	}
+	return order.ApplySLA(policy)
+}
+
+// NewOrderCreatedEvent builds the order.created payload for order
+func NewOrderCreatedEvent(order *models.Order) OrderCreatedEvent {
+	return OrderCreatedEvent{
+		OrderID:   order.ID,
+		SKU:       order.SKU,
+		Location:  order.Location,
+		TenantID:  order.TenantID,
+		SellerID:  order.SellerID,
+		Status:    string(order.Status),
+		CreatedAt: order.CreatedAt.Format(time.RFC3339),
+
+		ExternalOrderID: order.ExternalOrderID,
+		Quantity:        order.OrderedQuantity(),
+		Currency:        order.Currency,
+		Totals:          order.Totals,
+		Priority:        string(order.Priority),
+		SLADeadline:     formatTime(order.SLADeadline),
+	}
+}
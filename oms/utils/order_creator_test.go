@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"oms/database"
+	"oms/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIntakeRepo keeps orders by external ID. A racer, when set, is saved by "another
+// request" just before this one saves, so the save hits the unique index.
+type fakeIntakeRepo struct {
+	byExternalID map[string]*models.Order
+	saved        []*models.Order
+	racer        *models.Order
+}
+
+func (r *fakeIntakeRepo) GetOrderByExternalID(_ context.Context, _, externalOrderID string) (*models.Order, error) {
+	if order, ok := r.byExternalID[externalOrderID]; ok {
+		return order, nil
+	}
+	return nil, errors.New("order not found")
+}
+
+func (r *fakeIntakeRepo) SaveOrder(_ context.Context, order *models.Order) error {
+	if r.racer != nil {
+		r.byExternalID[r.racer.ExternalOrderID] = r.racer
+		r.racer = nil
+	}
+	if _, ok := r.byExternalID[order.ExternalOrderID]; ok && order.ExternalOrderID != "" {
+		return fmt.Errorf("%w: %s", database.ErrDuplicateOrder, order.ExternalOrderID)
+	}
+	r.byExternalID[order.ExternalOrderID] = order
+	r.saved = append(r.saved, order)
+	return nil
+}
+
+// acceptRows passes every row as if IMS knew its SKU and hub
+type acceptRows struct{}
+
+func (acceptRows) ValidateCSVRow(context.Context, CSVRow) ValidationResult {
+	return ValidationResult{IsValid: true, SKUValid: true, HubValid: true}
+}
+
+func newTestCreator(existing ...*models.Order) (*OrderCreator, *fakeIntakeRepo) {
+	repo := &fakeIntakeRepo{byExternalID: map[string]*models.Order{}}
+	for _, order := range existing {
+		repo.byExternalID[order.ExternalOrderID] = order
+	}
+	return &OrderCreator{orderRepo: repo, validator: acceptRows{}}, repo
+}
+
+func intakeRow(externalOrderID string) CSVRow {
+	return CSVRow{SKU: "SKU-1", Location: "hub-a", TenantID: "tenant-1", SellerID: "seller-1", ExternalOrderID: externalOrderID, RowNumber: 1}
+}
+
+func existingOrder(externalOrderID string) *models.Order {
+	order := models.NewOrder("SKU-1", "hub-a", "tenant-1", "seller-1")
+	order.ExternalOrderID = externalOrderID
+	return order
+}
+
+func TestCreateOrderReturnsExistingDuplicate(t *testing.T) {
+	existing := existingOrder("EXT-1")
+	creator, repo := newTestCreator(existing)
+
+	order, err := creator.CreateOrder(context.Background(), intakeRow(" EXT-1 "))
+	assert.True(t, errors.Is(err, database.ErrDuplicateOrder))
+	assert.Equal(t, existing.ID, order.ID)
+	assert.Empty(t, repo.saved)
+}
+
+func TestCreateOrderLosesSaveRace(t *testing.T) {
+	creator, repo := newTestCreator()
+	winner := existingOrder("EXT-2")
+	repo.racer = winner
+
+	order, err := creator.CreateOrder(context.Background(), intakeRow("EXT-2"))
+	assert.True(t, errors.Is(err, database.ErrDuplicateOrder))
+	if assert.NotNil(t, order) {
+		assert.Equal(t, winner.ID, order.ID)
+	}
+	assert.Empty(t, repo.saved)
+}
+
+func TestImportRowsRecordsEveryRow(t *testing.T) {
+	existing := existingOrder("EXT-1")
+	creator, _ := newTestCreator(existing)
+	fresh, duplicate, rejected := intakeRow("EXT-9"), intakeRow("EXT-1"), intakeRow("EXT-8")
+	duplicate.RowNumber, rejected.RowNumber = 2, 4
+	rejected.Priority = "whenever"
+	parsed := &CSVParseResult{
+		TotalRows:   4,
+		ValidData:   []CSVRow{fresh, duplicate, rejected},
+		InvalidData: []CSVRow{{RowNumber: 3}},
+		RowErrors:   map[int]string{3: "SKU is empty"},
+	}
+
+	result := models.NewUploadResult("req-1", "uploads/orders.csv", models.UploadProcessing)
+	importRows(context.Background(), creator, parsed, result)
+
+	assert.Equal(t, 4, result.TotalRows)
+	assert.Len(t, result.OrderIDs, 1)
+	assert.Equal(t, []models.UploadDuplicate{{Row: 2, ExternalOrderID: "EXT-1", OrderID: existing.ID}}, result.Duplicates)
+	if assert.Len(t, result.Invalid, 2) {
+		assert.Equal(t, models.UploadRowError{Row: 3, Error: "SKU is empty"}, result.Invalid[0])
+		assert.Equal(t, 4, result.Invalid[1].Row)
+		assert.Contains(t, result.Invalid[1].Error, "priority")
+	}
+}
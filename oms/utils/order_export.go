@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"oms/database"
+	"oms/models"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// orderExportColumns are the columns of CSV and XLSX exports
+var orderExportColumns = []string{
+	"order_id", "external_order_id", "tenant_id", "seller_id", "sku", "location", "quantity",
+	"status", "priority", "currency", "total", "customer_name", "shipping_city", "shipping_country",
+	"sla_deadline", "created_at", "updated_at",
+}
+
+// exportRecord is order's row in a CSV or XLSX export
+func exportRecord(o *models.Order) []string {
+	var total, customer, city, country string
+	if o.Totals != nil {
+		total = o.Totals.Total.String()
+	}
+	if o.Customer != nil {
+		customer = o.Customer.Name
+	}
+	if o.ShippingAddress != nil {
+		city, country = o.ShippingAddress.City, o.ShippingAddress.Country
+	}
+	return []string{
+		o.ID, o.ExternalOrderID, o.TenantID, o.SellerID, o.SKU, o.Location, strconv.Itoa(o.OrderedQuantity()),
+		string(o.Status), string(o.Priority), o.Currency, total, customer, city, country,
+		formatTime(o.SLADeadline), o.CreatedAt.UTC().Format(time.RFC3339), o.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// OrderExportWriter writes orders one at a time in an export format. Close must be
+// called to flush the output.
+type OrderExportWriter interface {
+	Write(order *models.Order) error
+	Close() error
+}
+
+// NewOrderExportWriter writes format to w. CSV and NDJSON are written as orders arrive;
+// XLSX is assembled by excelize (spilling to temporary files when large) and written on Close.
+func NewOrderExportWriter(w io.Writer, format models.ExportFormat) (OrderExportWriter, error) {
+	switch format {
+	case models.ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(orderExportColumns); err != nil {
+			return nil, err
+		}
+		return &csvExportWriter{w: cw}, nil
+	case models.ExportNDJSON:
+		return &ndjsonExportWriter{enc: json.NewEncoder(w)}, nil
+	case models.ExportXLSX:
+		return newXLSXExportWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (e *csvExportWriter) Write(order *models.Order) error {
+	return e.w.Write(exportRecord(order))
+}
+
+func (e *csvExportWriter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ndjsonExportWriter writes each order as one JSON document per line
+type ndjsonExportWriter struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonExportWriter) Write(order *models.Order) error {
+	return e.enc.Encode(order)
+}
+
+func (e *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+type xlsxExportWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		return nil, err
+	}
+	e := &xlsxExportWriter{out: w, file: file, stream: stream, row: 1}
+	if err := e.writeRow(orderExportColumns); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *xlsxExportWriter) writeRow(values []string) error {
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		cells[i] = v
+	}
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	e.row++
+	return e.stream.SetRow(cell, cells)
+}
+
+func (e *xlsxExportWriter) Write(order *models.Order) error {
+	return e.writeRow(exportRecord(order))
+}
+
+func (e *xlsxExportWriter) Close() error {
+	defer e.file.Close()
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := e.file.WriteTo(e.out)
+	return err
+}
+
+// OrderExporter exports the orders matching ListOrders filters, either streamed
+// to the caller or as a background job that uploads the file to S3
+type OrderExporter struct {
+	orderRepo  *database.OrderRepository
+	jobs       *database.ExportJobRepository
+	s3Uploader *S3UploaderImpl
+	linkTTL    time.Duration
+}
+
+func NewOrderExporter(orderRepo *database.OrderRepository, jobs *database.ExportJobRepository, s3Uploader *S3UploaderImpl, linkTTL time.Duration) *OrderExporter {
+	return &OrderExporter{orderRepo: orderRepo, jobs: jobs, s3Uploader: s3Uploader, linkTTL: linkTTL}
+}
+
+// Stream writes the matching orders to w in format, newest first, and returns how many it wrote
+func (e *OrderExporter) Stream(ctx context.Context, w io.Writer, format models.ExportFormat, filters map[string]string) (int64, error) {
+	writer, err := NewOrderExportWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	var rows int64
+	err = e.orderRepo.StreamOrdersByFilter(ctx, filters, func(order *models.Order) error {
+		rows++
+		return writer.Write(order)
+	})
+	if err != nil {
+		return rows, err
+	}
+	return rows, writer.Close()
+}
+
+// StartJob records an export job for the total matching orders and runs it in the background
+func (e *OrderExporter) StartJob(ctx context.Context, format models.ExportFormat, filters map[string]string, total int64) (*models.ExportJob, error) {
+	job := models.NewExportJob(format, filters, total)
+	if err := e.jobs.SaveJob(ctx, job); err != nil {
+		return nil, err
+	}
+	go e.run(context.Background(), job)
+	return job, nil
+}
+
+// GetJob returns an export job with a fresh download link once it completed
+func (e *OrderExporter) GetJob(ctx context.Context, jobID string) (*models.ExportJob, string, error) {
+	job, err := e.jobs.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, "", err
+	}
+	if job.Status != models.ExportCompleted {
+		return job, "", nil
+	}
+	link, err := e.s3Uploader.PresignDownload(ctx, job.S3Path, e.linkTTL)
+	if err != nil {
+		return nil, "", err
+	}
+	return job, link, nil
+}
+
+// run writes the export to a temporary file, uploads it and records the outcome on the job
+func (e *OrderExporter) run(ctx context.Context, job *models.ExportJob) {
+	job.Status = models.ExportRunning
+	e.save(ctx, job)
+
+	s3Path, rows, err := e.export(ctx, job)
+	now := time.Now()
+	job.Rows = rows
+	if err != nil {
+		fmt.Printf("ERROR: Export job %s failed: %v\n", job.ID, err)
+		job.Status, job.Error = models.ExportFailed, err.Error()
+	} else {
+		fmt.Printf("Export job %s completed: %d orders at %s\n", job.ID, rows, s3Path)
+		job.Status, job.S3Path, job.CompletedAt = models.ExportCompleted, s3Path, &now
+	}
+	e.save(ctx, job)
+}
+
+func (e *OrderExporter) export(ctx context.Context, job *models.ExportJob) (string, int64, error) {
+	tmp, err := os.CreateTemp("", "order-export-*."+string(job.Format))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	rows, err := e.Stream(ctx, tmp, job.Format, job.Filters)
+	if err != nil {
+		return "", rows, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", rows, err
+	}
+	s3Path, err := e.s3Uploader.UploadExport(ctx, tmp, job.Filename(), job.Format.ContentType())
+	return s3Path, rows, err
+}
+
+func (e *OrderExporter) save(ctx context.Context, job *models.ExportJob) {
+	job.UpdatedAt = time.Now()
+	if err := e.jobs.UpdateJob(ctx, job); err != nil {
+		fmt.Printf("ERROR: Failed to update export job %s: %v\n", job.ID, err)
+	}
+}
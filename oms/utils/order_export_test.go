@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"oms/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func exportOrders() []*models.Order {
+	created := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	total, _ := models.NewAmount("21.50")
+	return []*models.Order{
+		{ID: "ORD-1", SKU: "SKU-1", Location: "hub-a", TenantID: "t1", SellerID: "s1", Status: models.OrderStatusNewOrder,
+			Quantity: 2, Priority: models.PriorityExpress, Currency: "USD", Totals: &models.OrderTotals{Total: total},
+			Customer: &models.Customer{Name: "Asha"}, ShippingAddress: &models.Address{City: "Pune", Country: "IN"},
+			CreatedAt: created, UpdatedAt: created},
+		{ID: "ORD-2", SKU: "SKU-2", Location: "hub-b", TenantID: "t1", SellerID: "s1", Status: models.OrderStatusOnHold,
+			CreatedAt: created, UpdatedAt: created},
+	}
+}
+
+func writeExport(t *testing.T, format models.ExportFormat) []byte {
+	var buf bytes.Buffer
+	writer, err := NewOrderExportWriter(&buf, format)
+	assert.NoError(t, err)
+	for _, o := range exportOrders() {
+		assert.NoError(t, writer.Write(o))
+	}
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestCSVOrderExport(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeExport(t, models.ExportCSV))).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, orderExportColumns, records[0])
+		assert.Equal(t, []string{"ORD-1", "", "t1", "s1", "SKU-1", "hub-a", "2", "new_order", "express", "USD", "21.5",
+			"Asha", "Pune", "IN", "", "2024-07-01T10:00:00Z", "2024-07-01T10:00:00Z"}, records[1])
+		assert.Equal(t, "1", records[2][6], "orders without a quantity export as one")
+	}
+}
+
+func TestNDJSONOrderExport(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(writeExport(t, models.ExportNDJSON))), "\n")
+	if assert.Len(t, lines, 2) {
+		var order models.Order
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &order))
+		assert.Equal(t, "ORD-2", order.ID)
+		assert.Equal(t, models.OrderStatusOnHold, order.Status)
+	}
+}
+
+func TestXLSXOrderExport(t *testing.T) {
+	file, err := excelize.OpenReader(bytes.NewReader(writeExport(t, models.ExportXLSX)))
+	assert.NoError(t, err)
+	defer file.Close()
+
+	rows, err := file.GetRows("Sheet1")
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, orderExportColumns, rows[0])
+		assert.Equal(t, "ORD-2", rows[2][0])
+	}
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"oms/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOrderRepo keeps orders in memory; failStatusUpdates fails that many status updates
+type fakeOrderRepo struct {
+	orders            map[string]*models.Order
+	failStatusUpdates int
+}
+
+func newFakeOrderRepo(orders ...*models.Order) *fakeOrderRepo {
+	repo := &fakeOrderRepo{orders: make(map[string]*models.Order)}
+	for _, o := range orders {
+		repo.orders[o.ID] = o
+	}
+	return repo
+}
+
+func (r *fakeOrderRepo) SaveOrder(_ context.Context, order *models.Order) error {
+	r.orders[order.ID] = order
+	return nil
+}
+
+func (r *fakeOrderRepo) GetOrders(context.Context, int, int) ([]models.Order, error) { return nil, nil }
+
+func (r *fakeOrderRepo) GetOrdersByFilter(context.Context, map[string]string, int, int) ([]models.Order, error) {
+	return nil, nil
+}
+
+func (r *fakeOrderRepo) GetOrderByID(_ context.Context, orderID string) (*models.Order, error) {
+	order, ok := r.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("order not found: %s", orderID)
+	}
+	copied := *order
+	copied.SubShipments = append([]models.SubShipment(nil), order.SubShipments...)
+	return &copied, nil
+}
+
+func (r *fakeOrderRepo) UpdateOrderStatus(_ context.Context, orderID string, status models.OrderStatus) error {
+	if r.failStatusUpdates > 0 {
+		r.failStatusUpdates--
+		return errors.New("mongo unavailable")
+	}
+	r.orders[orderID].Status = status
+	return nil
+}
+
+func (r *fakeOrderRepo) UpdateOrderFulfilment(_ context.Context, orderID string, status models.OrderStatus, shipments []models.SubShipment) error {
+	r.orders[orderID].Status = status
+	r.orders[orderID].SubShipments = append([]models.SubShipment(nil), shipments...)
+	return nil
+}
+
+func (r *fakeOrderRepo) MarkOrderWaiting(_ context.Context, orderID string, status models.OrderStatus, reason string) error {
+	r.orders[orderID].Status = status
+	r.orders[orderID].WaitReason = reason
+	return nil
+}
+
+func (r *fakeOrderRepo) GetWaitingOrders(_ context.Context, tenantID, sku string, limit int) ([]models.Order, error) {
+	var waiting []models.Order
+	for _, o := range r.orders {
+		if o.Status.IsWaiting() && (tenantID == "" || o.TenantID == tenantID) && (sku == "" || o.SKU == sku) {
+			waiting = append(waiting, *o)
+		}
+	}
+	return waiting, nil
+}
+
+// fakeIMS holds stock per location and, like IMS, applies one reduction per order ID
+type fakeIMS struct {
+	stock   map[string]int
+	reduced map[string]int
+}
+
+func newFakeIMS(stock map[string]int) *fakeIMS {
+	return &fakeIMS{stock: stock, reduced: make(map[string]int)}
+}
+
+func (f *fakeIMS) GetSKUs(string) ([]SKU, error)                       { return nil, nil }
+func (f *fakeIMS) GetHubs(string) ([]Hub, error)                       { return nil, nil }
+func (f *fakeIMS) GetInventory(string) ([]Inventory, error)            { return nil, nil }
+func (f *fakeIMS) GetSKUInventory(string, string) ([]Inventory, error) { return nil, nil }
+func (f *fakeIMS) ValidateSKU(string, string, string) (bool, error)    { return true, nil }
+func (f *fakeIMS) ValidateHub(string, string, string) (bool, error)    { return true, nil }
+
+func (f *fakeIMS) CheckInventoryAvailability(_, location, _, _ string) (bool, int, error) {
+	return f.stock[location] > 0, f.stock[location], nil
+}
+
+func (f *fakeIMS) ReduceInventory(_, location, _, _ string, quantity int, orderID string) (bool, error) {
+	if _, ok := f.reduced[orderID]; ok {
+		return true, nil
+	}
+	if f.stock[location] < quantity {
+		return false, ErrInsufficientStock
+	}
+	f.stock[location] -= quantity
+	f.reduced[orderID] = quantity
+	return true, nil
+}
+
+type fakePolicies struct{ policy models.BackorderPolicy }
+
+func (p fakePolicies) GetPolicy(_ context.Context, tenantID string) (models.BackorderPolicy, error) {
+	policy := p.policy
+	policy.TenantID = tenantID
+	return policy, nil
+}
+
+func waitingOrder(quantity int) *models.Order {
+	order := models.NewOrder("SKU-1", "hub-a", "tenant-1", "seller-1")
+	order.Quantity = quantity
+	order.Status = models.OrderStatusOnHold
+	return order
+}
+
+func TestFinalizeReducesStock(t *testing.T) {
+	order := waitingOrder(2)
+	repo, ims := newFakeOrderRepo(order), newFakeIMS(map[string]int{"hub-a": 5})
+	handler := NewOrderFinalizationHandler(repo, ims, nil, fakePolicies{models.DefaultBackorderPolicy("")})
+
+	assert.NoError(t, handler.Finalize(context.Background(), order))
+	assert.Equal(t, models.OrderStatusNewOrder, repo.orders[order.ID].Status)
+	assert.Equal(t, 3, ims.stock["hub-a"])
+}
+
+func TestFinalizeRetryAfterStatusUpdateFailed(t *testing.T) {
+	order := waitingOrder(2)
+	repo, ims := newFakeOrderRepo(order), newFakeIMS(map[string]int{"hub-a": 2})
+	repo.failStatusUpdates = 1
+	policies := fakePolicies{models.BackorderPolicy{}}
+	handler := NewOrderFinalizationHandler(repo, ims, nil, policies)
+
+	assert.Error(t, handler.Finalize(context.Background(), order))
+	assert.Equal(t, 0, ims.stock["hub-a"])
+	assert.Equal(t, models.OrderStatusOnHold, repo.orders[order.ID].Status)
+
+	// the stock is gone now, but it was taken for this order: the retry finalizes it
+	// rather than cancelling it for lack of stock
+	NewBackorderScheduler(repo, handler, policies, time.Hour).RetryWaiting(context.Background(), "", "")
+	assert.Equal(t, models.OrderStatusNewOrder, repo.orders[order.ID].Status)
+	assert.Equal(t, 0, ims.stock["hub-a"])
+}
+
+func TestFinalizeWithoutStock(t *testing.T) {
+	backordered := waitingOrder(3)
+	cancelled := waitingOrder(3)
+	ims := newFakeIMS(map[string]int{"hub-a": 1})
+
+	repo := newFakeOrderRepo(backordered)
+	handler := NewOrderFinalizationHandler(repo, ims, nil, fakePolicies{models.BackorderPolicy{Backorder: true}})
+	assert.NoError(t, handler.Finalize(context.Background(), backordered))
+	assert.Equal(t, models.OrderStatusBackordered, repo.orders[backordered.ID].Status)
+	assert.Equal(t, "insufficient stock: 1 of 3 on hand", repo.orders[backordered.ID].WaitReason)
+
+	repo = newFakeOrderRepo(cancelled)
+	handler = NewOrderFinalizationHandler(repo, ims, nil, fakePolicies{models.BackorderPolicy{}})
+	assert.NoError(t, handler.Finalize(context.Background(), cancelled))
+	assert.Equal(t, models.OrderStatusCancelled, repo.orders[cancelled.ID].Status)
+	assert.Equal(t, 1, ims.stock["hub-a"])
+}
+
+func TestFinalizeSplitRetriesSubShipmentsOnHold(t *testing.T) {
+	order := waitingOrder(3)
+	order.SubShipments = []models.SubShipment{
+		{ID: order.ID + "-S1", Location: "hub-a", Quantity: 2, Status: models.OrderStatusOnHold},
+		{ID: order.ID + "-S2", Location: "hub-b", Quantity: 1, Status: models.OrderStatusOnHold},
+	}
+	repo, ims := newFakeOrderRepo(order), newFakeIMS(map[string]int{"hub-a": 2})
+	handler := NewOrderFinalizationHandler(repo, ims, nil, fakePolicies{models.BackorderPolicy{}})
+
+	assert.NoError(t, handler.Finalize(context.Background(), order))
+	stored := repo.orders[order.ID]
+	assert.Equal(t, models.OrderStatusOnHold, stored.Status)
+	assert.Equal(t, models.OrderStatusNewOrder, stored.SubShipments[0].Status)
+	assert.Equal(t, models.OrderStatusOnHold, stored.SubShipments[1].Status)
+
+	ims.stock["hub-b"] = 1
+	retried, _ := repo.GetOrderByID(context.Background(), order.ID)
+	assert.NoError(t, handler.Finalize(context.Background(), retried))
+	assert.Equal(t, models.OrderStatusNewOrder, repo.orders[order.ID].Status)
+	assert.Equal(t, 0, ims.stock["hub-a"])
+	assert.Equal(t, 0, ims.stock["hub-b"])
+
+	// cancelling a split order only cancels what is still on hold
+	assert.NoError(t, handler.Cancel(context.Background(), retried, "customer request"))
+	assert.Equal(t, models.OrderStatusNewOrder, repo.orders[order.ID].SubShipments[0].Status)
+}
+
+func TestRetryWaitingCancelsExpiredOrders(t *testing.T) {
+	order := waitingOrder(1)
+	order.CreatedAt = time.Now().Add(-48 * time.Hour)
+	repo, ims := newFakeOrderRepo(order), newFakeIMS(map[string]int{"hub-a": 5})
+	policies := fakePolicies{models.BackorderPolicy{OnHoldWaitHours: 24}}
+	handler := NewOrderFinalizationHandler(repo, ims, nil, policies)
+
+	NewBackorderScheduler(repo, handler, policies, time.Hour).RetryWaiting(context.Background(), "tenant-1", "SKU-1")
+	assert.Equal(t, models.OrderStatusCancelled, repo.orders[order.ID].Status)
+	assert.Equal(t, 5, ims.stock["hub-a"])
+}
+
+func (r *fakeOrderRepo) AdvanceOrderStatus(_ context.Context, orderID string, from, status models.OrderStatus) (bool, error) {
+	order, ok := r.orders[orderID]
+	if !ok || order.Status != from {
+		return false, nil
+	}
+	order.Status = status
+	return true, nil
+}
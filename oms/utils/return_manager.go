@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"oms/database"
+	"oms/models"
+	"oms/webhook"
+)
+
+var (
+	// ErrInvalidReturn marks return lines or dispositions that failed validation
+	ErrInvalidReturn = errors.New("invalid return")
+	// ErrRestockFailed marks a receipt IMS did not accept; the return stays approved so it can be retried
+	ErrRestockFailed = errors.New("IMS restock failed")
+)
+
+// ReturnEvent is published on every step of a return request
+type ReturnEvent struct {
+	Event      string              `json:"event"`
+	ReturnID   string              `json:"return_id"`
+	OrderID    string              `json:"order_id"`
+	TenantID   string              `json:"tenant_id"`
+	SellerID   string              `json:"seller_id"`
+	Location   string              `json:"location"`
+	Status     string              `json:"status"`
+	Lines      []models.ReturnLine `json:"lines"`
+	Reason     string              `json:"reason,omitempty"`
+	OccurredAt string              `json:"occurred_at"`
+}
+
+// ReturnManager runs the RMA workflow: a return is requested against a delivered order,
+// approved or rejected, and on receipt restocked in IMS. Each step is logged as a
+// webhook event and published on Kafka.
+type ReturnManager struct {
+	returnRepo    *database.ReturnRepository
+	orderRepo     *database.OrderRepository
+	imsClient     *IMSClient
+	kafkaProducer *KafkaProducer
+	topic         string
+}
+
+func NewReturnManager(returnRepo *database.ReturnRepository, orderRepo *database.OrderRepository, imsClient *IMSClient, kafkaProducer *KafkaProducer, topic string) *ReturnManager {
+	return &ReturnManager{
+		returnRepo:    returnRepo,
+		orderRepo:     orderRepo,
+		imsClient:     imsClient,
+		kafkaProducer: kafkaProducer,
+		topic:         topic,
+	}
+}
+
+// Request opens a return for lines of a delivered order and links it to the order
+func (m *ReturnManager) Request(ctx context.Context, orderID string, lines []models.ReturnLine) (*models.ReturnRequest, error) {
+	order, err := m.orderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := m.returnRepo.GetReturnsByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		lines[i].SKU = strings.TrimSpace(lines[i].SKU)
+		lines[i].Disposition = ""
+	}
+	ret, err := models.NewReturnRequest(order, lines, previous)
+	if err != nil {
+		if errors.Is(err, models.ErrOrderNotReturnable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReturn, err)
+	}
+	// the order's count, not previous, decides when requests race for the same units
+	if err := m.orderRepo.ReserveOrderReturn(ctx, order, ret.ID, ret.Lines, models.ReturnedQuantities(previous)); err != nil {
+		if errors.Is(err, database.ErrReturnExceedsOrder) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidReturn, err)
+		}
+		return nil, err
+	}
+	if err := m.returnRepo.SaveReturn(ctx, ret); err != nil {
+		if releaseErr := m.orderRepo.ReleaseOrderReturn(ctx, orderID, ret.Lines); releaseErr != nil {
+			fmt.Printf("ERROR: Return %s not saved and still counted on order %s: %v\n", ret.ID, orderID, releaseErr)
+		}
+		return nil, err
+	}
+	m.emit(ctx, "return.requested", ret)
+	return ret, nil
+}
+
+// Approve accepts a requested return so it can be shipped back
+func (m *ReturnManager) Approve(ctx context.Context, returnID string) (*models.ReturnRequest, error) {
+	return m.transition(ctx, returnID, models.ReturnApproved, func(ret *models.ReturnRequest) error { return nil })
+}
+
+// Reject closes a return that was not received, recording why
+func (m *ReturnManager) Reject(ctx context.Context, returnID, reason string) (*models.ReturnRequest, error) {
+	ret, err := m.transition(ctx, returnID, models.ReturnRejected, func(ret *models.ReturnRequest) error {
+		ret.RejectReason = reason
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the rejected units may be requested again
+	if err := m.orderRepo.ReleaseOrderReturn(ctx, ret.OrderID, ret.Lines); err != nil {
+		fmt.Printf("ERROR: Rejected return %s still counted on order %s: %v\n", ret.ID, ret.OrderID, err)
+	}
+	return ret, nil
+}
+
+// Receive books an approved return in at location (the return's hub when empty), restocking
+// each line in IMS with its disposition: bySKU when given, otherwise fallback
+func (m *ReturnManager) Receive(ctx context.Context, returnID, location string, fallback models.Disposition, bySKU map[string]models.Disposition) (*models.ReturnRequest, error) {
+	return m.transition(ctx, returnID, models.ReturnReceived, func(ret *models.ReturnRequest) error {
+		if location = strings.TrimSpace(location); location != "" {
+			ret.Location = location
+		}
+		if err := ret.AssignDispositions(fallback, bySKU); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidReturn, err)
+		}
+		// a split order was reduced, and its serials allocated, under its sub-shipment IDs
+		order, err := m.orderRepo.GetOrderByID(ctx, ret.OrderID)
+		if err != nil {
+			return err
+		}
+		var shipmentIDs []string
+		for _, sub := range order.SubShipments {
+			shipmentIDs = append(shipmentIDs, sub.ID)
+		}
+		for _, line := range ret.Lines {
+			err := m.imsClient.RestockReturn(ret.TenantID, ReturnRestock{
+				ReturnID:    ret.ID,
+				OrderID:     ret.OrderID,
+				ShipmentIDs: shipmentIDs,
+				SKU:         line.SKU,
+				Location:    ret.Location,
+				SellerID:    ret.SellerID,
+				Quantity:    line.Quantity,
+				Disposition: string(line.Disposition),
+				Reason:      line.Reason,
+			})
+			if err != nil {
+				return fmt.Errorf("%w: sku %s: %v", ErrRestockFailed, line.SKU, err)
+			}
+			fmt.Printf("Return line restocked - ReturnID: %s, SKU: %s, Quantity: %d, Disposition: %s\n", ret.ID, line.SKU, line.Quantity, line.Disposition)
+		}
+		return nil
+	})
+}
+
+// transition loads the return, checks it may move to next, applies prepare and saves it
+// only if no other request moved it meanwhile
+func (m *ReturnManager) transition(ctx context.Context, returnID string, next models.ReturnStatus, prepare func(*models.ReturnRequest) error) (*models.ReturnRequest, error) {
+	ret, err := m.returnRepo.GetReturnByID(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	from := ret.Status
+	if !from.CanBecome(next) {
+		return nil, fmt.Errorf("%w: %s to %s", models.ErrReturnTransition, from, next)
+	}
+	if err := prepare(ret); err != nil {
+		return nil, err
+	}
+	if err := ret.Transition(next, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := m.returnRepo.UpdateReturn(ctx, ret, from); err != nil {
+		return nil, err
+	}
+	m.emit(ctx, "return."+string(next), ret)
+	return ret, nil
+}
+
+func (m *ReturnManager) emit(ctx context.Context, name string, ret *models.ReturnRequest) {
+	_ = webhook.LogWebhookEvent(ctx, name, ret)
+	if m.kafkaProducer == nil {
+		return
+	}
+	event := ReturnEvent{
+		Event:      name,
+		ReturnID:   ret.ID,
+		OrderID:    ret.OrderID,
+		TenantID:   ret.TenantID,
+		SellerID:   ret.SellerID,
+		Location:   ret.Location,
+		Status:     string(ret.Status),
+		Lines:      ret.Lines,
+		Reason:     ret.RejectReason,
+		OccurredAt: ret.UpdatedAt.Format(time.RFC3339),
+	}
+	if err := m.kafkaProducer.Publish(ctx, m.topic, ret.OrderID, event); err != nil {
+		fmt.Printf("ERROR: Failed to publish %s for return %s: %v\n", name, ret.ID, err)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -76,6 +78,9 @@ func (s *S3UploaderImpl) UploadFile(ctx context.Context, fileContent []byte, fil
 	}
 
 	contentType := "text/csv"
+	if format, err := DetectUploadFormat(filename); err == nil {
+		contentType = format.ContentType()
+	}
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      &s.bucket,
 		Key:         &key,
@@ -89,4 +94,34 @@ func (s *S3UploaderImpl) UploadFile(ctx context.Context, fileContent []byte, fil
 	s3Path := fmt.Sprintf("s3://%s/%s", s.bucket, key)
 	fmt.Println("Upload complete:", s3Path)
 	return s3Path, nil
-}
\ No newline at end of file
+}
+
+// UploadExport uploads an order export under exports/ and returns its s3:// path
+func (s *S3UploaderImpl) UploadExport(ctx context.Context, body io.Reader, filename, contentType string) (string, error) {
+	key := fmt.Sprintf("exports/%s", filename)
+	fmt.Printf("Uploading export to S3: %s/%s\n", s.bucket, key)
+
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &s.bucket,
+		Key:         &key,
+		Body:        body,
+		ContentType: &contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("upload error: %w", err)
+	}
+	return fmt.Sprintf("s3://%s/%s", s.bucket, key), nil
+}
+
+// PresignDownload returns a link that downloads the object at s3Path for ttl
+func (s *S3UploaderImpl) PresignDownload(ctx context.Context, s3Path string, ttl time.Duration) (string, error) {
+	key := strings.TrimPrefix(s3Path, fmt.Sprintf("s3://%s/", s.bucket))
+	request, err := s3.NewPresignClient(s.client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("presign error: %w", err)
+	}
+	return request.URL, nil
+}
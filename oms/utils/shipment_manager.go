@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"oms/database"
+	"oms/models"
+	"oms/webhook"
+)
+
+var (
+	// ErrAlreadyShipped is returned when an order already has a shipment that is not in exception
+	ErrAlreadyShipped = errors.New("order already has a shipment")
+	// ErrCarrierFailed marks a carrier that could not quote, label or track a shipment
+	ErrCarrierFailed = errors.New("carrier request failed")
+)
+
+// shipmentPollBatch caps how many shipments one poll tracks
+const shipmentPollBatch = 200
+
+// ShipmentEvent is published whenever a shipment's status changes
+type ShipmentEvent struct {
+	ShipmentID     string `json:"shipment_id"`
+	OrderID        string `json:"order_id"`
+	TenantID       string `json:"tenant_id"`
+	SellerID       string `json:"seller_id"`
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+	Status         string `json:"status"`
+	OrderStatus    string `json:"order_status"`
+	OccurredAt     string `json:"occurred_at"`
+}
+
+// ShipmentStore is the shipment storage the manager books and tracks shipments in
+type ShipmentStore interface {
+	SaveShipment(ctx context.Context, shipment *models.Shipment) error
+	GetShipmentByID(ctx context.Context, shipmentID string) (*models.Shipment, error)
+	GetShipmentsByOrder(ctx context.Context, orderID string) ([]models.Shipment, error)
+	GetActiveShipments(ctx context.Context, limit int) ([]models.Shipment, error)
+	UpdateShipment(ctx context.Context, shipment *models.Shipment) error
+}
+
+// ShipmentOrderStore is what the manager reads and moves on the orders it ships
+type ShipmentOrderStore interface {
+	GetOrderByID(ctx context.Context, orderID string) (*models.Order, error)
+	AdvanceOrderStatus(ctx context.Context, orderID string, from, newStatus models.OrderStatus) (bool, error)
+}
+
+// ShipmentManager books orders with carriers and polls them for status. Carrier updates
+// move orders to shipped and delivered; each change is logged as a webhook event and
+// published on Kafka.
+type ShipmentManager struct {
+	shipmentRepo  ShipmentStore
+	orderRepo     ShipmentOrderStore
+	carriers      *CarrierRegistry
+	kafkaProducer *KafkaProducer
+	topic         string
+	interval      time.Duration
+}
+
+func NewShipmentManager(shipmentRepo ShipmentStore, orderRepo ShipmentOrderStore, carriers *CarrierRegistry, kafkaProducer *KafkaProducer, topic string, interval time.Duration) *ShipmentManager {
+	return &ShipmentManager{
+		shipmentRepo:  shipmentRepo,
+		orderRepo:     orderRepo,
+		carriers:      carriers,
+		kafkaProducer: kafkaProducer,
+		topic:         topic,
+		interval:      interval,
+	}
+}
+
+// Quote collects rates for an order from carrierName, or from every carrier when it is empty
+func (m *ShipmentManager) Quote(ctx context.Context, orderID, carrierName string) ([]models.RateQuote, error) {
+	order, err := m.orderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	names := m.carriers.Names()
+	if carrierName != "" {
+		names = []string{carrierName}
+	}
+
+	parcel, err := m.nextParcel(ctx, order)
+	if errors.Is(err, ErrAlreadyShipped) {
+		parcel, err = order.Parcels()[0], nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := []models.RateQuote{}
+	for _, name := range names {
+		carrier, err := m.carriers.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		rates, err := carrier.Quote(ctx, shipmentRequest(order, parcel))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s quote: %v", ErrCarrierFailed, name, err)
+		}
+		quotes = append(quotes, rates...)
+	}
+	return quotes, nil
+}
+
+// Ship books the order's next unshipped parcel with carrierName's service and stores the
+// labelled shipment. A split order ships one parcel per fulfilled sub-shipment.
+func (m *ShipmentManager) Ship(ctx context.Context, orderID, carrierName, service string) (*models.Shipment, error) {
+	carrier, err := m.carriers.Get(carrierName)
+	if err != nil {
+		return nil, err
+	}
+	order, err := m.orderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := order.CheckShippable(); err != nil {
+		return nil, err
+	}
+	parcel, err := m.nextParcel(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	label, err := carrier.CreateLabel(ctx, shipmentRequest(order, parcel), service)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s label: %v", ErrCarrierFailed, carrierName, err)
+	}
+	shipment, err := models.NewShipment(order, parcel, label.Rate, label.TrackingNumber, label.LabelURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.shipmentRepo.SaveShipment(ctx, shipment); err != nil {
+		if errors.Is(err, database.ErrParcelBooked) {
+			return nil, fmt.Errorf("%w: %s", ErrAlreadyShipped, parcel.Key(order.ID))
+		}
+		return nil, err
+	}
+	m.emit(ctx, shipment, order.Status)
+	return shipment, nil
+}
+
+// nextParcel picks the first of order's parcels without a shipment outside exception
+func (m *ShipmentManager) nextParcel(ctx context.Context, order *models.Order) (models.Parcel, error) {
+	existing, err := m.shipmentRepo.GetShipmentsByOrder(ctx, order.ID)
+	if err != nil {
+		return models.Parcel{}, err
+	}
+	booked := make(map[string]string)
+	for _, s := range existing {
+		if s.Status != models.ShipmentException {
+			booked[s.ParcelKey()] = s.ID
+		}
+	}
+	parcels := order.Parcels()
+	if len(parcels) == 0 {
+		return models.Parcel{}, fmt.Errorf("%w: no sub-shipment has reserved stock", models.ErrOrderNotShippable)
+	}
+	var last string
+	for _, parcel := range parcels {
+		id, ok := booked[parcel.Key(order.ID)]
+		if !ok {
+			return parcel, nil
+		}
+		last = id
+	}
+	return models.Parcel{}, fmt.Errorf("%w: %s", ErrAlreadyShipped, last)
+}
+
+// Start polls carriers every interval until ctx is done
+func (m *ShipmentManager) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Poll(ctx)
+		}
+	}
+}
+
+// Poll tracks the shipments the carriers may still update, least recently polled first
+func (m *ShipmentManager) Poll(ctx context.Context) {
+	shipments, err := m.shipmentRepo.GetActiveShipments(ctx, shipmentPollBatch)
+	if err != nil {
+		fmt.Println("ERROR: Failed to load shipments to poll:", err)
+		return
+	}
+	for i := range shipments {
+		if _, err := m.track(ctx, &shipments[i]); err != nil {
+			fmt.Printf("ERROR: Tracking shipment %s failed: %v\n", shipments[i].ID, err)
+		}
+	}
+}
+
+// Refresh polls one shipment's carrier right away
+func (m *ShipmentManager) Refresh(ctx context.Context, shipmentID string) (*models.Shipment, error) {
+	shipment, err := m.shipmentRepo.GetShipmentByID(ctx, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if shipment.Status.IsFinal() {
+		return shipment, nil
+	}
+	return m.track(ctx, shipment)
+}
+
+// track applies the carrier's current status to shipment and brings its order up to
+// date with it, also when an earlier poll updated the shipment but not the order
+func (m *ShipmentManager) track(ctx context.Context, shipment *models.Shipment) (*models.Shipment, error) {
+	carrier, err := m.carriers.Get(shipment.Carrier)
+	if err != nil {
+		return nil, err
+	}
+	update, err := carrier.Track(ctx, shipment.TrackingNumber)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s tracking: %v", ErrCarrierFailed, shipment.Carrier, err)
+	}
+	changed := shipment.ApplyTracking(update.Status, update.Events, time.Now())
+	if err := m.shipmentRepo.UpdateShipment(ctx, shipment); err != nil {
+		return nil, err
+	}
+	if changed {
+		fmt.Printf("Shipment %s is %s\n", shipment.ID, shipment.Status)
+	}
+
+	orderStatus, moved, err := m.advanceOrder(ctx, shipment)
+	if err != nil {
+		fmt.Printf("ERROR: Shipment %s updated but order %s was not: %v\n", shipment.ID, shipment.OrderID, err)
+	}
+	if changed || moved {
+		m.emit(ctx, shipment, orderStatus)
+	}
+	return shipment, nil
+}
+
+// advanceOrder moves the shipment's order forward when it is behind its parcels and
+// returns the order's resulting status and whether it moved. A split order only moves
+// once every parcel got that far, so its other parcels stay bookable until then.
+func (m *ShipmentManager) advanceOrder(ctx context.Context, shipment *models.Shipment) (models.OrderStatus, bool, error) {
+	order, err := m.orderRepo.GetOrderByID(ctx, shipment.OrderID)
+	if err != nil {
+		return "", false, err
+	}
+	shipments, err := m.shipmentRepo.GetShipmentsByOrder(ctx, order.ID)
+	if err != nil {
+		return order.Status, false, err
+	}
+	status, ok := order.ParcelStatus(shipments)
+	if !ok {
+		return order.Status, false, nil
+	}
+	next, ok := models.OrderStatusAfter(order.Status, status)
+	if !ok {
+		return order.Status, false, nil
+	}
+	moved, err := m.orderRepo.AdvanceOrderStatus(ctx, order.ID, order.Status, next)
+	if err != nil || !moved {
+		return order.Status, false, err
+	}
+	order.Status = next
+	_ = webhook.LogWebhookEvent(ctx, "order."+string(next), order)
+	return next, true, nil
+}
+
+func (m *ShipmentManager) emit(ctx context.Context, shipment *models.Shipment, orderStatus models.OrderStatus) {
+	_ = webhook.LogWebhookEvent(ctx, "shipment."+string(shipment.Status), shipment)
+	if m.kafkaProducer == nil {
+		return
+	}
+	event := ShipmentEvent{
+		ShipmentID:     shipment.ID,
+		OrderID:        shipment.OrderID,
+		TenantID:       shipment.TenantID,
+		SellerID:       shipment.SellerID,
+		Carrier:        shipment.Carrier,
+		TrackingNumber: shipment.TrackingNumber,
+		Status:         string(shipment.Status),
+		OrderStatus:    string(orderStatus),
+		OccurredAt:     shipment.UpdatedAt.Format(time.RFC3339),
+	}
+	if err := m.kafkaProducer.Publish(ctx, m.topic, shipment.OrderID, event); err != nil {
+		fmt.Printf("ERROR: Failed to publish shipment event for %s: %v\n", shipment.ID, err)
+	}
+}
+
+func shipmentRequest(order *models.Order, parcel models.Parcel) ShipmentRequest {
+	return ShipmentRequest{
+		OrderID:     order.ID,
+		TenantID:    order.TenantID,
+		Origin:      parcel.Origin,
+		Destination: order.ShippingAddress,
+		Quantity:    parcel.Quantity,
+	}
+}
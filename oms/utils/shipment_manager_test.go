@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"oms/database"
+	"oms/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeShipmentStore keeps shipments in booking order and, like the active_parcel index,
+// refuses a second active shipment of a parcel
+type fakeShipmentStore struct {
+	shipments []*models.Shipment
+}
+
+func (s *fakeShipmentStore) SaveShipment(_ context.Context, shipment *models.Shipment) error {
+	for _, existing := range s.shipments {
+		if shipment.ActiveParcel != "" && existing.ActiveParcel == shipment.ActiveParcel {
+			return fmt.Errorf("%w: %s", database.ErrParcelBooked, shipment.ActiveParcel)
+		}
+	}
+	copied := *shipment
+	s.shipments = append(s.shipments, &copied)
+	return nil
+}
+
+func (s *fakeShipmentStore) GetShipmentByID(_ context.Context, shipmentID string) (*models.Shipment, error) {
+	for _, shipment := range s.shipments {
+		if shipment.ID == shipmentID {
+			copied := *shipment
+			return &copied, nil
+		}
+	}
+	return nil, database.ErrShipmentNotFound
+}
+
+func (s *fakeShipmentStore) GetShipmentsByOrder(_ context.Context, orderID string) ([]models.Shipment, error) {
+	var found []models.Shipment
+	for _, shipment := range s.shipments {
+		if shipment.OrderID == orderID {
+			found = append(found, *shipment)
+		}
+	}
+	return found, nil
+}
+
+func (s *fakeShipmentStore) GetActiveShipments(_ context.Context, limit int) ([]models.Shipment, error) {
+	var active []models.Shipment
+	for _, shipment := range s.shipments {
+		if shipment.Status != models.ShipmentDelivered && len(active) < limit {
+			active = append(active, *shipment)
+		}
+	}
+	return active, nil
+}
+
+func (s *fakeShipmentStore) UpdateShipment(_ context.Context, shipment *models.Shipment) error {
+	for i, existing := range s.shipments {
+		if existing.ID == shipment.ID {
+			copied := *shipment
+			s.shipments[i] = &copied
+			return nil
+		}
+	}
+	return database.ErrShipmentNotFound
+}
+
+func TestShipmentManagerSplitOrder(t *testing.T) {
+	order := models.NewOrder("SKU-1", "hub-a", "tenant-1", "seller-1")
+	order.Quantity = 3
+	order.Status = models.OrderStatusNewOrder
+	order.SubShipments = []models.SubShipment{
+		{ID: order.ID + "-S1", Location: "hub-b", Quantity: 2, Status: models.OrderStatusNewOrder},
+		{ID: order.ID + "-S2", Location: "hub-c", Quantity: 1, Status: models.OrderStatusNewOrder},
+	}
+	orders := newFakeOrderRepo(order)
+	shipments := &fakeShipmentStore{}
+	labelled := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	carrier := NewFakeCarrier(time.Hour)
+	carrier.now = func() time.Time { return labelled }
+	manager := NewShipmentManager(shipments, orders, NewCarrierRegistry(carrier), nil, "", time.Hour)
+	ctx := context.Background()
+	at := func(elapsed time.Duration) {
+		carrier.now = func() time.Time { return labelled.Add(elapsed) }
+		manager.Poll(ctx)
+	}
+
+	first, err := manager.Ship(ctx, order.ID, FakeCarrierName, "standard")
+	assert.NoError(t, err)
+	assert.Equal(t, "hub-b", first.Origin)
+	assert.Equal(t, order.ID+"-S1", first.SubShipmentID)
+
+	// the first parcel is on its way, the second still at its hub: the order waits
+	at(time.Hour)
+	assert.Equal(t, models.OrderStatusNewOrder, orders.orders[order.ID].Status)
+
+	second, err := manager.Ship(ctx, order.ID, FakeCarrierName, "standard")
+	assert.NoError(t, err)
+	assert.Equal(t, "hub-c", second.Origin)
+	_, err = manager.Ship(ctx, order.ID, FakeCarrierName, "standard")
+	assert.True(t, errors.Is(err, ErrAlreadyShipped))
+
+	at(2 * time.Hour)
+	assert.Equal(t, models.OrderStatusShipped, orders.orders[order.ID].Status)
+
+	// one parcel delivered is not the order delivered
+	at(3 * time.Hour)
+	assert.Equal(t, models.OrderStatusShipped, orders.orders[order.ID].Status)
+
+	at(4 * time.Hour)
+	assert.Equal(t, models.OrderStatusDelivered, orders.orders[order.ID].Status)
+}
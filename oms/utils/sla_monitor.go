@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"oms/models"
+	"oms/webhook"
+)
+
+// SLAPolicyStore looks up a tenant's dispatch cut-offs
+type SLAPolicyStore interface {
+	GetPolicy(ctx context.Context, tenantID string) (models.SLAPolicy, error)
+}
+
+// SLABreachClaimer marks orders past their deadline as breached, each only once
+type SLABreachClaimer interface {
+	ClaimSLABreaches(ctx context.Context, now time.Time, limit int) ([]models.Order, error)
+}
+
+// slaBatchSize caps how many breaches one check reports
+const slaBatchSize = 200
+
+// SLAMonitor reports open orders that passed their SLA deadline as order.sla_breached
+// on Kafka and as a webhook event
+type SLAMonitor struct {
+	orders        SLABreachClaimer
+	kafkaProducer *KafkaProducer
+	topic         string
+	interval      time.Duration
+}
+
+func NewSLAMonitor(orders SLABreachClaimer, kafkaProducer *KafkaProducer, topic string, interval time.Duration) *SLAMonitor {
+	return &SLAMonitor{orders: orders, kafkaProducer: kafkaProducer, topic: topic, interval: interval}
+}
+
+// Start checks for breaches every interval until ctx is done
+func (m *SLAMonitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx, time.Now())
+		}
+	}
+}
+
+// Check reports every order whose deadline passed before now and was not reported yet
+func (m *SLAMonitor) Check(ctx context.Context, now time.Time) {
+	for {
+		breached, err := m.orders.ClaimSLABreaches(ctx, now, slaBatchSize)
+		for i := range breached {
+			m.report(ctx, &breached[i])
+		}
+		if err != nil || len(breached) < slaBatchSize {
+			return
+		}
+	}
+}
+
+func (m *SLAMonitor) report(ctx context.Context, order *models.Order) {
+	fmt.Printf("SLA breached - OrderID: %s, Priority: %s, Deadline: %s\n", order.ID, order.Priority, formatTime(order.SLADeadline))
+	_ = webhook.LogWebhookEvent(ctx, "order.sla_breached", order)
+	if m.kafkaProducer == nil {
+		return
+	}
+	event := OrderSLABreachedEvent{
+		OrderID:     order.ID,
+		TenantID:    order.TenantID,
+		SellerID:    order.SellerID,
+		Priority:    string(order.Priority),
+		Status:      string(order.Status),
+		SLADeadline: formatTime(order.SLADeadline),
+		BreachedAt:  formatTime(order.SLABreachedAt),
+	}
+	if err := m.kafkaProducer.Publish(ctx, m.topic, order.ID, event); err != nil {
+		fmt.Printf("Kafka publish failed for SLA breach of order %s: %v\n", order.ID, err)
+	}
+}
+
+// formatTime renders an optional time as RFC3339, or empty when unset
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
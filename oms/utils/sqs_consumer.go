@@ -31,6 +31,7 @@ type ConsumerMessage struct {
 	RequestID string `json:"request_id"`
 	Path      string `json:"path"`
 	GroupID   string `json:"group_id"`
+	Sheet     string `json:"sheet,omitempty"`
 }
 
 
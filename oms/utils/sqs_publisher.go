@@ -24,6 +24,8 @@ type SQSMessage struct {
 	RequestID string `json:"request_id"`
 	Path      string `json:"path"`
 	GroupID   string `json:"group_id"`
+	// Sheet is the XLSX sheet to import; empty means the first sheet
+	Sheet     string `json:"sheet,omitempty"`
 }
 
 func NewSQSPublisher(queueName, endpoint, region string) (*SQSPublisherImpl, error) {
@@ -90,17 +92,25 @@ func NewSQSPublisher(queueName, endpoint, region string) (*SQSPublisherImpl, err
 
 
 func (s *SQSPublisherImpl) PublishS3Path(ctx context.Context, s3Path string) error {
+	_, err := s.PublishUpload(ctx, s3Path, "")
+	return err
+}
+
+// PublishUpload queues an uploaded file for processing, reading sheet when it is an XLSX
+// workbook, and returns the request ID the upload's result is kept under
+func (s *SQSPublisherImpl) PublishUpload(ctx context.Context, s3Path, sheet string) (string, error) {
 	requestID := uuid.New().String()
 
 	msg := SQSMessage{
 		RequestID: requestID,
 		Path:      s3Path,
 		GroupID:   "csv-processing",
+		Sheet:     sheet,
 	}
 
 	data, err := json.Marshal(msg)
 	if err != nil {
-		return fmt.Errorf("marshal error: %w", err)
+		return "", fmt.Errorf("marshal error: %w", err)
 	}
 
 	fmt.Printf("Sending: %s\n", requestID)
@@ -115,11 +125,11 @@ func (s *SQSPublisherImpl) PublishS3Path(ctx context.Context, s3Path string) err
 	_, err = s.client.SendMessage(message)
 	if err != nil {
 		fmt.Println("FIFO publish failed")
-		return fmt.Errorf("FIFO publish error: %w", err)
+		return "", fmt.Errorf("FIFO publish error: %w", err)
 	}
 
 	fmt.Println("FIFO message sent")
-	return nil
+	return requestID, nil
 }
 
 func (s *SQSPublisherImpl) GetQueueName() string {
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// StockIncreasedEvent is published by IMS when a (sku, hub) gains stock
+type StockIncreasedEvent struct {
+	SKU       string `json:"sku"`
+	Location  string `json:"location"`
+	TenantID  string `json:"tenant_id"`
+	SellerID  string `json:"seller_id"`
+	Previous  int    `json:"previous"`
+	Quantity  int    `json:"quantity"`
+	CreatedAt string `json:"created_at"`
+}
+
+// StockEventConsumer retries the waiting orders of a SKU as soon as IMS reports more of it
+type StockEventConsumer struct {
+	consumerGroup sarama.ConsumerGroup
+	topic         string
+	scheduler     *BackorderScheduler
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+func NewStockEventConsumer(brokers []string, topic string, scheduler *BackorderScheduler) (*StockEventConsumer, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_8_1_0
+	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
+	config.Consumer.Offsets.Initial = sarama.OffsetNewest
+
+	consumerGroup, err := sarama.NewConsumerGroup(brokers, "oms-stock-events-group", config)
+	if err != nil {
+		return nil, fmt.Errorf("new group error: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	fmt.Printf("Stock event consumer ready for topic: %s\n", topic)
+
+	return &StockEventConsumer{
+		consumerGroup: consumerGroup,
+		topic:         topic,
+		scheduler:     scheduler,
+		ctx:           ctx,
+		cancel:        cancel,
+	}, nil
+}
+
+func (c *StockEventConsumer) Start(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ctx.Done():
+				return
+			default:
+				if err := c.consumerGroup.Consume(c.ctx, []string{c.topic}, c); err != nil {
+					fmt.Printf("Stock event consume error: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+func (c *StockEventConsumer) Stop() error {
+	c.cancel()
+	c.wg.Wait()
+	return c.consumerGroup.Close()
+}
+
+func (c *StockEventConsumer) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (c *StockEventConsumer) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim retries waiting orders for each event; malformed events are skipped
+func (c *StockEventConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			var event StockIncreasedEvent
+			if err := json.Unmarshal(message.Value, &event); err != nil {
+				fmt.Printf("Stock event parse error: %v\n", err)
+			} else {
+				fmt.Printf("Stock increased - SKU: %s, Location: %s, Tenant: %s, %d -> %d\n",
+					event.SKU, event.Location, event.TenantID, event.Previous, event.Quantity)
+				c.scheduler.RetryWaiting(session.Context(), event.TenantID, event.SKU)
+			}
+			session.MarkMessage(message, "")
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// UploadFormat is the file format of a bulk order upload
+type UploadFormat string
+
+const (
+	UploadCSV    UploadFormat = "csv"
+	UploadXLSX   UploadFormat = "xlsx"
+	UploadNDJSON UploadFormat = "ndjson"
+)
+
+// ErrUnsupportedUpload is returned for uploads that are not CSV, XLSX or NDJSON
+var ErrUnsupportedUpload = errors.New("unsupported upload format")
+
+// maxNDJSONLine caps the length of one NDJSON order
+const maxNDJSONLine = 1 << 20
+
+// DetectUploadFormat picks the upload format from a file name or S3 path's extension.
+// .jsonl is accepted as NDJSON.
+func DetectUploadFormat(filename string) (UploadFormat, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return UploadCSV, nil
+	case ".xlsx":
+		return UploadXLSX, nil
+	case ".ndjson", ".jsonl":
+		return UploadNDJSON, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedUpload, filename)
+	}
+}
+
+func (f UploadFormat) ContentType() string {
+	switch f {
+	case UploadXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case UploadNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "text/csv"
+	}
+}
+
+// uploadRecord is one order read from an XLSX or NDJSON upload, keyed by column name
+type uploadRecord struct {
+	line   int
+	values map[string]string
+	err    error
+}
+
+// ParseUpload parses an upload in any format into the same result ParseCSVFromBytes
+// returns, so every format gets the same header and row validation. sheet names the
+// XLSX sheet to read; the first sheet is read when it is empty.
+func (p *CSVParser) ParseUpload(ctx context.Context, data []byte, format UploadFormat, sheet string) (*CSVParseResult, error) {
+	var (
+		headers []string
+		records []uploadRecord
+		err     error
+	)
+	switch format {
+	case UploadCSV:
+		return p.ParseCSVFromBytes(ctx, data)
+	case UploadXLSX:
+		headers, records, err = readXLSXRecords(data, sheet)
+	case UploadNDJSON:
+		headers, records, err = readNDJSONRecords(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedUpload, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s upload: %w", format, err)
+	}
+
+	fmt.Printf("%s Headers: %v\n", strings.ToUpper(string(format)), headers)
+	if err := p.validateHeaders(headers); err != nil {
+		return nil, fmt.Errorf("invalid %s headers: %w", format, err)
+	}
+
+	result := &CSVParseResult{
+		Headers:     headers,
+		ValidData:   make([]CSVRow, 0),
+		InvalidData: make([]CSVRow, 0),
+		ErrorRows:   make([]int, 0),
+	}
+	for _, record := range records {
+		row, err := record.row()
+		if err == nil {
+			err = p.validateRow(row)
+		}
+		if err != nil {
+			result.rejectRow(row, err)
+		} else {
+			result.ValidData = append(result.ValidData, row)
+			result.ValidRows++
+		}
+		result.TotalRows++
+	}
+
+	fmt.Printf("%s parsing completed - Total: %d, Valid: %d, Invalid: %d\n",
+		strings.ToUpper(string(format)), result.TotalRows, result.ValidRows, result.InvalidRows)
+	return result, nil
+}
+
+// row maps the record's columns onto a CSVRow by the row's JSON field names, the same
+// names CSV headers use
+func (r uploadRecord) row() (CSVRow, error) {
+	row := CSVRow{RowNumber: r.line}
+	if r.err != nil {
+		return row, r.err
+	}
+	fields := make(map[string]string, len(r.values))
+	for k, v := range r.values {
+		if k != "row_number" {
+			fields[k] = v
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return row, err
+	}
+	if err := json.Unmarshal(data, &row); err != nil {
+		return row, err
+	}
+	row.RowNumber = r.line
+	return row, nil
+}
+
+// readXLSXRecords reads sheet (or the workbook's first sheet) with its first row as the
+// header. Blank rows are skipped; record lines are spreadsheet row numbers.
+func readXLSXRecords(data []byte, sheet string) ([]string, []uploadRecord, error) {
+	file, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, nil, fmt.Errorf("workbook has no sheets")
+	}
+	if sheet == "" {
+		sheet = sheets[0]
+	} else if idx, _ := file.GetSheetIndex(sheet); idx < 0 {
+		return nil, nil, fmt.Errorf("sheet %q not found", sheet)
+	}
+
+	rows, err := file.Rows(sheet)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var headers []string
+	var records []uploadRecord
+	for line := 1; rows.Next(); line++ {
+		cells, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, nil, fmt.Errorf("row %d: %w", line, err)
+		}
+		if isBlankRow(cells) {
+			continue
+		}
+		if headers == nil {
+			for _, c := range cells {
+				headers = append(headers, strings.ToLower(strings.TrimSpace(c)))
+			}
+			continue
+		}
+		values := make(map[string]string, len(headers))
+		for i, h := range headers {
+			if h != "" && i < len(cells) {
+				values[h] = strings.TrimSpace(cells[i])
+			}
+		}
+		records = append(records, uploadRecord{line: line, values: values})
+	}
+	if err := rows.Error(); err != nil {
+		return nil, nil, err
+	}
+	if headers == nil {
+		return nil, nil, fmt.Errorf("sheet %q is empty", sheet)
+	}
+	return headers, records, nil
+}
+
+func isBlankRow(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// readNDJSONRecords reads one JSON object per line. The headers are every key seen, in
+// the order they first appear (alphabetical within a line). Numbers and booleans become
+// their text; a line that is not a flat JSON object is kept as an invalid record rather
+// than failing the upload.
+func readNDJSONRecords(data []byte) ([]string, []uploadRecord, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+
+	var headers []string
+	seen := make(map[string]bool)
+	var records []uploadRecord
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		values, err := ndjsonValues(text)
+		if err != nil {
+			records = append(records, uploadRecord{line: line, err: fmt.Errorf("line %d: %w", line, err)})
+			continue
+		}
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		headers = append(headers, keys...)
+		records = append(records, uploadRecord{line: line, values: values})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("file has no orders")
+	}
+	return headers, records, nil
+}
+
+func ndjsonValues(text []byte) (map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(object))
+	for k, v := range object {
+		key := strings.ToLower(strings.TrimSpace(k))
+		switch v := v.(type) {
+		case nil:
+			values[key] = ""
+		case string:
+			values[key] = strings.TrimSpace(v)
+		case json.Number:
+			values[key] = v.String()
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("%s must be a string, number or boolean", key)
+		}
+	}
+	return values, nil
+}
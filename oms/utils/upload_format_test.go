@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func workbook(t *testing.T, sheets map[string][][]interface{}) []byte {
+	file := excelize.NewFile()
+	defer file.Close()
+	for name, rows := range sheets {
+		if name != "Sheet1" {
+			_, err := file.NewSheet(name)
+			assert.NoError(t, err)
+		}
+		for i, row := range rows {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			assert.NoError(t, file.SetSheetRow(name, cell, &row))
+		}
+	}
+	var buf bytes.Buffer
+	_, err := file.WriteTo(&buf)
+	assert.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestDetectUploadFormat(t *testing.T) {
+	for name, want := range map[string]UploadFormat{
+		"orders.csv":                        UploadCSV,
+		"Orders.XLSX":                       UploadXLSX,
+		"orders.ndjson":                     UploadNDJSON,
+		"s3://bucket/csv-uploads/1-o.jsonl": UploadNDJSON,
+	} {
+		got, err := DetectUploadFormat(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+	_, err := DetectUploadFormat("orders.xls")
+	assert.ErrorIs(t, err, ErrUnsupportedUpload)
+}
+
+func TestCSVParser_ParseUpload_XLSX(t *testing.T) {
+	data := workbook(t, map[string][][]interface{}{
+		"Sheet1": {
+			{"SKU", "Location", "tenant_id", "seller_id", "quantity", "unit_price", "currency"},
+			{"sku1", "loc1", "tenant1", "seller1", 2, 9.5, "USD"},
+			{},
+			{"", "loc2", "tenant2", "seller2"},
+		},
+		"Returns": {
+			{"sku", "tenant_id", "seller_id"},
+			{"sku9", "tenant9", "seller9"},
+		},
+	})
+	parser := NewCSVParser(10)
+
+	result, err := parser.ParseUpload(context.Background(), data, UploadXLSX, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.TotalRows)
+	assert.Equal(t, 1, result.ValidRows)
+	assert.Equal(t, []int{4}, result.ErrorRows)
+	row := result.ValidData[0]
+	assert.Equal(t, "sku1", row.SKU)
+	assert.Equal(t, "loc1", row.Location)
+	assert.Equal(t, "2", row.Quantity)
+	assert.Equal(t, "9.5", row.UnitPrice)
+	assert.Equal(t, 2, row.RowNumber)
+
+	result, err = parser.ParseUpload(context.Background(), data, UploadXLSX, "Returns")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.ValidRows)
+	assert.Equal(t, "sku9", result.ValidData[0].SKU)
+
+	_, err = parser.ParseUpload(context.Background(), data, UploadXLSX, "Missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `sheet "Missing" not found`)
+}
+
+func TestCSVParser_ParseUpload_NDJSON(t *testing.T) {
+	data := []byte(`{"sku":"sku1","tenant_id":"tenant1","seller_id":"seller1","quantity":3,"unit_price":12.25,"currency":"EUR"}
+
+{"sku":"sku2","tenant_id":"tenant2","seller_id":"seller2","customer":{"name":"x"}}
+not json
+{"sku":"","tenant_id":"tenant3","seller_id":"seller3"}
+`)
+	result, err := NewCSVParser(10).ParseUpload(context.Background(), data, UploadNDJSON, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.TotalRows)
+	assert.Equal(t, 1, result.ValidRows)
+	assert.Equal(t, []int{3, 4, 5}, result.ErrorRows)
+	assert.Equal(t, "3", result.ValidData[0].Quantity)
+	assert.Equal(t, "12.25", result.ValidData[0].UnitPrice)
+	assert.Equal(t, 1, result.ValidData[0].RowNumber)
+}
+
+func TestCSVParser_ParseUpload_InvalidHeaders(t *testing.T) {
+	data := []byte(`{"sku":"sku1","tenant_id":"tenant1"}`)
+	result, err := NewCSVParser(10).ParseUpload(context.Background(), data, UploadNDJSON, "")
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "missing required header: seller_id")
+}
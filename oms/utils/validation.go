@@ -46,12 +46,16 @@ func (v *CSVRowValidator) ValidateCSVRow(ctx context.Context, row CSVRow) Valida
 	}
 	result.SKUValid = skuValid
 
-	hubValid, err := v.imsClient.ValidateHub(row.Location, row.TenantID, row.SellerID)
-	if err != nil {
-		fmt.Printf("Hub check failed for row %d\n", row.RowNumber)
-		result.IsValid = false
-		result.Reason = fmt.Sprintf("Hub error: %v", err)
-		return result
+	// a row without a location is allocated a hub after validation
+	hubValid := true
+	if row.Location != "" {
+		hubValid, err = v.imsClient.ValidateHub(row.Location, row.TenantID, row.SellerID)
+		if err != nil {
+			fmt.Printf("Hub check failed for row %d\n", row.RowNumber)
+			result.IsValid = false
+			result.Reason = fmt.Sprintf("Hub error: %v", err)
+			return result
+		}
 	}
 	result.HubValid = hubValid
 